@@ -222,6 +222,53 @@ type Profile struct {
 	Location          Location   `json:"location"`
 	Credential        Credential `json:"credential"`
 	SkipSSLVerify     bool       `json:"skipSSLVerify"`
+	// Encryption enables client-side encryption of the artifacts that are
+	// written with kando and the location package.
+	Encryption *ClientSideEncryption `json:"encryption,omitempty"`
+	// BandwidthLimit is the default bandwidth limit of the functions and
+	// kando commands that move data to and from the location.
+	BandwidthLimit BandwidthLimit `json:"bandwidthLimit,omitempty"`
+	// CABundle holds the certificates of the CAs that are trusted, in
+	// addition to the system CAs, when connecting to the location.
+	CABundle *CABundle `json:"caBundle,omitempty"`
+	// Proxy is the URL of the HTTP(S) proxy through which the location is
+	// reached, e.g. http://proxy.example.com:3128.
+	Proxy string `json:"proxy,omitempty"`
+	// RepositoryPasswordSecret stores the passwords of the restic
+	// repositories that functions create without an explicit encryption
+	// key. A random password is generated for each new repository. The
+	// namespace defaults to the namespace of the Profile.
+	RepositoryPasswordSecret *ObjectReference `json:"repositoryPasswordSecret,omitempty"`
+	// DataMover selects the backend of the data functions, e.g. Restic,
+	// which is the default.
+	DataMover string `json:"dataMover,omitempty"`
+}
+
+// CABundle references a PEM encoded bundle of CA certificates in a Secret or
+// a ConfigMap. Exactly one of them must be set.
+type CABundle struct {
+	Secret    *ObjectReference `json:"secret,omitempty"`
+	ConfigMap *ObjectReference `json:"configMap,omitempty"`
+	// Key of the bundle in the Secret or ConfigMap
+	Key string `json:"key"`
+}
+
+// BandwidthLimit limits the rate of data transfers in KiB/s. Zero means no
+// limit.
+type BandwidthLimit struct {
+	Upload   int64 `json:"upload,omitempty"`
+	Download int64 `json:"download,omitempty"`
+}
+
+// ClientSideEncryption references the keys that wrap the data keys of
+// encrypted artifacts.
+type ClientSideEncryption struct {
+	// Secret holds the 32 byte key encryption keys under their key IDs.
+	// Keys that are no longer used for new artifacts can be kept in the
+	// Secret to read older artifacts.
+	Secret ObjectReference `json:"secret"`
+	// KeyID is the key in Secret that wraps the data keys of new artifacts.
+	KeyID string `json:"keyID"`
 }
 
 // LocationType
@@ -231,6 +278,8 @@ const (
 	LocationTypeGCS         LocationType = "gcs"
 	LocationTypeS3Compliant LocationType = "s3Compliant"
 	LocationTypeAzure       LocationType = "azure"
+	LocationTypeFileSystem  LocationType = "filesystem"
+	LocationTypeSFTP        LocationType = "sftp"
 )
 
 // Location
@@ -240,6 +289,59 @@ type Location struct {
 	Endpoint string       `json:"endpoint"`
 	Prefix   string       `json:"prefix"`
 	Region   string       `json:"region"`
+	// Path is the directory under which a filesystem or sftp location
+	// stores its artifacts. A filesystem location must be mounted at the
+	// same path in every Pod that accesses it.
+	Path string `json:"path,omitempty"`
+	// ClaimName is the PVC that backs a filesystem location. Pods created
+	// by Kanister functions mount it at Path.
+	ClaimName string `json:"claimName,omitempty"`
+	// Host and Port of the server for sftp locations. Port defaults to 22.
+	Host string `json:"host,omitempty"`
+	Port int    `json:"port,omitempty"`
+	// HostKey is the public key of the sftp server, in authorized_keys
+	// format. It is required unless SkipSSLVerify is set.
+	HostKey string `json:"hostKey,omitempty"`
+	// ServerSideEncryption is the encryption that the object store applies
+	// to the artifacts Kanister writes.
+	ServerSideEncryption ServerSideEncryption `json:"serverSideEncryption,omitempty"`
+	// Retention locks the artifacts Kanister writes against deletion and
+	// overwrites. The bucket must support object lock.
+	Retention Retention `json:"retention,omitempty"`
+}
+
+// ServerSideEncryption configures customer-managed encryption at rest.
+// Restic cannot request it for the objects it writes, so the data functions
+// require the default encryption of the bucket to match these settings.
+type ServerSideEncryption struct {
+	// Type is the S3 encryption, either "AES256" (SSE-S3) or "aws:kms"
+	// (SSE-KMS).
+	Type string `json:"type,omitempty"`
+	// KMSKeyID is the SSE-KMS key ID for S3 compliant locations or the
+	// Cloud KMS key name (CMEK) for GCS locations.
+	KMSKeyID string `json:"kmsKeyID,omitempty"`
+	// EncryptionScope is the encryption scope for Azure locations.
+	EncryptionScope string `json:"encryptionScope,omitempty"`
+}
+
+// RetentionMode
+type RetentionMode string
+
+const (
+	// RetentionModeGovernance locks can be removed by privileged users.
+	RetentionModeGovernance RetentionMode = "governance"
+	// RetentionModeCompliance locks cannot be removed until they expire.
+	RetentionModeCompliance RetentionMode = "compliance"
+)
+
+// Retention configures object lock (WORM) retention. S3 compliant locations
+// need a bucket with Object Lock enabled, GCS locations a bucket retention
+// policy of at least Duration, locked in compliance mode, and Azure
+// locations a container with version-level immutability.
+type Retention struct {
+	Mode RetentionMode `json:"mode,omitempty"`
+	// Duration for which new artifacts are locked
+	Duration metav1.Duration `json:"duration,omitempty"`
 }
 
 // CredentialType
@@ -247,6 +247,22 @@ func (in *Artifact) DeepCopy() *Artifact {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BandwidthLimit) DeepCopyInto(out *BandwidthLimit) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BandwidthLimit.
+func (in *BandwidthLimit) DeepCopy() *BandwidthLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(BandwidthLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Blueprint) DeepCopyInto(out *Blueprint) {
 	*out = *in
@@ -380,6 +396,49 @@ func (in *BlueprintPhase) DeepCopy() *BlueprintPhase {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CABundle) DeepCopyInto(out *CABundle) {
+	*out = *in
+	if in.Secret != nil {
+		in, out := &in.Secret, &out.Secret
+		*out = new(ObjectReference)
+		**out = **in
+	}
+	if in.ConfigMap != nil {
+		in, out := &in.ConfigMap, &out.ConfigMap
+		*out = new(ObjectReference)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CABundle.
+func (in *CABundle) DeepCopy() *CABundle {
+	if in == nil {
+		return nil
+	}
+	out := new(CABundle)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClientSideEncryption) DeepCopyInto(out *ClientSideEncryption) {
+	*out = *in
+	out.Secret = in.Secret
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClientSideEncryption.
+func (in *ClientSideEncryption) DeepCopy() *ClientSideEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(ClientSideEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Credential) DeepCopyInto(out *Credential) {
 	*out = *in
@@ -421,6 +480,8 @@ func (in *KeyPair) DeepCopy() *KeyPair {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Location) DeepCopyInto(out *Location) {
 	*out = *in
+	out.ServerSideEncryption = in.ServerSideEncryption
+	out.Retention = in.Retention
 	return
 }
 
@@ -467,6 +528,22 @@ func (in *Profile) DeepCopyInto(out *Profile) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Location = in.Location
 	in.Credential.DeepCopyInto(&out.Credential)
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(ClientSideEncryption)
+		**out = **in
+	}
+	out.BandwidthLimit = in.BandwidthLimit
+	if in.CABundle != nil {
+		in, out := &in.CABundle, &out.CABundle
+		*out = new(CABundle)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RepositoryPasswordSecret != nil {
+		in, out := &in.RepositoryPasswordSecret, &out.RepositoryPasswordSecret
+		*out = new(ObjectReference)
+		**out = **in
+	}
 	return
 }
 
@@ -524,3 +601,36 @@ func (in *ProfileList) DeepCopyObject() runtime.Object {
 	}
 	return nil
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Retention) DeepCopyInto(out *Retention) {
+	*out = *in
+	out.Duration = in.Duration
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Retention.
+func (in *Retention) DeepCopy() *Retention {
+	if in == nil {
+		return nil
+	}
+	out := new(Retention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ServerSideEncryption) DeepCopyInto(out *ServerSideEncryption) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ServerSideEncryption.
+func (in *ServerSideEncryption) DeepCopy() *ServerSideEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(ServerSideEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
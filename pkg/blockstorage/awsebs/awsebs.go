@@ -13,6 +13,7 @@ import (
 	"github.com/aws/aws-sdk-go/aws/ec2metadata"
 	"github.com/aws/aws-sdk-go/aws/session"
 	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/sts"
 	"github.com/jpillora/backoff"
 	"github.com/kanisterio/kanister/pkg/poll"
 	"github.com/pkg/errors"
@@ -25,6 +26,7 @@ import (
 
 var _ blockstorage.Provider = (*ebsStorage)(nil)
 var _ zone.Mapper = (*ebsStorage)(nil)
+var _ blockstorage.SnapshotSharer = (*ebsStorage)(nil)
 
 type ebsStorage struct {
 	ec2Cli *EC2
@@ -223,6 +225,47 @@ func (s *ebsStorage) SnapshotsList(ctx context.Context, tags map[string]string)
 	return snaps, nil
 }
 
+// AccountID returns the ID of the AWS account of the credentials
+func (s *ebsStorage) AccountID(ctx context.Context) (string, error) {
+	sess, err := session.NewSession(s.ec2Cli.Config.Copy())
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to create session")
+	}
+	user, err := sts.New(sess).GetCallerIdentityWithContext(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to get user")
+	}
+	if aws.StringValue(user.Account) == "" {
+		return "", errors.New("Account ID is empty")
+	}
+	return aws.StringValue(user.Account), nil
+}
+
+// SnapshotShare adds a create volume permission for the account to the
+// snapshot, which also allows the account to copy it. Snapshots encrypted
+// with a KMS key can only be copied if the key policy allows the account to
+// use the key as well.
+func (s *ebsStorage) SnapshotShare(ctx context.Context, snapshot blockstorage.Snapshot, accountID string) error {
+	ec2Cli := s.ec2Cli
+	if snapshot.Region != "" && snapshot.Region != aws.StringValue(s.ec2Cli.Config.Region) {
+		var err error
+		if ec2Cli, err = newEC2Client(snapshot.Region, s.ec2Cli.Config.Copy()); err != nil {
+			return errors.Wrap(err, "Could not get EC2 client")
+		}
+	}
+	msi := &ec2.ModifySnapshotAttributeInput{
+		SnapshotId: aws.String(snapshot.ID),
+		Attribute:  aws.String(ec2.SnapshotAttributeNameCreateVolumePermission),
+		CreateVolumePermission: &ec2.CreateVolumePermissionModifications{
+			Add: []*ec2.CreateVolumePermission{{UserId: aws.String(accountID)}},
+		},
+	}
+	if _, err := ec2Cli.ModifySnapshotAttributeWithContext(ctx, msi); err != nil {
+		return errors.Wrapf(err, "Failed to share snapshot %s with account %s", snapshot.ID, accountID)
+	}
+	return nil
+}
+
 // SnapshotCopy copies snapshot 'from' to 'to'. Follows aws restrictions regarding encryption;
 // i.e., copying unencrypted to encrypted snapshot is allowed but not vice versa.
 func (s *ebsStorage) SnapshotCopy(ctx context.Context, from, to blockstorage.Snapshot) (*blockstorage.Snapshot, error) {
@@ -232,8 +275,9 @@ func (s *ebsStorage) SnapshotCopy(ctx context.Context, from, to blockstorage.Sna
 	if to.ID != "" {
 		return nil, errors.Errorf("Snapshot %v destination ID must be empty", to)
 	}
-	// Copy operation must be initiated from the destination region.
-	ec2Cli, err := newEC2Client(to.Region, nil)
+	// Copy operation must be initiated from the destination region, with
+	// the credentials of the destination account.
+	ec2Cli, err := newEC2Client(to.Region, s.ec2Cli.Config.Copy())
 	if err != nil {
 		return nil, errors.Wrapf(err, "Could not get EC2 client")
 	}
@@ -241,7 +285,7 @@ func (s *ebsStorage) SnapshotCopy(ctx context.Context, from, to blockstorage.Sna
 	// independent of whether or not the snapshot is encrypted.
 	var presignedURL *string
 	if to.Region != from.Region {
-		fromCli, err2 := newEC2Client(from.Region, nil)
+		fromCli, err2 := newEC2Client(from.Region, s.ec2Cli.Config.Copy())
 		if err2 != nil {
 			return nil, errors.Wrap(err2, "Could not create client to presign URL for snapshot copy request")
 		}
@@ -31,3 +31,12 @@ type RestoreTargeter interface {
 	// If not globally restorable, returns a map of the regions and zones to which snapshot can be restored.
 	SnapshotRestoreTargets(context.Context, *Snapshot) (global bool, regionsAndZones map[string][]string, err error)
 }
+
+// SnapshotSharer implements the methods that share snapshots with other accounts
+type SnapshotSharer interface {
+	// AccountID returns the ID of the account whose credentials the provider uses
+	AccountID(context.Context) (string, error)
+	// SnapshotShare allows the account to copy the snapshot and to create
+	// volumes from it.
+	SnapshotShare(ctx context.Context, snapshot Snapshot, accountID string) error
+}
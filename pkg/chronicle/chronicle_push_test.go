@@ -1,14 +1,18 @@
 package chronicle
 
 import (
+	"bytes"
 	"context"
 	"path/filepath"
+	"strconv"
 
 	. "gopkg.in/check.v1"
 	"k8s.io/apimachinery/pkg/util/rand"
 
 	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/location"
 	"github.com/kanisterio/kanister/pkg/objectstore"
+	"github.com/kanisterio/kanister/pkg/param"
 	"github.com/kanisterio/kanister/pkg/testutil"
 )
 
@@ -37,3 +41,33 @@ func (s *ChroniclePushSuite) TestPush(c *C) {
 	err = push(ctx, p, 0)
 	c.Assert(err, IsNil)
 }
+
+func (s *ChroniclePushSuite) TestPushPullMemory(c *C) {
+	prof := param.Profile{
+		Location: crv1alpha1.Location{
+			Type:     location.TypeMemory,
+			Endpoint: c.TestName(),
+			Bucket:   "chronicle",
+		},
+	}
+	defer objectstore.ResetMemoryProvider(c.TestName())
+	pp := filepath.Join(c.MkDir(), "profile.json")
+	err := writeProfile(pp, prof)
+	c.Assert(err, IsNil)
+
+	p := PushParams{
+		ProfilePath:  pp,
+		ArtifactPath: rand.String(10),
+	}
+	ctx := context.Background()
+	for i := 0; i < 3; i++ {
+		p.Command = []string{"echo", strconv.Itoa(i)}
+		err = push(ctx, p, i)
+		c.Assert(err, IsNil)
+
+		buf := bytes.NewBuffer(nil)
+		err = Pull(ctx, buf, prof, p.ArtifactPath)
+		c.Assert(err, IsNil)
+		c.Assert(buf.String(), Equals, strconv.Itoa(i)+"\n")
+	}
+}
@@ -0,0 +1,123 @@
+package datamover
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+// Type selects the implementation of a DataMover
+type Type string
+
+const (
+	// TypeRestic stores backups in restic repositories
+	TypeRestic Type = "Restic"
+)
+
+// DataMover copies data between the file system of a container and the
+// location of a Profile
+type DataMover interface {
+	// Init creates the repository if it does not exist yet
+	Init(ctx context.Context) error
+	// Create creates the repository. It fails if the repository exists.
+	Create(ctx context.Context) error
+	// Backup copies the files under the paths to the repository
+	Backup(ctx context.Context, in BackupInput) (*BackupOutput, error)
+	// Restore copies the files of a backup from the repository
+	Restore(ctx context.Context, in RestoreInput) error
+	// Snapshots lists the backups in the repository with the tag, or all
+	// backups if the tag is empty
+	Snapshots(ctx context.Context, tag string) ([]Snapshot, error)
+	// Forget removes the backup from the repository
+	Forget(ctx context.Context, id string) error
+	// Prune deletes the data that is no longer referenced by a backup
+	Prune(ctx context.Context) error
+}
+
+// Target is the container in which a DataMover runs
+type Target struct {
+	Cli       kubernetes.Interface
+	Namespace string
+	Pod       string
+	Container string
+}
+
+// Repository is where a DataMover stores backups. Path is relative to the
+// location of the Profile.
+type Repository struct {
+	Profile       *param.Profile
+	Path          string
+	EncryptionKey string
+}
+
+// BackupInput selects the files that are backed up
+type BackupInput struct {
+	// Tag identifies the backup in addition to its ID
+	Tag   string
+	Paths []string
+	// Exclude lists patterns of the paths that are not backed up
+	Exclude []string
+	// ExcludeCaches skips the directories that contain a CACHEDIR.TAG file
+	ExcludeCaches bool
+	// OneFileSystem does not cross the file system boundaries of the paths
+	OneFileSystem bool
+}
+
+// BackupOutput describes a completed backup
+type BackupOutput struct {
+	ID  string
+	Tag string
+	// Size is the size in bytes of the backed up files
+	Size int64
+	// SizeAdded is the size in bytes of the data added to the repository
+	SizeAdded int64
+	FileCount int64
+}
+
+// RestoreInput selects the backup, by ID or else by tag, and the files that
+// are restored
+type RestoreInput struct {
+	ID  string
+	Tag string
+	// Target is the directory into which the files are restored
+	Target string
+	// Include lists patterns of the paths that are restored
+	Include []string
+	// Exclude lists patterns of the paths that are not restored
+	Exclude []string
+	// Verify reads the restored files back and checks them
+	Verify bool
+}
+
+// Snapshot is a backup in a repository
+type Snapshot struct {
+	ID    string
+	Time  time.Time
+	Paths []string
+	Tags  []string
+}
+
+// New returns the DataMover of the type that runs in the target and stores
+// backups in the repository. An empty type selects restic.
+func New(t Type, target Target, repo Repository) (DataMover, error) {
+	switch t {
+	case TypeRestic, "":
+		return &resticMover{target: target, repo: repo}, nil
+	default:
+		return nil, errors.Errorf("Unsupported data mover %s", t)
+	}
+}
+
+// Supported returns true if the data mover type is supported
+func Supported(t Type) bool {
+	switch t {
+	case TypeRestic, "":
+		return true
+	default:
+		return false
+	}
+}
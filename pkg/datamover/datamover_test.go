@@ -0,0 +1,98 @@
+package datamover
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/param"
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+// Hook up gocheck into the "go test" runner.
+func Test(t *testing.T) { TestingT(t) }
+
+type DataMoverSuite struct{}
+
+var _ = Suite(&DataMoverSuite{})
+
+func (s *DataMoverSuite) TestNew(c *C) {
+	for _, tc := range []struct {
+		t          Type
+		errChecker Checker
+	}{
+		{t: "", errChecker: IsNil},
+		{t: TypeRestic, errChecker: IsNil},
+		{t: "Tar", errChecker: NotNil},
+	} {
+		m, err := New(tc.t, Target{}, Repository{})
+		c.Check(err, tc.errChecker)
+		c.Check(Supported(tc.t), Equals, err == nil)
+		if err == nil {
+			_, ok := m.(*resticMover)
+			c.Check(ok, Equals, true)
+		}
+	}
+}
+
+func (s *DataMoverSuite) TestRestoreCommand(c *C) {
+	repo := Repository{
+		Profile: &param.Profile{
+			Location: crv1alpha1.Location{
+				Type: crv1alpha1.LocationTypeFileSystem,
+				Path: "/mnt/backups",
+			},
+		},
+		Path: "repo",
+	}
+	prefix := "export RESTIC_REPOSITORY='/mnt/backups/repo'\n . /dev/stdin\n restic "
+	for _, tc := range []struct {
+		in       RestoreInput
+		expected string
+	}{
+		{RestoreInput{ID: "1a2b3c4d", Target: "/mnt/data"}, "restore 1a2b3c4d --target /mnt/data"},
+		{RestoreInput{Tag: "tag", Target: "/", Exclude: []string{"*.log"}, Verify: true}, "restore --tag tag latest --target / --exclude '*.log' --verify"},
+	} {
+		cmd, err := restoreCommand(repo, tc.in)
+		c.Assert(err, IsNil)
+		c.Check(cmd[len(cmd)-1], Equals, prefix+tc.expected)
+	}
+	_, err := restoreCommand(repo, RestoreInput{Target: "/"})
+	c.Assert(err, NotNil)
+
+	m, err := New(TypeRestic, Target{}, repo)
+	c.Assert(err, IsNil)
+	c.Assert(m.Restore(context.Background(), RestoreInput{Target: "/"}), NotNil)
+}
+
+func (s *DataMoverSuite) TestBackupOutput(c *C) {
+	out := backupOutput("tag", &restic.BackupSummary{
+		DataAdded:           2100,
+		TotalFilesProcessed: 4,
+		TotalBytesProcessed: 2048,
+		SnapshotID:          "1a2b3c4d5e6f",
+	})
+	c.Assert(*out, DeepEquals, BackupOutput{ID: "1a2b3c4d", Tag: "tag", Size: 2048, SizeAdded: 2100, FileCount: 4})
+}
+
+func (s *DataMoverSuite) TestParseSnapshots(c *C) {
+	output := `[{"time":"2019-06-04T10:00:00Z","tree":"5e6f","paths":["/mnt/data"],"hostname":"pod","tags":["tag"],"id":"1a2b3c4d5e6f","short_id":"1a2b3c4d"}]`
+	snapshots, err := parseSnapshots(output)
+	c.Assert(err, IsNil)
+	c.Assert(snapshots, DeepEquals, []Snapshot{{
+		ID:    "1a2b3c4d",
+		Time:  time.Date(2019, 6, 4, 10, 0, 0, 0, time.UTC),
+		Paths: []string{"/mnt/data"},
+		Tags:  []string{"tag"},
+	}})
+
+	snapshots, err = parseSnapshots("[]")
+	c.Assert(err, IsNil)
+	c.Assert(snapshots, HasLen, 0)
+
+	_, err = parseSnapshots("Fatal: unable to open config file")
+	c.Assert(err, NotNil)
+}
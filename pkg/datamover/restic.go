@@ -0,0 +1,123 @@
+package datamover
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+var _ DataMover = (*resticMover)(nil)
+
+// resticMover runs the restic binary of the target container
+type resticMover struct {
+	target Target
+	repo   Repository
+}
+
+func (m *resticMover) exec(cmd []string) (string, error) {
+	stdout, _, err := restic.Exec(m.target.Cli, m.target.Namespace, m.target.Pod, m.target.Container, cmd, m.repo.Profile, m.repo.EncryptionKey)
+	return stdout, err
+}
+
+// execJSON runs a command that prints JSON, which is not logged
+func (m *resticMover) execJSON(cmd []string) (string, error) {
+	stdout, _, err := restic.ExecJSON(m.target.Cli, m.target.Namespace, m.target.Pod, m.target.Container, cmd, m.repo.Profile, m.repo.EncryptionKey)
+	return stdout, err
+}
+
+func (m *resticMover) Init(ctx context.Context) error {
+	return restic.GetOrCreateRepository(m.target.Cli, m.target.Namespace, m.target.Pod, m.target.Container, m.repo.Path, m.repo.EncryptionKey, m.repo.Profile)
+}
+
+func (m *resticMover) Create(ctx context.Context) error {
+	return restic.CreateRepository(m.target.Cli, m.target.Namespace, m.target.Pod, m.target.Container, m.repo.Path, m.repo.EncryptionKey, m.repo.Profile)
+}
+
+func (m *resticMover) Backup(ctx context.Context, in BackupInput) (*BackupOutput, error) {
+	opts := restic.BackupOptions{
+		Exclude:       in.Exclude,
+		ExcludeCaches: in.ExcludeCaches,
+		OneFileSystem: in.OneFileSystem,
+	}
+	cmd := restic.BackupCommandByTagWithOptions(m.repo.Profile, m.repo.Path, in.Tag, in.Paths, opts)
+	stdout, err := m.execJSON(cmd)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create and upload backup")
+	}
+	summary, err := restic.ParseBackupOutput(stdout, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to parse the backup summary from logs")
+	}
+	return backupOutput(in.Tag, summary), nil
+}
+
+func backupOutput(tag string, summary *restic.BackupSummary) *BackupOutput {
+	return &BackupOutput{
+		ID:        summary.ShortSnapshotID(),
+		Tag:       tag,
+		Size:      summary.TotalBytesProcessed,
+		SizeAdded: summary.DataAdded,
+		FileCount: summary.TotalFilesProcessed,
+	}
+}
+
+func (m *resticMover) Restore(ctx context.Context, in RestoreInput) error {
+	cmd, err := restoreCommand(m.repo, in)
+	if err != nil {
+		return err
+	}
+	_, err = m.exec(cmd)
+	return errors.Wrapf(err, "Failed to restore backup")
+}
+
+func restoreCommand(repo Repository, in RestoreInput) ([]string, error) {
+	opts := restic.RestoreOptions{
+		Include: in.Include,
+		Exclude: in.Exclude,
+		Verify:  in.Verify,
+	}
+	switch {
+	case in.ID != "":
+		return restic.RestoreCommandByIDWithOptions(repo.Profile, repo.Path, in.ID, in.Target, opts), nil
+	case in.Tag != "":
+		return restic.RestoreCommandByTagWithOptions(repo.Profile, repo.Path, in.Tag, in.Target, opts), nil
+	default:
+		return nil, errors.New("Backup ID or tag required for restore")
+	}
+}
+
+func (m *resticMover) Snapshots(ctx context.Context, tag string) ([]Snapshot, error) {
+	cmd := restic.SnapshotsCommand(m.repo.Profile, m.repo.Path)
+	if tag != "" {
+		cmd = restic.SnapshotsCommandByTag(m.repo.Profile, m.repo.Path, tag)
+	}
+	stdout, err := m.execJSON(cmd)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list snapshots")
+	}
+	return parseSnapshots(stdout)
+}
+
+func parseSnapshots(output string) ([]Snapshot, error) {
+	snaps, err := restic.ParseSnapshotsOutput(output)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]Snapshot, 0, len(snaps))
+	for _, s := range snaps {
+		snapshots = append(snapshots, Snapshot{ID: s.ShortID, Time: s.Time, Paths: s.Paths, Tags: s.Tags})
+	}
+	return snapshots, nil
+}
+
+func (m *resticMover) Forget(ctx context.Context, id string) error {
+	_, err := m.exec(restic.ForgetCommandByID(m.repo.Profile, m.repo.Path, id))
+	return errors.Wrapf(err, "Failed to forget data")
+}
+
+func (m *resticMover) Prune(ctx context.Context) error {
+	_, err := m.exec(restic.PruneCommand(m.repo.Profile, m.repo.Path))
+	return errors.Wrapf(err, "Failed to prune data after forget")
+}
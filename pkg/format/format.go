@@ -2,11 +2,77 @@ package format
 
 import (
 	"regexp"
+	"sort"
 	"strings"
 
 	log "github.com/sirupsen/logrus"
 )
 
+// RedactedValue replaces the secret values in logs and errors
+const RedactedValue = "<redacted>"
+
+// minSecretLength is the length below which values are not redacted. Shorter
+// values would redact unrelated words from the output.
+const minSecretLength = 4
+
+// Redactor replaces the secret values, such as credentials and passwords,
+// used by a command in its output and errors
+type Redactor struct {
+	// secrets is sorted by decreasing length so that secrets containing
+	// other secrets are redacted whole
+	secrets []string
+}
+
+// NewRedactor returns a Redactor for the secret values. Empty values and
+// values shorter than 4 characters are ignored.
+func NewRedactor(values ...string) *Redactor {
+	r := &Redactor{}
+	seen := make(map[string]bool, len(values))
+	for _, v := range values {
+		if v = strings.TrimSpace(v); len(v) >= minSecretLength && !seen[v] {
+			seen[v] = true
+			r.secrets = append(r.secrets, v)
+		}
+	}
+	sort.SliceStable(r.secrets, func(i, j int) bool { return len(r.secrets[i]) > len(r.secrets[j]) })
+	return r
+}
+
+// Redact returns s with the secret values replaced
+func (r *Redactor) Redact(s string) string {
+	for _, v := range r.secrets {
+		s = strings.Replace(s, v, RedactedValue, -1)
+	}
+	return s
+}
+
+// RedactError returns an error whose message has the secret values replaced.
+// The cause of the error is kept.
+func (r *Redactor) RedactError(err error) error {
+	if err == nil || len(r.secrets) == 0 {
+		return err
+	}
+	return &redactedError{err: err, r: r}
+}
+
+// Log logs the output of the container with the secret values replaced
+func (r *Redactor) Log(podName string, containerName string, output string) {
+	Log(podName, containerName, r.Redact(output))
+}
+
+type redactedError struct {
+	err error
+	r   *Redactor
+}
+
+func (e *redactedError) Error() string {
+	return e.r.Redact(e.err.Error())
+}
+
+func (e *redactedError) Cause() error {
+	return e.err
+}
+
 func Log(podName string, containerName string, output string) {
 	if output != "" {
 		logs := regexp.MustCompile("[\r\n]").Split(output, -1)
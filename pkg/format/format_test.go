@@ -0,0 +1,30 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type FormatSuite struct{}
+
+var _ = Suite(&FormatSuite{})
+
+func (s *FormatSuite) TestRedact(c *C) {
+	r := NewRedactor("", "pw", "pass", " password\n", "pass")
+	c.Assert(r.Redact("no secrets, pw"), Equals, "no secrets, pw")
+	c.Assert(r.Redact("password=password pass=pass"), Equals, "<redacted>=<redacted> <redacted>=<redacted>")
+
+	cause := errors.New("failed with password")
+	err := r.RedactError(errors.Wrap(cause, "pass"))
+	c.Assert(err.Error(), Equals, "<redacted>: failed with <redacted>")
+	c.Assert(errors.Cause(err), Equals, cause)
+	c.Assert(r.RedactError(nil), IsNil)
+
+	// Redactors do not share their secrets
+	c.Assert(NewRedactor("other").Redact("password"), Equals, "password")
+	c.Assert(NewRedactor().RedactError(cause), Equals, cause)
+}
@@ -11,8 +11,9 @@ import (
 
 	kanister "github.com/kanisterio/kanister/pkg"
 	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
-	"github.com/kanisterio/kanister/pkg/format"
+	"github.com/kanisterio/kanister/pkg/datamover"
 	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/location"
 	"github.com/kanisterio/kanister/pkg/param"
 	"github.com/kanisterio/kanister/pkg/restic"
 )
@@ -26,14 +27,47 @@ const (
 	BackupDataContainerArg = "container"
 	// BackupDataIncludePathArg provides the path of the volume or sub-path for required backup
 	BackupDataIncludePathArg = "includePath"
+	// BackupDataIncludePathsArg provides a list of paths to be backed up along with includePath
+	BackupDataIncludePathsArg = "includePaths"
+	// BackupDataExcludePathsArg provides the patterns of the paths not to be backed up
+	BackupDataExcludePathsArg = "excludePaths"
+	// BackupDataExcludeCachesArg skips the directories tagged as caches with a CACHEDIR.TAG file
+	BackupDataExcludeCachesArg = "excludeCaches"
+	// BackupDataOneFileSystemArg does not cross the file system boundaries of the included paths
+	BackupDataOneFileSystemArg = "oneFileSystem"
 	// BackupDataBackupArtifactPrefixArg provides the path to store artifacts on the object store
 	BackupDataBackupArtifactPrefixArg = "backupArtifactPrefix"
 	// BackupDataEncryptionKeyArg provides the encryption key to be used for backups
 	BackupDataEncryptionKeyArg = "encryptionKey"
+	// BackupDataUploadLimitArg provides the maximum upload rate in KiB/s
+	BackupDataUploadLimitArg = "uploadLimit"
+	// BackupDataDownloadLimitArg provides the maximum download rate in KiB/s
+	BackupDataDownloadLimitArg = "downloadLimit"
 	// BackupDataOutputBackupID is the key used for returning backup ID output
 	BackupDataOutputBackupID = "backupID"
 	// BackupDataOutputBackupTag is the key used for returning backupTag output
 	BackupDataOutputBackupTag = "backupTag"
+	// BackupDataOutputSize is the key used for returning the size in bytes of the backed up files
+	BackupDataOutputSize = "size"
+	// BackupDataOutputSizeAdded is the key used for returning the size in bytes of the data added to the repository
+	BackupDataOutputSizeAdded = "sizeAdded"
+	// BackupDataOutputFileCount is the key used for returning the number of backed up files
+	BackupDataOutputFileCount = "fileCount"
+
+	// RepositoryPasswordSecretArg names the Secret that stores the passwords
+	// of restic repositories. It overrides the Secret of the Profile.
+	RepositoryPasswordSecretArg = "repositoryPasswordSecret"
+	// RepositoryPasswordSecretNamespaceArg is the namespace of the Secret
+	// named by RepositoryPasswordSecretArg. It defaults to the namespace of
+	// the Profile, so that functions running in other namespaces, e.g. to
+	// restore into a new namespace, find the same passwords.
+	RepositoryPasswordSecretNamespaceArg = "repositoryPasswordSecretNamespace"
+	// LegacyRepositoryPasswordArg opts in to the fixed password of
+	// repositories created without an encryption key by older versions
+	LegacyRepositoryPasswordArg = "legacyRepositoryPassword"
+	// DataMoverArg selects the backend of the data functions. It overrides
+	// the data mover of the Profile.
+	DataMoverArg = "dataMover"
 )
 
 func init() {
@@ -52,6 +86,12 @@ func validateProfile(profile *param.Profile) error {
 	if profile == nil {
 		return errors.New("Profile must be non-nil")
 	}
+	if profile.Location.Type == crv1alpha1.LocationTypeFileSystem {
+		if profile.Location.Path == "" {
+			return errors.New("Location path is not set")
+		}
+		return nil
+	}
 	if profile.Credential.Type != param.CredentialTypeKeyPair {
 		return errors.New("Credential type not supported")
 	}
@@ -65,14 +105,226 @@ func validateProfile(profile *param.Profile) error {
 	case crv1alpha1.LocationTypeS3Compliant:
 	case crv1alpha1.LocationTypeGCS:
 	case crv1alpha1.LocationTypeAzure:
+	case crv1alpha1.LocationTypeSFTP:
+		if profile.Location.Host == "" {
+			return errors.New("Location host is not set")
+		}
 	default:
 		return errors.New("Location type not supported")
 	}
 	return nil
 }
 
+// withBandwidthLimit returns a copy of the profile with the bandwidth limits
+// given in the args. Limits that are not set default to the limits of the
+// profile.
+func withBandwidthLimit(args map[string]interface{}, uploadArg, downloadArg string, profile *param.Profile) (*param.Profile, error) {
+	var upload, download int64
+	if err := OptArg(args, uploadArg, &upload, int64(0)); err != nil {
+		return nil, err
+	}
+	if err := OptArg(args, downloadArg, &download, int64(0)); err != nil {
+		return nil, err
+	}
+	if upload < 0 || download < 0 {
+		return nil, errors.Errorf("Bandwidth limits must not be negative: %s=%d, %s=%d", uploadArg, upload, downloadArg, download)
+	}
+	if profile == nil || (upload == 0 && download == 0) {
+		return profile, nil
+	}
+	p := *profile
+	if upload > 0 {
+		p.BandwidthLimit.Upload = upload
+	}
+	if download > 0 {
+		p.BandwidthLimit.Download = download
+	}
+	return &p, nil
+}
+
+// withDataMover returns a copy of the profile with the data mover given in
+// the args. The data mover defaults to the data mover of the profile.
+func withDataMover(ctx context.Context, args map[string]interface{}, profile *param.Profile) (*param.Profile, error) {
+	var mover string
+	if err := OptArg(args, DataMoverArg, &mover, ""); err != nil {
+		return nil, err
+	}
+	if profile != nil && mover == "" {
+		mover = profile.DataMover
+	}
+	if !datamover.Supported(datamover.Type(mover)) {
+		return nil, errors.Errorf("Unsupported data mover %s", mover)
+	}
+	if err := checkDataMoverEncryption(ctx, profile); err != nil {
+		return nil, err
+	}
+	if profile == nil || mover == profile.DataMover {
+		return profile, nil
+	}
+	p := *profile
+	p.DataMover = mover
+	return &p, nil
+}
+
+// checkDataMoverEncryption returns an error if the location of the profile
+// requests server-side encryption that the bucket does not apply by default.
+// Restic cannot request encryption for the objects it writes, so it relies
+// on the bucket default.
+func checkDataMoverEncryption(ctx context.Context, profile *param.Profile) error {
+	if profile == nil {
+		return nil
+	}
+	return errors.Wrap(location.CheckBucketEncryption(ctx, *profile), "Server-side encryption of the location cannot be applied to the repository")
+}
+
+// newDataMover returns the data mover of the profile that runs in the
+// container and stores backups in the repository
+func newDataMover(cli kubernetes.Interface, profile *param.Profile, namespace, pod, container, repository, encryptionKey string) (datamover.DataMover, error) {
+	return datamover.New(
+		datamover.Type(profile.DataMover),
+		datamover.Target{Cli: cli, Namespace: namespace, Pod: pod, Container: container},
+		datamover.Repository{Profile: profile, Path: repository, EncryptionKey: encryptionKey},
+	)
+}
+
+// repositoryPasswords resolves the passwords of restic repositories. They are
+// either the same key for all repositories or stored per repository in a
+// Secret.
+type repositoryPasswords struct {
+	key    string
+	cli    kubernetes.Interface
+	secret *crv1alpha1.ObjectReference
+}
+
+// newRepositoryPasswords returns the repository passwords given in the args
+// or in the Profile. The encryption key arg takes precedence over the
+// legacy password, the Secret in the args and the Secret of the Profile.
+// The Secret in the args is looked up in the namespace of the Profile, or in
+// the given namespace if the Profile has none.
+func newRepositoryPasswords(cli kubernetes.Interface, tp param.TemplateParams, args map[string]interface{}, encryptionKeyArg, namespace string) (repositoryPasswords, error) {
+	var key, secret, secretNamespace string
+	var legacy bool
+	if err := OptArg(args, encryptionKeyArg, &key, ""); err != nil {
+		return repositoryPasswords{}, err
+	}
+	if err := OptArg(args, RepositoryPasswordSecretArg, &secret, ""); err != nil {
+		return repositoryPasswords{}, err
+	}
+	if tp.Profile != nil && tp.Profile.Namespace != "" {
+		namespace = tp.Profile.Namespace
+	}
+	if err := OptArg(args, RepositoryPasswordSecretNamespaceArg, &secretNamespace, namespace); err != nil {
+		return repositoryPasswords{}, err
+	}
+	if err := OptArg(args, LegacyRepositoryPasswordArg, &legacy, false); err != nil {
+		return repositoryPasswords{}, err
+	}
+	switch {
+	case key != "":
+		return repositoryPasswords{key: key}, nil
+	case legacy:
+		return repositoryPasswords{key: restic.GeneratePassword()}, nil
+	case secret != "":
+		return repositoryPasswords{cli: cli, secret: &crv1alpha1.ObjectReference{Name: secret, Namespace: secretNamespace}}, nil
+	case tp.Profile != nil && tp.Profile.RepositoryPasswordSecret != nil:
+		return repositoryPasswords{cli: cli, secret: tp.Profile.RepositoryPasswordSecret}, nil
+	default:
+		return repositoryPasswords{}, errors.Errorf("Require one argument: %s, %s or %s, or a repository password secret in the Profile",
+			encryptionKeyArg, RepositoryPasswordSecretArg, LegacyRepositoryPasswordArg)
+	}
+}
+
+// get returns the password of an existing repository
+func (p repositoryPasswords) get(repository string) (string, error) {
+	if p.secret == nil {
+		return p.key, nil
+	}
+	return restic.RepositoryPassword(p.cli, *p.secret, repository)
+}
+
+// repositoryPassword is the password of a repository. A new password is only
+// stored once the repository has been created with it.
+type repositoryPassword struct {
+	value string
+	// store persists a new password. It is nil if the password need not be
+	// stored.
+	store func() error
+}
+
+// getOrCreate returns the password of a repository, or generates one if the
+// repository has none yet. Generated passwords are stored by initRepository.
+func (p repositoryPasswords) getOrCreate(repository string) (repositoryPassword, error) {
+	if p.secret == nil {
+		return repositoryPassword{value: p.key}, nil
+	}
+	pw, ok, err := restic.LookupRepositoryPassword(p.cli, *p.secret, repository)
+	if err != nil {
+		return repositoryPassword{}, err
+	}
+	if ok {
+		return repositoryPassword{value: pw}, nil
+	}
+	if pw, err = restic.NewRepositoryPassword(); err != nil {
+		return repositoryPassword{}, err
+	}
+	cli, secret := p.cli, *p.secret
+	return repositoryPassword{
+		value: pw,
+		store: func() error { return restic.StoreRepositoryPassword(cli, secret, repository, pw) },
+	}, nil
+}
+
+// initRepository creates the repository if it does not exist. A repository
+// with a generated password must not exist yet, since it was created with
+// another password. The generated password is stored once the repository
+// has been created.
+func initRepository(ctx context.Context, mover datamover.DataMover, password repositoryPassword) error {
+	if password.store == nil {
+		return mover.Init(ctx)
+	}
+	if err := mover.Create(ctx); err != nil {
+		return errors.Wrap(err, "Failed to create repository. The passwords of existing repositories must be stored in the repository password secret or given as the encryption key")
+	}
+	return password.store()
+}
+
+// backupPaths reads the paths to be backed up from the single path and list
+// args. At least one path is required.
+func backupPaths(args map[string]interface{}, pathArg, pathsArg string) ([]string, error) {
+	var path string
+	var paths []string
+	if err := OptArg(args, pathArg, &path, ""); err != nil {
+		return nil, err
+	}
+	if err := OptArg(args, pathsArg, &paths, nil); err != nil {
+		return nil, err
+	}
+	if path != "" {
+		paths = append([]string{path}, paths...)
+	}
+	if len(paths) == 0 {
+		return nil, errors.Errorf("Require at least one argument: %s or %s", pathArg, pathsArg)
+	}
+	return paths, nil
+}
+
+// backupOptions reads the args that select the backed up files
+func backupOptions(args map[string]interface{}, excludeArg, excludeCachesArg, oneFileSystemArg string) (datamover.BackupInput, error) {
+	var opts datamover.BackupInput
+	if err := OptArg(args, excludeArg, &opts.Exclude, nil); err != nil {
+		return opts, err
+	}
+	if err := OptArg(args, excludeCachesArg, &opts.ExcludeCaches, false); err != nil {
+		return opts, err
+	}
+	if err := OptArg(args, oneFileSystemArg, &opts.OneFileSystem, false); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
 func (*backupDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
-	var namespace, pod, container, includePath, backupArtifactPrefix, encryptionKey string
+	var namespace, pod, container, backupArtifactPrefix string
 	var err error
 	if err = Arg(args, BackupDataNamespaceArg, &namespace); err != nil {
 		return nil, err
@@ -83,76 +335,135 @@ func (*backupDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args m
 	if err = Arg(args, BackupDataContainerArg, &container); err != nil {
 		return nil, err
 	}
-	if err = Arg(args, BackupDataIncludePathArg, &includePath); err != nil {
+	if err = Arg(args, BackupDataBackupArtifactPrefixArg, &backupArtifactPrefix); err != nil {
 		return nil, err
 	}
-	if err = Arg(args, BackupDataBackupArtifactPrefixArg, &backupArtifactPrefix); err != nil {
+	includePaths, err := backupPaths(args, BackupDataIncludePathArg, BackupDataIncludePathsArg)
+	if err != nil {
 		return nil, err
 	}
-	if err = OptArg(args, BackupDataEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
+	opts, err := backupOptions(args, BackupDataExcludePathsArg, BackupDataExcludeCachesArg, BackupDataOneFileSystemArg)
+	if err != nil {
 		return nil, err
 	}
 	// Validate the Profile
 	if err = validateProfile(tp.Profile); err != nil {
 		return nil, errors.Wrapf(err, "Failed to validate Profile")
 	}
+	if err = checkExecLocation(tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withBandwidthLimit(args, BackupDataUploadLimitArg, BackupDataDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
 	cli, err := kube.NewClient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
-	backupID, backupTag, err := backupData(ctx, cli, namespace, pod, container, backupArtifactPrefix, includePath, encryptionKey, tp)
+	passwords, err := newRepositoryPasswords(cli, tp, args, BackupDataEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	password, err := passwords.getOrCreate(backupArtifactPrefix)
+	if err != nil {
+		return nil, err
+	}
+	backup, err := backupData(ctx, cli, namespace, pod, container, backupArtifactPrefix, includePaths, opts, password, tp)
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to backup data")
 	}
 	output := map[string]interface{}{
-		BackupDataOutputBackupID:  backupID,
-		BackupDataOutputBackupTag: backupTag,
+		BackupDataOutputBackupID:  backup.ID,
+		BackupDataOutputBackupTag: backup.Tag,
+		BackupDataOutputSize:      backup.Size,
+		BackupDataOutputSizeAdded: backup.SizeAdded,
+		BackupDataOutputFileCount: backup.FileCount,
 	}
 	return output, nil
 }
 
 func (*backupDataFunc) RequiredArgs() []string {
 	return []string{BackupDataNamespaceArg, BackupDataPodArg, BackupDataContainerArg,
-		BackupDataIncludePathArg, BackupDataBackupArtifactPrefixArg}
+		BackupDataBackupArtifactPrefixArg}
 }
 
-func backupData(ctx context.Context, cli kubernetes.Interface, namespace, pod, container, backupArtifactPrefix, includePath, encryptionKey string, tp param.TemplateParams) (string, string, error) {
+// backupData backs up the files under includePaths selected by opts with the
+// data mover of the profile
+func backupData(ctx context.Context, cli kubernetes.Interface, namespace, pod, container, backupArtifactPrefix string, includePaths []string, opts datamover.BackupInput, password repositoryPassword, tp param.TemplateParams) (*datamover.BackupOutput, error) {
+	mover, err := newDataMover(cli, tp.Profile, namespace, pod, container, backupArtifactPrefix, password.value)
+	if err != nil {
+		return nil, err
+	}
 	pw, err := getPodWriter(cli, ctx, namespace, pod, container, tp.Profile)
 	if err != nil {
-		return "", "", err
+		return nil, err
 	}
 	defer cleanUpCredsFile(ctx, pw, namespace, pod, container)
-	if err = restic.GetOrCreateRepository(cli, namespace, pod, container, backupArtifactPrefix, encryptionKey, tp.Profile); err != nil {
-		return "", "", err
+	if err = initRepository(ctx, mover, password); err != nil {
+		return nil, err
 	}
 
 	// Create backup and dump it on the object store
-	backupTag := rand.String(10)
-	cmd := restic.BackupCommandByTag(tp.Profile, backupArtifactPrefix, backupTag, includePath, encryptionKey)
-	stdout, stderr, err := kube.Exec(cli, namespace, pod, container, cmd, nil)
-	format.Log(pod, container, stdout)
-	format.Log(pod, container, stderr)
-	if err != nil {
-		return "", "", errors.Wrapf(err, "Failed to create and upload backup")
+	opts.Tag = rand.String(10)
+	opts.Paths = includePaths
+	return mover.Backup(ctx, opts)
+}
+
+func getPodWriter(cli kubernetes.Interface, ctx context.Context, namespace, podName, containerName string, profile *param.Profile) (*kube.PodWriter, error) {
+	var path string
+	switch profile.Location.Type {
+	case crv1alpha1.LocationTypeGCS:
+		path = restic.GoogleCloudCredsFilePath
+	case crv1alpha1.LocationTypeSFTP:
+		path = restic.SSHKeyFilePath
+	default:
+		return nil, nil
 	}
-	// Get the snapshot ID from log
-	backupID := restic.SnapshotIDFromBackupLog(stdout)
-	if backupID == "" {
-		return "", "", errors.New("Failed to parse the backup ID from logs")
+	pw := kube.NewPodWriter(cli, path, bytes.NewBufferString(profile.Credential.KeyPair.Secret))
+	if err := pw.Write(ctx, namespace, podName, containerName); err != nil {
+		return nil, err
 	}
-	return backupID, backupTag, nil
+	return pw, nil
 }
 
-func getPodWriter(cli kubernetes.Interface, ctx context.Context, namespace, podName, containerName string, profile *param.Profile) (*kube.PodWriter, error) {
-	if profile.Location.Type == crv1alpha1.LocationTypeGCS {
-		pw := kube.NewPodWriter(cli, restic.GoogleCloudCredsFilePath, bytes.NewBufferString(profile.Credential.KeyPair.Secret))
-		if err := pw.Write(ctx, namespace, podName, containerName); err != nil {
-			return nil, err
-		}
-		return pw, nil
+// locationVolumes returns the PVCs, mapped to their mount paths, that Pods
+// created by data functions need in order to reach the profile's location.
+func locationVolumes(profile *param.Profile) map[string]string {
+	if profile == nil || profile.Location.Type != crv1alpha1.LocationTypeFileSystem || profile.Location.ClaimName == "" {
+		return nil
 	}
-	return nil, nil
+	return map[string]string{profile.Location.ClaimName: profile.Location.Path}
+}
+
+// checkExecLocation returns an error if the location of the profile is a
+// volume. Functions that exec into application Pods cannot mount it, since
+// only the Pods that functions create get the location volumes.
+func checkExecLocation(profile *param.Profile) error {
+	if profile != nil && profile.Location.Type == crv1alpha1.LocationTypeFileSystem {
+		return errors.New("Filesystem locations are not supported by functions that run in application Pods. Use CopyVolumeData to back up the volume instead")
+	}
+	return nil
 }
+
+// withLocationVolumes adds the location volumes of the profile to vols
+func withLocationVolumes(vols map[string]string, profile *param.Profile) map[string]string {
+	lv := locationVolumes(profile)
+	if len(lv) == 0 {
+		return vols
+	}
+	all := make(map[string]string, len(vols)+len(lv))
+	for pvc, mountPath := range vols {
+		all[pvc] = mountPath
+	}
+	for pvc, mountPath := range lv {
+		all[pvc] = mountPath
+	}
+	return all
+}
+
 func cleanUpCredsFile(ctx context.Context, pw *kube.PodWriter, namespace, podName, containerName string) {
 	if pw != nil {
 		if err := pw.Remove(ctx, namespace, podName, containerName); err != nil {
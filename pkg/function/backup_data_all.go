@@ -10,9 +10,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/datamover"
 	"github.com/kanisterio/kanister/pkg/kube"
 	"github.com/kanisterio/kanister/pkg/param"
-	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 const (
@@ -24,10 +24,22 @@ const (
 	BackupDataAllContainerArg = "container"
 	// BackupDataAllIncludePathArg provides the path of the volume or sub-path for required backup
 	BackupDataAllIncludePathArg = "includePath"
+	// BackupDataAllIncludePathsArg provides a list of paths to be backed up along with includePath
+	BackupDataAllIncludePathsArg = "includePaths"
+	// BackupDataAllExcludePathsArg provides the patterns of the paths not to be backed up
+	BackupDataAllExcludePathsArg = "excludePaths"
+	// BackupDataAllExcludeCachesArg skips the directories tagged as caches with a CACHEDIR.TAG file
+	BackupDataAllExcludeCachesArg = "excludeCaches"
+	// BackupDataAllOneFileSystemArg does not cross the file system boundaries of the included paths
+	BackupDataAllOneFileSystemArg = "oneFileSystem"
 	// BackupDataAllBackupArtifactPrefixArg provides the path to store artifacts on the object store
 	BackupDataAllBackupArtifactPrefixArg = "backupArtifactPrefix"
 	// BackupDataAllEncryptionKeyArg provides the encryption key to be used for backups
 	BackupDataAllEncryptionKeyArg = "encryptionKey"
+	// BackupDataAllUploadLimitArg provides the maximum upload rate in KiB/s
+	BackupDataAllUploadLimitArg = "uploadLimit"
+	// BackupDataAllDownloadLimitArg provides the maximum download rate in KiB/s
+	BackupDataAllDownloadLimitArg = "downloadLimit"
 	// BackupDataAllOutput is the key name of the output generated by BackupDataAll func
 	BackupDataAllOutput = "BackupAllInfo"
 )
@@ -51,7 +63,7 @@ func (*backupDataAllFunc) Name() string {
 }
 
 func (*backupDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
-	var namespace, pods, container, includePath, backupArtifactPrefix, encryptionKey string
+	var namespace, pods, container, backupArtifactPrefix string
 	var err error
 	if err = Arg(args, BackupDataAllNamespaceArg, &namespace); err != nil {
 		return nil, err
@@ -59,26 +71,41 @@ func (*backupDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, arg
 	if err = Arg(args, BackupDataAllContainerArg, &container); err != nil {
 		return nil, err
 	}
-	if err = Arg(args, BackupDataAllIncludePathArg, &includePath); err != nil {
+	if err = Arg(args, BackupDataAllBackupArtifactPrefixArg, &backupArtifactPrefix); err != nil {
 		return nil, err
 	}
-	if err = Arg(args, BackupDataAllBackupArtifactPrefixArg, &backupArtifactPrefix); err != nil {
+	includePaths, err := backupPaths(args, BackupDataAllIncludePathArg, BackupDataAllIncludePathsArg)
+	if err != nil {
 		return nil, err
 	}
-	if err = OptArg(args, BackupDataAllPodsArg, &pods, ""); err != nil {
+	opts, err := backupOptions(args, BackupDataAllExcludePathsArg, BackupDataAllExcludeCachesArg, BackupDataAllOneFileSystemArg)
+	if err != nil {
 		return nil, err
 	}
-	if err = OptArg(args, BackupDataAllEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
+	if err = OptArg(args, BackupDataAllPodsArg, &pods, ""); err != nil {
 		return nil, err
 	}
 	// Validate the Profile
 	if err = validateProfile(tp.Profile); err != nil {
 		return nil, errors.Wrapf(err, "Failed to validate Profile")
 	}
+	if err = checkExecLocation(tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withBandwidthLimit(args, BackupDataAllUploadLimitArg, BackupDataAllDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
 	cli, err := kube.NewClient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
+	passwords, err := newRepositoryPasswords(cli, tp, args, BackupDataAllEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
 	var ps []string
 	if pods == "" {
 		switch {
@@ -92,22 +119,31 @@ func (*backupDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, arg
 	} else {
 		ps = strings.Fields(pods)
 	}
-	return backupDataAll(ctx, cli, namespace, ps, container, backupArtifactPrefix, includePath, encryptionKey, tp)
+	return backupDataAll(ctx, cli, namespace, ps, container, backupArtifactPrefix, includePaths, opts, passwords, tp)
 }
 
 func (*backupDataAllFunc) RequiredArgs() []string {
 	return []string{BackupDataAllNamespaceArg, BackupDataAllContainerArg,
-		BackupDataAllIncludePathArg, BackupDataAllBackupArtifactPrefixArg}
+		BackupDataAllBackupArtifactPrefixArg}
 }
 
-func backupDataAll(ctx context.Context, cli kubernetes.Interface, namespace string, ps []string, container string, backupArtifactPrefix, includePath, encryptionKey string, tp param.TemplateParams) (map[string]interface{}, error) {
+func backupDataAll(ctx context.Context, cli kubernetes.Interface, namespace string, ps []string, container string, backupArtifactPrefix string, includePaths []string, opts datamover.BackupInput, passwords repositoryPasswords, tp param.TemplateParams) (map[string]interface{}, error) {
 	errChan := make(chan error, len(ps))
 	outChan := make(chan BackupInfo, len(ps))
 	Output := make(map[string]BackupInfo)
 	// Run the command
 	for _, pod := range ps {
 		go func(pod string, container string) {
-			backupID, backupTag, err := backupData(ctx, cli, namespace, pod, container, fmt.Sprintf("%s/%s", backupArtifactPrefix, pod), includePath, encryptionKey, tp)
+			var backupID, backupTag string
+			var backup *datamover.BackupOutput
+			repository := fmt.Sprintf("%s/%s", backupArtifactPrefix, pod)
+			password, err := passwords.getOrCreate(repository)
+			if err == nil {
+				backup, err = backupData(ctx, cli, namespace, pod, container, repository, includePaths, opts, password, tp)
+			}
+			if err == nil {
+				backupID, backupTag = backup.ID, backup.Tag
+			}
 			errChan <- errors.Wrapf(err, "Failed to backup data for pod %s", pod)
 			outChan <- BackupInfo{PodName: pod, BackupID: backupID, BackupTag: backupTag}
 		}(pod, container)
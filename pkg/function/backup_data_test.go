@@ -1,10 +1,18 @@
 package function
 
 import (
+	"context"
+
+	"github.com/pkg/errors"
 	. "gopkg.in/check.v1"
+	"k8s.io/client-go/kubernetes/fake"
 
 	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/datamover"
+	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/objectstore"
 	"github.com/kanisterio/kanister/pkg/param"
+	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 type BackupDataSuite struct {
@@ -67,3 +75,308 @@ func (s *BackupDataSuite) TestValidateProfile(c *C) {
 		c.Check(err, tc.errChecker, Commentf("Test %s Failed", tc.name))
 	}
 }
+
+func (s *BackupDataSuite) TestCheckExecLocation(c *C) {
+	c.Assert(checkExecLocation(newValidProfile()), IsNil)
+	c.Assert(checkExecLocation(nil), IsNil)
+	profile := &param.Profile{
+		Location: crv1alpha1.Location{
+			Type:      crv1alpha1.LocationTypeFileSystem,
+			Path:      "/mnt/backups",
+			ClaimName: "backups",
+		},
+	}
+	c.Assert(validateProfile(profile), IsNil)
+	c.Assert(checkExecLocation(profile), ErrorMatches, "Filesystem locations are not supported .*")
+}
+
+func (s *BackupDataSuite) TestWithBandwidthLimit(c *C) {
+	profile := newValidProfile()
+	profile.BandwidthLimit = crv1alpha1.BandwidthLimit{Upload: 100, Download: 200}
+	for _, tc := range []struct {
+		args       map[string]interface{}
+		limit      crv1alpha1.BandwidthLimit
+		errChecker Checker
+	}{
+		{
+			args:       map[string]interface{}{},
+			limit:      crv1alpha1.BandwidthLimit{Upload: 100, Download: 200},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataUploadLimitArg: 10},
+			limit:      crv1alpha1.BandwidthLimit{Upload: 10, Download: 200},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataUploadLimitArg: 10, BackupDataDownloadLimitArg: 20},
+			limit:      crv1alpha1.BandwidthLimit{Upload: 10, Download: 20},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataDownloadLimitArg: -1},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataUploadLimitArg: "fast"},
+			errChecker: NotNil,
+		},
+	} {
+		p, err := withBandwidthLimit(tc.args, BackupDataUploadLimitArg, BackupDataDownloadLimitArg, profile)
+		c.Assert(err, tc.errChecker)
+		if err == nil {
+			c.Assert(p.BandwidthLimit, Equals, tc.limit)
+		}
+	}
+	// The profile of the template params is not modified
+	c.Assert(profile.BandwidthLimit, Equals, crv1alpha1.BandwidthLimit{Upload: 100, Download: 200})
+}
+
+func (s *BackupDataSuite) TestWithDataMover(c *C) {
+	profile := newValidProfile()
+	for _, tc := range []struct {
+		args       map[string]interface{}
+		profile    string
+		mover      string
+		errChecker Checker
+	}{
+		{
+			args:       map[string]interface{}{},
+			mover:      "",
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{},
+			profile:    string(datamover.TypeRestic),
+			mover:      string(datamover.TypeRestic),
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{DataMoverArg: string(datamover.TypeRestic)},
+			mover:      string(datamover.TypeRestic),
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{DataMoverArg: "Tar"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{},
+			profile:    "Tar",
+			errChecker: NotNil,
+		},
+	} {
+		profile.DataMover = tc.profile
+		p, err := withDataMover(context.Background(), tc.args, profile)
+		c.Assert(err, tc.errChecker)
+		if err == nil {
+			c.Assert(p.DataMover, Equals, tc.mover)
+		}
+	}
+	// The profile of the template params is not modified
+	profile.DataMover = ""
+	p, err := withDataMover(context.Background(), map[string]interface{}{DataMoverArg: string(datamover.TypeRestic)}, profile)
+	c.Assert(err, IsNil)
+	c.Assert(p, Not(Equals), profile)
+	c.Assert(profile.DataMover, Equals, "")
+
+	// Restic relies on the default encryption of the bucket, which the
+	// memory store does not have
+	profile.Location = crv1alpha1.Location{
+		Type:                 location.TypeMemory,
+		Endpoint:             c.TestName(),
+		Bucket:               "bucket",
+		ServerSideEncryption: crv1alpha1.ServerSideEncryption{Type: "aws:kms", KMSKeyID: "key"},
+	}
+	defer objectstore.ResetMemoryProvider(c.TestName())
+	_, err = withDataMover(context.Background(), map[string]interface{}{}, profile)
+	c.Assert(err, ErrorMatches, "Server-side encryption .* cannot be applied .*")
+}
+
+func (s *BackupDataSuite) TestNewRepositoryPasswords(c *C) {
+	cli := fake.NewSimpleClientset()
+	profile := newValidProfile()
+	profileSecret := &crv1alpha1.ObjectReference{Name: "profile-passwords", Namespace: "kanister"}
+	for _, tc := range []struct {
+		args       map[string]interface{}
+		profile    *crv1alpha1.ObjectReference
+		key        string
+		secret     *crv1alpha1.ObjectReference
+		errChecker Checker
+	}{
+		{
+			args:       map[string]interface{}{},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataEncryptionKeyArg: "key", LegacyRepositoryPasswordArg: true},
+			profile:    profileSecret,
+			key:        "key",
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{LegacyRepositoryPasswordArg: true},
+			profile:    profileSecret,
+			key:        restic.GeneratePassword(),
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{RepositoryPasswordSecretArg: "passwords"},
+			profile:    profileSecret,
+			secret:     &crv1alpha1.ObjectReference{Name: "passwords", Namespace: "app"},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{},
+			profile:    profileSecret,
+			secret:     profileSecret,
+			errChecker: IsNil,
+		},
+	} {
+		profile.RepositoryPasswordSecret = tc.profile
+		tp := param.TemplateParams{Profile: profile}
+		p, err := newRepositoryPasswords(cli, tp, tc.args, BackupDataEncryptionKeyArg, "app")
+		c.Assert(err, tc.errChecker)
+		c.Assert(p.key, Equals, tc.key)
+		c.Assert(p.secret, DeepEquals, tc.secret)
+	}
+
+	// The Secret in the args is looked up in the namespace of the Profile
+	profile.RepositoryPasswordSecret = nil
+	profile.Namespace = "kanister"
+	args := map[string]interface{}{RepositoryPasswordSecretArg: "passwords"}
+	p, err := newRepositoryPasswords(cli, param.TemplateParams{Profile: profile}, args, BackupDataEncryptionKeyArg, "app")
+	c.Assert(err, IsNil)
+	c.Assert(p.secret, DeepEquals, &crv1alpha1.ObjectReference{Name: "passwords", Namespace: "kanister"})
+	args[RepositoryPasswordSecretNamespaceArg] = "backups"
+	p, err = newRepositoryPasswords(cli, param.TemplateParams{Profile: profile}, args, BackupDataEncryptionKeyArg, "app")
+	c.Assert(err, IsNil)
+	c.Assert(p.secret, DeepEquals, &crv1alpha1.ObjectReference{Name: "passwords", Namespace: "backups"})
+
+	p = repositoryPasswords{cli: cli, secret: profileSecret}
+	_, err = p.get("bucket/repo")
+	c.Assert(err, NotNil)
+	created, err := p.getOrCreate("bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(created.store, NotNil)
+	// Generated passwords are not stored before the repository is created
+	_, err = p.get("bucket/repo")
+	c.Assert(err, NotNil)
+
+	// The password is not stored if the repository cannot be created
+	err = initRepository(context.Background(), &fakeMover{createErr: errors.New("repository exists")}, created)
+	c.Assert(err, ErrorMatches, ".*repository exists")
+	_, err = p.get("bucket/repo")
+	c.Assert(err, NotNil)
+
+	m := &fakeMover{}
+	c.Assert(initRepository(context.Background(), m, created), IsNil)
+	c.Assert(m.created, Equals, true)
+	got, err := p.get("bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(got, Equals, created.value)
+
+	// Existing repositories are initialized with their stored password
+	again, err := p.getOrCreate("bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(again.value, Equals, created.value)
+	c.Assert(again.store, IsNil)
+	m = &fakeMover{}
+	c.Assert(initRepository(context.Background(), m, again), IsNil)
+	c.Assert(m.initialized, Equals, true)
+	c.Assert(m.created, Equals, false)
+}
+
+func (s *BackupDataSuite) TestRepositoryPasswordsAcrossNamespaces(c *C) {
+	cli := fake.NewSimpleClientset()
+	profile := newValidProfile()
+	profile.Namespace = "kanister"
+	tp := param.TemplateParams{Profile: profile}
+	args := map[string]interface{}{RepositoryPasswordSecretArg: "passwords"}
+
+	// Back up from the application namespace
+	p, err := newRepositoryPasswords(cli, tp, args, BackupDataEncryptionKeyArg, "app")
+	c.Assert(err, IsNil)
+	created, err := p.getOrCreate("bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(initRepository(context.Background(), &fakeMover{}, created), IsNil)
+
+	// Restore into another namespace
+	p, err = newRepositoryPasswords(cli, tp, args, RestoreDataEncryptionKeyArg, "app-restored")
+	c.Assert(err, IsNil)
+	got, err := p.get("bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(got, Equals, created.value)
+}
+
+// fakeMover records the creation of the repository
+type fakeMover struct {
+	datamover.DataMover
+	createErr   error
+	created     bool
+	initialized bool
+}
+
+func (m *fakeMover) Init(ctx context.Context) error {
+	m.initialized = true
+	return nil
+}
+
+func (m *fakeMover) Create(ctx context.Context) error {
+	m.created = m.createErr == nil
+	return m.createErr
+}
+
+func (s *BackupDataSuite) TestBackupPathsAndOptions(c *C) {
+	for _, tc := range []struct {
+		args       map[string]interface{}
+		paths      []string
+		opts       datamover.BackupInput
+		errChecker Checker
+	}{
+		{
+			args:       map[string]interface{}{},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataIncludePathArg: "/mnt/data"},
+			paths:      []string{"/mnt/data"},
+			errChecker: IsNil,
+		},
+		{
+			args: map[string]interface{}{
+				BackupDataIncludePathArg:   "/mnt/data",
+				BackupDataIncludePathsArg:  []string{"/mnt/conf", "/mnt/logs"},
+				BackupDataExcludePathsArg:  []interface{}{"pg_wal/*.tmp", "*.cache"},
+				BackupDataExcludeCachesArg: true,
+				BackupDataOneFileSystemArg: true,
+			},
+			paths: []string{"/mnt/data", "/mnt/conf", "/mnt/logs"},
+			opts: datamover.BackupInput{
+				Exclude:       []string{"pg_wal/*.tmp", "*.cache"},
+				ExcludeCaches: true,
+				OneFileSystem: true,
+			},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataIncludePathsArg: "/mnt/data"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{BackupDataIncludePathArg: "/mnt/data", BackupDataExcludeCachesArg: "yes"},
+			errChecker: NotNil,
+		},
+	} {
+		paths, err := backupPaths(tc.args, BackupDataIncludePathArg, BackupDataIncludePathsArg)
+		if err == nil {
+			var opts datamover.BackupInput
+			opts, err = backupOptions(tc.args, BackupDataExcludePathsArg, BackupDataExcludeCachesArg, BackupDataOneFileSystemArg)
+			c.Assert(opts, DeepEquals, tc.opts)
+		}
+		c.Assert(err, tc.errChecker)
+		if err == nil {
+			c.Assert(paths, DeepEquals, tc.paths)
+		}
+	}
+}
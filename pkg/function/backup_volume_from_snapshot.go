@@ -0,0 +1,264 @@
+package function
+
+import (
+	"context"
+	"fmt"
+
+	snapshotclient "github.com/kubernetes-csi/external-snapshotter/pkg/client/clientset/versioned"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/blockstorage/getter"
+	"github.com/kanisterio/kanister/pkg/datamover"
+	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/kube/snapshot"
+	kubevolume "github.com/kanisterio/kanister/pkg/kube/volume"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+const (
+	// BackupVolumeFromSnapshotNamespaceArg provides the namespace of the PVC
+	BackupVolumeFromSnapshotNamespaceArg = "namespace"
+	// BackupVolumeFromSnapshotVolumeArg provides the name of the PVC that is backed up
+	BackupVolumeFromSnapshotVolumeArg = "volume"
+	// BackupVolumeFromSnapshotArtifactPrefixArg provides the path of the repository on the object store
+	BackupVolumeFromSnapshotArtifactPrefixArg = "dataArtifactPrefix"
+	// BackupVolumeFromSnapshotEncryptionKeyArg provides the encryption key of the repository
+	BackupVolumeFromSnapshotEncryptionKeyArg = "encryptionKey"
+	// BackupVolumeFromSnapshotSnapshotTypeArg selects CSI or provider snapshots
+	BackupVolumeFromSnapshotSnapshotTypeArg = "snapshotType"
+	// BackupVolumeFromSnapshotSnapshotClassArg provides the VolumeSnapshotClass of CSI snapshots
+	BackupVolumeFromSnapshotSnapshotClassArg = "snapshotClass"
+	// BackupVolumeFromSnapshotStorageClassArg provides the StorageClass of the PVC restored from a CSI snapshot
+	BackupVolumeFromSnapshotStorageClassArg  = "storageClass"
+	BackupVolumeFromSnapshotUploadLimitArg   = "uploadLimit"
+	BackupVolumeFromSnapshotDownloadLimitArg = "downloadLimit"
+	BackupVolumeFromSnapshotIncludePathsArg  = "includePaths"
+	BackupVolumeFromSnapshotExcludePathsArg  = "excludePaths"
+	BackupVolumeFromSnapshotExcludeCachesArg = "excludeCaches"
+	BackupVolumeFromSnapshotOneFileSystemArg = "oneFileSystem"
+	// SnapshotTypeCSI snapshots the PVC with a CSI VolumeSnapshot
+	SnapshotTypeCSI = "CSI"
+	// SnapshotTypeProvider snapshots the volume of the PVC with the API of the storage provider
+	SnapshotTypeProvider         = "Provider"
+	backupVolumeFromSnapshotName = "kanister-clone-%s"
+)
+
+func init() {
+	kanister.Register(&backupVolumeFromSnapshotFunc{})
+}
+
+var _ kanister.Func = (*backupVolumeFromSnapshotFunc)(nil)
+
+type backupVolumeFromSnapshotFunc struct{}
+
+func (*backupVolumeFromSnapshotFunc) Name() string {
+	return "BackupVolumeFromSnapshot"
+}
+
+// volumeClone is a PVC restored from a snapshot of the backed up PVC. The
+// snapshot and the clone are deleted by cleanup.
+type volumeClone struct {
+	pvc     string
+	cleanup func(ctx context.Context) error
+}
+
+// cloneVolumeCSI snapshots the PVC with a CSI VolumeSnapshot and restores
+// the snapshot to a new PVC
+func cloneVolumeCSI(ctx context.Context, cli kubernetes.Interface, snapCli snapshotclient.Interface, namespace, pvc, snapshotClass, storageClass string) (*volumeClone, error) {
+	source, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(pvc, metav1.GetOptions{})
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve PVC. Namespace %s, Name %s", namespace, pvc)
+	}
+	if storageClass == "" && source.Spec.StorageClassName != nil {
+		storageClass = *source.Spec.StorageClassName
+	}
+	var class *string
+	if snapshotClass != "" {
+		class = &snapshotClass
+	}
+	name := fmt.Sprintf(backupVolumeFromSnapshotName, rand.String(10))
+	deleteSnapshot := func(ctx context.Context) error {
+		return errors.Wrapf(snapshot.Delete(ctx, snapCli, name, namespace), "Failed to delete VolumeSnapshot %s", name)
+	}
+	if err := snapshot.Create(ctx, cli, snapCli, name, namespace, pvc, class, true); err != nil {
+		// The snapshot may have been created before the wait failed
+		deleteSnapshot(context.Background()) // nolint: errcheck
+		return nil, errors.Wrapf(err, "Failed to snapshot PVC %s", pvc)
+	}
+	clone, err := kubevolume.CreatePVCFromSnapshot(ctx, cli, snapCli, namespace, name, storageClass, name, nil)
+	if err != nil {
+		deleteSnapshot(context.Background()) // nolint: errcheck
+		return nil, errors.Wrapf(err, "Failed to create PVC from VolumeSnapshot %s", name)
+	}
+	return &volumeClone{
+		pvc: clone,
+		cleanup: func(ctx context.Context) error {
+			if err := kubevolume.DeletePVC(cli, namespace, clone); err != nil {
+				return errors.Wrapf(err, "Failed to delete PVC %s", clone)
+			}
+			return deleteSnapshot(ctx)
+		},
+	}, nil
+}
+
+// cloneVolumeProvider snapshots the volume of the PVC with the API of the
+// storage provider and binds a new volume, created from the snapshot, to a
+// new PVC. The new volume is deleted with the PVC.
+func cloneVolumeProvider(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc string, getter getter.Getter) (*volumeClone, error) {
+	volInfo, err := getPVCInfo(ctx, cli, namespace, pvc, tp, getter)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get PVC info")
+	}
+	snapInfo, err := snapshotVolume(ctx, *volInfo, namespace, false)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to snapshot PVC %s", pvc)
+	}
+	provider := volInfo.provider
+	snap, err := provider.SnapshotGet(ctx, snapInfo.SnapshotID)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to get Snapshot from Provider")
+	}
+	deleteSnapshot := func(ctx context.Context) error {
+		return errors.Wrapf(provider.SnapshotDelete(ctx, snap), "Failed to delete snapshot %s", snap.ID)
+	}
+	name := fmt.Sprintf(backupVolumeFromSnapshotName, rand.String(10))
+	snap.Volume.Az = snapInfo.Az
+	snap.Volume.VolumeType = snapInfo.VolumeType
+	snap.Volume.Tags = snapInfo.Tags
+	vol, err := provider.VolumeCreateFromSnapshot(ctx, *snap, map[string]string{"pvcname": name})
+	if err != nil {
+		deleteSnapshot(context.Background()) // nolint: errcheck
+		return nil, errors.Wrapf(err, "Failed to create volume from snapshot, snapID: %s", snap.ID)
+	}
+	annotations := map[string]string{}
+	deleteVolume := func(ctx context.Context) error {
+		return errors.Wrapf(provider.VolumeDelete(ctx, vol), "Failed to delete volume %s", vol.ID)
+	}
+	clone, err := kubevolume.CreatePVC(ctx, cli, namespace, name, vol.Size, vol.ID, annotations)
+	if err != nil {
+		deleteVolume(context.Background())   // nolint: errcheck
+		deleteSnapshot(context.Background()) // nolint: errcheck
+		return nil, errors.Wrapf(err, "Unable to create PVC for volume %v", *vol)
+	}
+	if _, err = kubevolume.CreatePV(ctx, cli, vol, vol.Type, annotations); err != nil {
+		kubevolume.DeletePVC(cli, namespace, clone) // nolint: errcheck
+		deleteVolume(context.Background())          // nolint: errcheck
+		deleteSnapshot(context.Background())        // nolint: errcheck
+		return nil, errors.Wrapf(err, "Unable to create PV for volume %v", *vol)
+	}
+	return &volumeClone{
+		pvc: clone,
+		cleanup: func(ctx context.Context) error {
+			// The PV has the Delete reclaim policy, so the volume is deleted
+			// once the PVC is gone
+			if err := kubevolume.DeletePVC(cli, namespace, clone); err != nil {
+				return errors.Wrapf(err, "Failed to delete PVC %s", clone)
+			}
+			return deleteSnapshot(ctx)
+		},
+	}, nil
+}
+
+func backupVolumeFromSnapshot(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc, targetPath string, password repositoryPassword, includePaths []string, opts datamover.BackupInput, clone *volumeClone) (out map[string]interface{}, err error) {
+	defer func() {
+		// Clean up even if the phase was cancelled
+		cerr := clone.cleanup(context.Background())
+		if cerr == nil {
+			return
+		}
+		if err != nil {
+			log.WithError(cerr).Errorf("Failed to clean up the clone of PVC %s", pvc)
+			return
+		}
+		out, err = nil, cerr
+	}()
+	// The clone is mounted where CopyVolumeData mounts the PVC, so that the
+	// backups of both functions can be restored alike
+	mountPoint := fmt.Sprintf(copyVolumeDataMountPoint, pvc)
+	return copyVolumeDataFromPVC(ctx, cli, tp, namespace, clone.pvc, mountPoint, targetPath, password, includePaths, opts)
+}
+
+func (*backupVolumeFromSnapshotFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var namespace, vol, targetPath, snapshotType, snapshotClass, storageClass string
+	var err error
+	if err = Arg(args, BackupVolumeFromSnapshotNamespaceArg, &namespace); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, BackupVolumeFromSnapshotVolumeArg, &vol); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, BackupVolumeFromSnapshotArtifactPrefixArg, &targetPath); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, BackupVolumeFromSnapshotSnapshotTypeArg, &snapshotType, SnapshotTypeCSI); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, BackupVolumeFromSnapshotSnapshotClassArg, &snapshotClass, ""); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, BackupVolumeFromSnapshotStorageClassArg, &storageClass, ""); err != nil {
+		return nil, err
+	}
+	switch snapshotType {
+	case SnapshotTypeCSI:
+	case SnapshotTypeProvider:
+		if snapshotClass != "" || storageClass != "" {
+			return nil, errors.Errorf("%s and %s require %s %s", BackupVolumeFromSnapshotSnapshotClassArg, BackupVolumeFromSnapshotStorageClassArg, BackupVolumeFromSnapshotSnapshotTypeArg, SnapshotTypeCSI)
+		}
+	default:
+		return nil, errors.Errorf("Unsupported %s %s", BackupVolumeFromSnapshotSnapshotTypeArg, snapshotType)
+	}
+	var includePaths []string
+	if err = OptArg(args, BackupVolumeFromSnapshotIncludePathsArg, &includePaths, nil); err != nil {
+		return nil, err
+	}
+	opts, err := backupOptions(args, BackupVolumeFromSnapshotExcludePathsArg, BackupVolumeFromSnapshotExcludeCachesArg, BackupVolumeFromSnapshotOneFileSystemArg)
+	if err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withBandwidthLimit(args, BackupVolumeFromSnapshotUploadLimitArg, BackupVolumeFromSnapshotDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
+	cli, err := kube.NewClient()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
+	}
+	passwords, err := newRepositoryPasswords(cli, tp, args, BackupVolumeFromSnapshotEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	password, err := passwords.getOrCreate(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	var clone *volumeClone
+	switch snapshotType {
+	case SnapshotTypeCSI:
+		snapCli, err := kube.NewSnapshotClient()
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to create Kubernetes snapshot client")
+		}
+		clone, err = cloneVolumeCSI(ctx, cli, snapCli, namespace, vol, snapshotClass, storageClass)
+		if err != nil {
+			return nil, err
+		}
+	case SnapshotTypeProvider:
+		clone, err = cloneVolumeProvider(ctx, cli, tp, namespace, vol, getter.New())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return backupVolumeFromSnapshot(ctx, cli, tp, namespace, vol, targetPath, password, includePaths, opts, clone)
+}
+
+func (*backupVolumeFromSnapshotFunc) RequiredArgs() []string {
+	return []string{BackupVolumeFromSnapshotNamespaceArg, BackupVolumeFromSnapshotVolumeArg, BackupVolumeFromSnapshotArtifactPrefixArg}
+}
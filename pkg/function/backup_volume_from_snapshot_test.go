@@ -0,0 +1,116 @@
+package function
+
+import (
+	"context"
+	"fmt"
+
+	. "gopkg.in/check.v1"
+	"k8s.io/api/core/v1"
+	k8sresource "k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/testing"
+
+	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	kubevolume "github.com/kanisterio/kanister/pkg/kube/volume"
+	"github.com/kanisterio/kanister/pkg/param"
+	"github.com/kanisterio/kanister/pkg/testutil/mockblockstorage"
+)
+
+type BackupVolumeFromSnapshotSuite struct{}
+
+var _ = Suite(&BackupVolumeFromSnapshotSuite{})
+
+func (s *BackupVolumeFromSnapshotSuite) TestCloneVolumeProvider(c *C) {
+	ctx := context.Background()
+	ns := "ns"
+	tp := param.TemplateParams{
+		Profile: &param.Profile{
+			Location: crv1alpha1.Location{
+				Type:   crv1alpha1.LocationTypeS3Compliant,
+				Region: "us-west-2",
+			},
+			Credential: param.Credential{
+				Type: param.CredentialTypeKeyPair,
+				KeyPair: &param.KeyPair{
+					ID:     "foo",
+					Secret: "bar",
+				},
+			},
+		},
+	}
+	cli := fake.NewSimpleClientset(
+		&v1.PersistentVolumeClaim{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "pvc-test",
+				Namespace: ns,
+			},
+			Spec: v1.PersistentVolumeClaimSpec{
+				VolumeName: "pv-test",
+			},
+		},
+		&v1.PersistentVolume{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "pv-test",
+				Labels: map[string]string{
+					kubevolume.PVZoneLabelName:   "us-west-2a",
+					kubevolume.PVRegionLabelName: "us-west-2",
+				},
+			},
+			Spec: v1.PersistentVolumeSpec{
+				Capacity: v1.ResourceList{
+					v1.ResourceStorage: *k8sresource.NewQuantity(1, k8sresource.BinarySI),
+				},
+				PersistentVolumeSource: v1.PersistentVolumeSource{
+					AWSElasticBlockStore: &v1.AWSElasticBlockStoreVolumeSource{
+						VolumeID: "vol-abc123",
+					},
+				},
+			},
+		},
+	)
+	// fake doesn't handle generated names for PVs, so ...
+	pvl := &v1.PersistentVolumeList{}
+	cli.PrependReactor("create", "persistentvolumes",
+		func(action testing.Action) (handled bool, ret runtime.Object, err error) {
+			pv := action.(testing.CreateAction).GetObject().(*v1.PersistentVolume)
+			pv.ObjectMeta.Name = fmt.Sprintf("%s%d", pv.ObjectMeta.GenerateName, len(pvl.Items))
+			pvl.Items = append(pvl.Items, *pv)
+			return true, pv, nil
+		})
+	cli.PrependReactor("list", "persistentvolumes",
+		func(action testing.Action) (handled bool, ret runtime.Object, err error) {
+			return true, pvl, nil
+		})
+	clone, err := cloneVolumeProvider(ctx, cli, tp, ns, "pvc-test", mockblockstorage.NewGetter())
+	c.Assert(err, IsNil)
+	c.Assert(clone.pvc, Matches, "kanister-clone-.*")
+	pvc, err := cli.CoreV1().PersistentVolumeClaims(ns).Get(clone.pvc, metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(pvc.Spec.Selector, NotNil)
+	c.Assert(pvl.Items, HasLen, 1)
+	c.Assert(pvl.Items[0].Spec.PersistentVolumeReclaimPolicy, Equals, v1.PersistentVolumeReclaimDelete)
+
+	err = clone.cleanup(ctx)
+	c.Assert(err, IsNil)
+	_, err = cli.CoreV1().PersistentVolumeClaims(ns).Get(clone.pvc, metav1.GetOptions{})
+	c.Assert(err, NotNil)
+
+	_, err = cloneVolumeProvider(ctx, cli, tp, ns, "pvc-missing", mockblockstorage.NewGetter())
+	c.Assert(err, NotNil)
+}
+
+func (s *BackupVolumeFromSnapshotSuite) TestSnapshotTypeArgs(c *C) {
+	for _, args := range []map[string]interface{}{
+		{BackupVolumeFromSnapshotSnapshotTypeArg: "Clone"},
+		{BackupVolumeFromSnapshotSnapshotTypeArg: SnapshotTypeProvider, BackupVolumeFromSnapshotSnapshotClassArg: "csi-snapclass"},
+		{BackupVolumeFromSnapshotSnapshotTypeArg: SnapshotTypeProvider, BackupVolumeFromSnapshotStorageClassArg: "standard"},
+	} {
+		args[BackupVolumeFromSnapshotNamespaceArg] = "ns"
+		args[BackupVolumeFromSnapshotVolumeArg] = "pvc-test"
+		args[BackupVolumeFromSnapshotArtifactPrefixArg] = "repo"
+		_, err := (&backupVolumeFromSnapshotFunc{}).Exec(context.Background(), param.TemplateParams{}, args)
+		c.Check(err, NotNil)
+	}
+}
@@ -0,0 +1,175 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/client-go/kubernetes"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+const (
+	// BackupVolumeTarNamespaceArg provides the namespace of the PVC
+	BackupVolumeTarNamespaceArg = "namespace"
+	// BackupVolumeTarVolumeArg provides the name of the PVC that is backed up
+	BackupVolumeTarVolumeArg = "volume"
+	// BackupVolumeTarPathArg provides the directory in the volume that is backed up. It defaults to the root of the volume.
+	BackupVolumeTarPathArg = "path"
+	// BackupVolumeTarArtifactPrefixArg provides the path on the object store under which the archive is stored
+	BackupVolumeTarArtifactPrefixArg = "backupArtifactPrefix"
+	// BackupVolumeTarCompressionArg provides the codec used to compress the archive, gzip or zstd
+	BackupVolumeTarCompressionArg = "compression"
+	// BackupVolumeTarUploadLimitArg provides the maximum upload rate in KiB/s
+	BackupVolumeTarUploadLimitArg = "uploadLimit"
+	// BackupVolumeTarDownloadLimitArg provides the maximum download rate in KiB/s
+	BackupVolumeTarDownloadLimitArg = "downloadLimit"
+	// BackupVolumeTarOutputArtifact is the path of the archive on the object store
+	BackupVolumeTarOutputArtifact = "backupArtifactLocation"
+	// BackupVolumeTarOutputSize is the size of the uncompressed archive in bytes
+	BackupVolumeTarOutputSize = "size"
+	volumeTarJobPrefix        = "volume-tar-"
+)
+
+func init() {
+	kanister.Register(&backupVolumeTarFunc{})
+}
+
+var _ kanister.Func = (*backupVolumeTarFunc)(nil)
+
+type backupVolumeTarFunc struct{}
+
+func (*backupVolumeTarFunc) Name() string {
+	return "BackupVolumeTar"
+}
+
+// volumeTarArtifact returns a new path for the archive of the PVC
+func volumeTarArtifact(prefix, pvc string) string {
+	return path.Join(prefix, fmt.Sprintf("%s-%s.tar", pvc, rand.String(10)))
+}
+
+// volumeTarDir returns the directory of the volume mounted at the mount
+// point. The directory cannot be outside the volume.
+func volumeTarDir(mountPoint, dir string) string {
+	return filepath.Join(mountPoint, filepath.Join("/", dir))
+}
+
+// tarCommand writes a tar archive of the directory to stdout
+func tarCommand(dir string) []string {
+	return []string{"tar", "-cf", "-", "-C", dir, "."}
+}
+
+// countingWriter counts the bytes written to w
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func backupVolumeTar(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc, dir, artifact string, opts location.WriteOptions) (map[string]interface{}, error) {
+	// Validate PVC exists
+	if _, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(pvc, metav1.GetOptions{}); err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve PVC. Namespace %s, Name %s", namespace, pvc)
+	}
+	mountPoint := fmt.Sprintf(copyVolumeDataMountPoint, pvc)
+	options := &kube.PodOptions{
+		Namespace:    namespace,
+		GenerateName: volumeTarJobPrefix,
+		Image:        kanisterToolsImage,
+		Command:      []string{"sh", "-c", "tail -f /dev/null"},
+		Volumes:      map[string]string{pvc: mountPoint},
+	}
+	pr := kube.NewPodRunner(cli, options)
+	podFunc := backupVolumeTarPodFunc(cli, tp, volumeTarDir(mountPoint, dir), artifact, opts)
+	return pr.Run(ctx, podFunc)
+}
+
+func backupVolumeTarPodFunc(cli kubernetes.Interface, tp param.TemplateParams, dir, artifact string, opts location.WriteOptions) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+		// Wait for pod to reach running state
+		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
+			return nil, errors.Wrapf(err, "Failed while waiting for Pod %s to be ready", pod.Name)
+		}
+		pr, pw := io.Pipe()
+		cw := &countingWriter{w: pw}
+		go func() {
+			execOpts := kube.ExecOptions{
+				Command:       tarCommand(dir),
+				Namespace:     pod.Namespace,
+				PodName:       pod.Name,
+				ContainerName: pod.Spec.Containers[0].Name,
+				Stdout:        cw,
+				CaptureStderr: true,
+			}
+			_, stderr, err := kube.ExecWithOptions(cli, execOpts)
+			// A failed archive fails the upload
+			pw.CloseWithError(errors.Wrapf(err, "Failed to archive %s: %s", dir, stderr))
+		}()
+		err := location.WriteWithOptions(ctx, pr, *tp.Profile, artifact, opts)
+		// Stop the archive if the upload failed
+		pr.CloseWithError(err)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Failed to upload archive of %s to %s", dir, artifact)
+		}
+		return map[string]interface{}{
+			BackupVolumeTarOutputArtifact: artifact,
+			BackupVolumeTarOutputSize:     cw.n,
+		}, nil
+	}
+}
+
+func (*backupVolumeTarFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var namespace, vol, dir, prefix, compression string
+	var err error
+	if err = Arg(args, BackupVolumeTarNamespaceArg, &namespace); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, BackupVolumeTarVolumeArg, &vol); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, BackupVolumeTarArtifactPrefixArg, &prefix); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, BackupVolumeTarPathArg, &dir, ""); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, BackupVolumeTarCompressionArg, &compression, ""); err != nil {
+		return nil, err
+	}
+	codec, err := location.ParseCodec(compression)
+	if err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withBandwidthLimit(args, BackupVolumeTarUploadLimitArg, BackupVolumeTarDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	// Validate the Profile
+	if err = validateProfile(tp.Profile); err != nil {
+		return nil, errors.Wrapf(err, "Failed to validate Profile")
+	}
+	cli, err := kube.NewClient()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
+	}
+	opts := location.WriteOptions{Compression: codec}
+	return backupVolumeTar(ctx, cli, tp, namespace, vol, dir, volumeTarArtifact(prefix, vol), opts)
+}
+
+func (*backupVolumeTarFunc) RequiredArgs() []string {
+	return []string{BackupVolumeTarNamespaceArg, BackupVolumeTarVolumeArg, BackupVolumeTarArtifactPrefixArg}
+}
@@ -0,0 +1,61 @@
+package function
+
+import (
+	"bytes"
+	"context"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+type VolumeTarSuite struct{}
+
+var _ = Suite(&VolumeTarSuite{})
+
+func (s *VolumeTarSuite) TestVolumeTarDir(c *C) {
+	for _, tc := range []struct {
+		dir  string
+		want string
+	}{
+		{dir: "", want: "/mnt/vol_data/pvc"},
+		{dir: "data/db", want: "/mnt/vol_data/pvc/data/db"},
+		{dir: "/data/", want: "/mnt/vol_data/pvc/data"},
+		{dir: "../../etc", want: "/mnt/vol_data/pvc/etc"},
+	} {
+		c.Check(volumeTarDir("/mnt/vol_data/pvc", tc.dir), Equals, tc.want)
+	}
+}
+
+func (s *VolumeTarSuite) TestVolumeTarArtifact(c *C) {
+	c.Assert(volumeTarArtifact("backups/app", "pvc"), Matches, "backups/app/pvc-[a-z0-9]{10}\\.tar")
+	c.Assert(volumeTarArtifact("backups/app", "pvc"), Not(Equals), volumeTarArtifact("backups/app", "pvc"))
+}
+
+func (s *VolumeTarSuite) TestCommands(c *C) {
+	c.Assert(tarCommand("/mnt/vol_data/pvc"), DeepEquals, []string{"tar", "-cf", "-", "-C", "/mnt/vol_data/pvc", "."})
+	cmd := untarCommand("/mnt/vol_data/pvc/my dir")
+	c.Assert(cmd[len(cmd)-1], Equals, "/mnt/vol_data/pvc/my dir")
+}
+
+func (s *VolumeTarSuite) TestCountingWriter(c *C) {
+	var buf bytes.Buffer
+	cw := &countingWriter{w: &buf}
+	for _, p := range []string{"tar", "", "archive"} {
+		_, err := cw.Write([]byte(p))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(cw.n, Equals, int64(10))
+	c.Assert(buf.String(), Equals, "tararchive")
+}
+
+func (s *VolumeTarSuite) TestBackupCompressionArg(c *C) {
+	args := map[string]interface{}{
+		BackupVolumeTarNamespaceArg:      "ns",
+		BackupVolumeTarVolumeArg:         "pvc",
+		BackupVolumeTarArtifactPrefixArg: "backups",
+		BackupVolumeTarCompressionArg:    "lz4",
+	}
+	_, err := (&backupVolumeTarFunc{}).Exec(context.Background(), param.TemplateParams{}, args)
+	c.Assert(err, ErrorMatches, "unsupported compression codec 'lz4'")
+}
@@ -0,0 +1,129 @@
+package function
+
+import (
+	"context"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/client/clientset/versioned"
+	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+const (
+	// CopyArtifactArtifactArg provides the path to the artifacts to copy
+	CopyArtifactArtifactArg = "artifact"
+	// CopyArtifactDestProfileArg provides the name of the Profile to copy to
+	CopyArtifactDestProfileArg = "destinationProfile"
+	// CopyArtifactDestProfileNamespaceArg provides the namespace of the Profile to copy to
+	CopyArtifactDestProfileNamespaceArg = "destinationProfileNamespace"
+	// CopyArtifactDestArtifactArg provides the path of the copies. It defaults to the source path.
+	CopyArtifactDestArtifactArg = "destinationArtifact"
+	// CopyArtifactVerifyArg requests reading back the copies to check them against their checksums
+	CopyArtifactVerifyArg = "verify"
+	// CopyArtifactOutputArtifact is the path of the copies
+	CopyArtifactOutputArtifact = "destinationArtifact"
+	// CopyArtifactOutputCount is the number of copied artifacts
+	CopyArtifactOutputCount = "artifactCount"
+	// CopyArtifactOutputSize is the size of the copied data in bytes
+	CopyArtifactOutputSize = "size"
+)
+
+func init() {
+	kanister.Register(&copyArtifactFunc{})
+}
+
+var _ kanister.Func = (*copyArtifactFunc)(nil)
+
+type copyArtifactFunc struct{}
+
+func (*copyArtifactFunc) Name() string {
+	return "CopyArtifact"
+}
+
+func (*copyArtifactFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var artifact, destProfile, destProfileNamespace, destArtifact string
+	var verify bool
+	var err error
+	if err = Arg(args, CopyArtifactArtifactArg, &artifact); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, CopyArtifactDestProfileArg, &destProfile); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, CopyArtifactDestProfileNamespaceArg, &destProfileNamespace); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, CopyArtifactDestArtifactArg, &destArtifact, ""); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, CopyArtifactVerifyArg, &verify, false); err != nil {
+		return nil, err
+	}
+	// Validate the Profile
+	if err = validateProfile(tp.Profile); err != nil {
+		return nil, errors.Wrapf(err, "Failed to validate Profile")
+	}
+	dst, err := fetchDestinationProfile(ctx, destProfile, destProfileNamespace)
+	if err != nil {
+		return nil, err
+	}
+	if err = validateProfile(dst); err != nil {
+		return nil, errors.Wrapf(err, "Failed to validate destination Profile")
+	}
+	src := bucketRelativePath(artifact, tp.Profile.Location.Bucket)
+	if destArtifact == "" {
+		destArtifact = path.Join(dst.Location.Bucket, src)
+	}
+	opts := location.CopyOptions{
+		Verify: verify,
+		Progress: func(p location.CopyProgress) {
+			log.Infof("Copied artifact %s to %s (%d bytes)", p.Source, p.Destination, p.Size)
+		},
+	}
+	res, err := location.Copy(ctx, *tp.Profile, *dst, src, bucketRelativePath(destArtifact, dst.Location.Bucket), opts)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to copy artifact %s", artifact)
+	}
+	return map[string]interface{}{
+		CopyArtifactOutputArtifact: destArtifact,
+		CopyArtifactOutputCount:    res.Artifacts,
+		CopyArtifactOutputSize:     res.Size,
+	}, nil
+}
+
+func (*copyArtifactFunc) RequiredArgs() []string {
+	return []string{CopyArtifactArtifactArg, CopyArtifactDestProfileArg, CopyArtifactDestProfileNamespaceArg}
+}
+
+// bucketRelativePath returns the path of the artifact in the bucket. The path
+// of the artifact may start with the name of the bucket.
+func bucketRelativePath(artifact, bucket string) string {
+	if bucket == "" {
+		return artifact
+	}
+	return strings.TrimPrefix(artifact, bucket+"/")
+}
+
+func fetchDestinationProfile(ctx context.Context, name, namespace string) (*param.Profile, error) {
+	cli, err := kube.NewClient()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
+	}
+	config, err := kube.LoadConfig()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to load Kubernetes config")
+	}
+	crCli, err := versioned.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kanister client")
+	}
+	p, err := param.FetchProfile(ctx, cli, crCli, &crv1alpha1.ObjectReference{Name: name, Namespace: namespace})
+	return p, errors.Wrapf(err, "Failed to fetch destination Profile %s/%s", namespace, name)
+}
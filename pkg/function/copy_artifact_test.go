@@ -0,0 +1,24 @@
+package function
+
+import (
+	. "gopkg.in/check.v1"
+)
+
+type CopyArtifactSuite struct{}
+
+var _ = Suite(&CopyArtifactSuite{})
+
+func (s *CopyArtifactSuite) TestBucketRelativePath(c *C) {
+	for _, tc := range []struct {
+		artifact string
+		bucket   string
+		want     string
+	}{
+		{artifact: "bucket/backups/app", bucket: "bucket", want: "backups/app"},
+		{artifact: "bucket-2/backups/app", bucket: "bucket", want: "bucket-2/backups/app"},
+		{artifact: "backups/app", bucket: "bucket", want: "backups/app"},
+		{artifact: "backups/app", bucket: "", want: "backups/app"},
+	} {
+		c.Check(bucketRelativePath(tc.artifact, tc.bucket), Equals, tc.want, Commentf("%s", tc.artifact))
+	}
+}
@@ -3,6 +3,7 @@ package function
 import (
 	"context"
 	"fmt"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 	"k8s.io/api/core/v1"
@@ -11,10 +12,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	kanister "github.com/kanisterio/kanister/pkg"
-	"github.com/kanisterio/kanister/pkg/format"
+	"github.com/kanisterio/kanister/pkg/datamover"
 	"github.com/kanisterio/kanister/pkg/kube"
 	"github.com/kanisterio/kanister/pkg/param"
-	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 const (
@@ -29,6 +29,15 @@ const (
 	CopyVolumeDataOutputBackupArtifactLocation = "backupArtifactLocation"
 	CopyVolumeDataEncryptionKeyArg             = "encryptionKey"
 	CopyVolumeDataOutputBackupTag              = "backupTag"
+	CopyVolumeDataOutputBackupSize             = "size"
+	CopyVolumeDataOutputBackupSizeAdded        = "sizeAdded"
+	CopyVolumeDataOutputBackupFileCount        = "fileCount"
+	CopyVolumeDataUploadLimitArg               = "uploadLimit"
+	CopyVolumeDataDownloadLimitArg             = "downloadLimit"
+	CopyVolumeDataIncludePathsArg              = "includePaths"
+	CopyVolumeDataExcludePathsArg              = "excludePaths"
+	CopyVolumeDataExcludeCachesArg             = "excludeCaches"
+	CopyVolumeDataOneFileSystemArg             = "oneFileSystem"
 )
 
 func init() {
@@ -43,26 +52,40 @@ func (*copyVolumeDataFunc) Name() string {
 	return "CopyVolumeData"
 }
 
-func copyVolumeData(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc, targetPath, encryptionKey string) (map[string]interface{}, error) {
+func copyVolumeData(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc, targetPath string, password repositoryPassword, includePaths []string, opts datamover.BackupInput) (map[string]interface{}, error) {
 	// Validate PVC exists
 	if _, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(pvc, metav1.GetOptions{}); err != nil {
 		return nil, errors.Wrapf(err, "Failed to retrieve PVC. Namespace %s, Name %s", namespace, pvc)
 	}
-	// Create a pod with PVCs attached
 	mountPoint := fmt.Sprintf(copyVolumeDataMountPoint, pvc)
+	return copyVolumeDataFromPVC(ctx, cli, tp, namespace, pvc, mountPoint, targetPath, password, includePaths, opts)
+}
+
+// copyVolumeDataFromPVC backs up the PVC mounted at the mount point, which
+// need not be named after the PVC
+func copyVolumeDataFromPVC(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc, mountPoint, targetPath string, password repositoryPassword, includePaths []string, opts datamover.BackupInput) (map[string]interface{}, error) {
+	// Create a pod with PVCs attached
 	options := &kube.PodOptions{
 		Namespace:    namespace,
 		GenerateName: copyVolumeDataJobPrefix,
 		Image:        kanisterToolsImage,
 		Command:      []string{"sh", "-c", "tail -f /dev/null"},
-		Volumes:      map[string]string{pvc: mountPoint},
+		Volumes:      withLocationVolumes(map[string]string{pvc: mountPoint}, tp.Profile),
 	}
 	pr := kube.NewPodRunner(cli, options)
-	podFunc := copyVolumeDataPodFunc(cli, tp, namespace, mountPoint, targetPath, encryptionKey)
+	// The included paths are relative to the root of the volume
+	paths := []string{mountPoint}
+	if len(includePaths) > 0 {
+		paths = make([]string, 0, len(includePaths))
+		for _, p := range includePaths {
+			paths = append(paths, filepath.Join(mountPoint, p))
+		}
+	}
+	podFunc := copyVolumeDataPodFunc(cli, tp, namespace, mountPoint, targetPath, password, paths, opts)
 	return pr.Run(ctx, podFunc)
 }
 
-func copyVolumeDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, namespace, mountPoint, targetPath, encryptionKey string) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+func copyVolumeDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, namespace, mountPoint, targetPath string, password repositoryPassword, includePaths []string, opts datamover.BackupInput) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
 	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
 		// Wait for pod to reach running state
 		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
@@ -73,36 +96,35 @@ func copyVolumeDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, na
 			return nil, err
 		}
 		defer cleanUpCredsFile(ctx, pw, pod.Namespace, pod.Name, pod.Spec.Containers[0].Name)
-		// Get restic repository
-		if err := restic.GetOrCreateRepository(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, targetPath, encryptionKey, tp.Profile); err != nil {
+		mover, err := newDataMover(cli, tp.Profile, namespace, pod.Name, pod.Spec.Containers[0].Name, targetPath, password.value)
+		if err != nil {
+			return nil, err
+		}
+		if err := initRepository(ctx, mover, password); err != nil {
 			return nil, err
 		}
 		// Copy data to object store
-		backupTag := rand.String(10)
-		cmd := restic.BackupCommandByTag(tp.Profile, targetPath, backupTag, mountPoint, encryptionKey)
-		stdout, stderr, err := kube.Exec(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, cmd, nil)
-		format.Log(pod.Name, pod.Spec.Containers[0].Name, stdout)
-		format.Log(pod.Name, pod.Spec.Containers[0].Name, stderr)
+		opts.Tag = rand.String(10)
+		opts.Paths = includePaths
+		backup, err := mover.Backup(ctx, opts)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Failed to create and upload backup")
-		}
-		// Get the snapshot ID from log
-		backupID := restic.SnapshotIDFromBackupLog(stdout)
-		if backupID == "" {
-			return nil, errors.New("Failed to parse the backup ID from logs")
+			return nil, err
 		}
 		return map[string]interface{}{
-				CopyVolumeDataOutputBackupID:               backupID,
+				CopyVolumeDataOutputBackupID:               backup.ID,
 				CopyVolumeDataOutputBackupRoot:             mountPoint,
 				CopyVolumeDataOutputBackupArtifactLocation: targetPath,
-				CopyVolumeDataOutputBackupTag:              backupTag,
+				CopyVolumeDataOutputBackupTag:              backup.Tag,
+				CopyVolumeDataOutputBackupSize:             backup.Size,
+				CopyVolumeDataOutputBackupSizeAdded:        backup.SizeAdded,
+				CopyVolumeDataOutputBackupFileCount:        backup.FileCount,
 			},
 			nil
 	}
 }
 
 func (*copyVolumeDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
-	var namespace, vol, targetPath, encryptionKey string
+	var namespace, vol, targetPath string
 	var err error
 	if err = Arg(args, CopyVolumeDataNamespaceArg, &namespace); err != nil {
 		return nil, err
@@ -113,14 +135,33 @@ func (*copyVolumeDataFunc) Exec(ctx context.Context, tp param.TemplateParams, ar
 	if err = Arg(args, CopyVolumeDataArtifactPrefixArg, &targetPath); err != nil {
 		return nil, err
 	}
-	if err = OptArg(args, CopyVolumeDataEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
+	var includePaths []string
+	if err = OptArg(args, CopyVolumeDataIncludePathsArg, &includePaths, nil); err != nil {
+		return nil, err
+	}
+	opts, err := backupOptions(args, CopyVolumeDataExcludePathsArg, CopyVolumeDataExcludeCachesArg, CopyVolumeDataOneFileSystemArg)
+	if err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withBandwidthLimit(args, CopyVolumeDataUploadLimitArg, CopyVolumeDataDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
 		return nil, err
 	}
 	cli, err := kube.NewClient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
-	return copyVolumeData(ctx, cli, tp, namespace, vol, targetPath, encryptionKey)
+	passwords, err := newRepositoryPasswords(cli, tp, args, CopyVolumeDataEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	password, err := passwords.getOrCreate(targetPath)
+	if err != nil {
+		return nil, err
+	}
+	return copyVolumeData(ctx, cli, tp, namespace, vol, targetPath, password, includePaths, opts)
 }
 
 func (*copyVolumeDataFunc) RequiredArgs() []string {
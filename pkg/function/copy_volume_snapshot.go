@@ -0,0 +1,195 @@
+package function
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/blockstorage"
+	"github.com/kanisterio/kanister/pkg/blockstorage/awsebs"
+	"github.com/kanisterio/kanister/pkg/blockstorage/getter"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+func init() {
+	kanister.Register(&copyVolumeSnapshotFunc{})
+}
+
+var (
+	_ kanister.Func = (*copyVolumeSnapshotFunc)(nil)
+)
+
+const (
+	CopyVolumeSnapshotManifestArg              = "snapshots"
+	CopyVolumeSnapshotDestRegionArg            = "destinationRegion"
+	CopyVolumeSnapshotDestZoneArg              = "destinationZone"
+	CopyVolumeSnapshotDestProfileArg           = "destinationProfile"
+	CopyVolumeSnapshotDestProfileNamespaceArg  = "destinationProfileNamespace"
+	CopyVolumeSnapshotOutputVolumeSnapshotInfo = "volumeSnapshotInfo"
+)
+
+type copyVolumeSnapshotFunc struct{}
+
+func (*copyVolumeSnapshotFunc) Name() string {
+	return "CopyVolumeSnapshot"
+}
+
+// copyVolumeSnapshots copies the snapshots of the manifest to the destination
+// region with the credentials of the destination Profile, after sharing them
+// with its account. It returns a manifest of the copies.
+func copyVolumeSnapshots(ctx context.Context, snapshotinfo string, profile, destProfile *param.Profile, destRegion, destZone string, getter getter.Getter) (string, error) {
+	PVCData := []VolumeSnapshotInfo{}
+	err := json.Unmarshal([]byte(snapshotinfo), &PVCData)
+	if err != nil {
+		return "", errors.Wrapf(err, "Could not decode JSON data")
+	}
+	copies := make([]VolumeSnapshotInfo, 0, len(PVCData))
+	for _, pvcInfo := range PVCData {
+		if err = ValidateProfile(profile, pvcInfo.Type); err != nil {
+			return "", errors.Wrap(err, "Profile validation failed")
+		}
+		if err = ValidateProfile(destProfile, pvcInfo.Type); err != nil {
+			return "", errors.Wrap(err, "Destination Profile validation failed")
+		}
+		region := destRegion
+		if region == "" {
+			region = pvcInfo.Region
+		}
+		provider, err := getter.Get(pvcInfo.Type, snapshotProviderConfig(profile, pvcInfo.Type, pvcInfo.Region))
+		if err != nil {
+			return "", errors.Wrapf(err, "Could not get storage provider %v", pvcInfo.Type)
+		}
+		destProvider, err := getter.Get(pvcInfo.Type, snapshotProviderConfig(destProfile, pvcInfo.Type, region))
+		if err != nil {
+			return "", errors.Wrapf(err, "Could not get storage provider %v", pvcInfo.Type)
+		}
+		snapshot, err := provider.SnapshotGet(ctx, pvcInfo.SnapshotID)
+		if err != nil {
+			return "", errors.Wrapf(err, "Failed to get Snapshot from Provider")
+		}
+		snapshot.Region = pvcInfo.Region
+		if destProfile != profile {
+			if err = shareSnapshot(ctx, provider, destProvider, *snapshot); err != nil {
+				return "", err
+			}
+		}
+		snap, err := destProvider.SnapshotCopy(ctx, *snapshot, blockstorage.Snapshot{Region: region})
+		if err != nil {
+			return "", errors.Wrapf(err, "Failed to copy snapshot %s to region %s", pvcInfo.SnapshotID, region)
+		}
+		if err = destProvider.SnapshotCreateWaitForCompletion(ctx, snap); err != nil {
+			return "", errors.Wrap(err, "Snapshot copy did not complete "+snap.ID)
+		}
+		log.Infof("Copied snapshot %s of pvc %s to snapshot %s in region %s", pvcInfo.SnapshotID, pvcInfo.PVCName, snap.ID, region)
+		c := pvcInfo
+		c.SnapshotID = snap.ID
+		c.Region = region
+		c.Az = destinationZone(pvcInfo.Az, pvcInfo.Region, region, destZone)
+		copies = append(copies, c)
+	}
+	manifestData, err := json.Marshal(copies)
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to encode JSON data")
+	}
+	return string(manifestData), nil
+}
+
+// shareSnapshot shares the snapshot with the account of the destination
+// provider, so that the copy can be made with its credentials. Nothing is
+// shared if both providers use the same account.
+func shareSnapshot(ctx context.Context, provider, destProvider blockstorage.Provider, snapshot blockstorage.Snapshot) error {
+	sharer, ok := provider.(blockstorage.SnapshotSharer)
+	destSharer, destOK := destProvider.(blockstorage.SnapshotSharer)
+	if !ok || !destOK {
+		return errors.Errorf("Snapshots of type %v cannot be shared with another account", provider.Type())
+	}
+	account, err := sharer.AccountID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Could not get the source account")
+	}
+	destAccount, err := destSharer.AccountID(ctx)
+	if err != nil {
+		return errors.Wrap(err, "Could not get the destination account")
+	}
+	if account == destAccount {
+		return nil
+	}
+	return sharer.SnapshotShare(ctx, snapshot, destAccount)
+}
+
+func snapshotProviderConfig(profile *param.Profile, sType blockstorage.Type, region string) map[string]string {
+	config := make(map[string]string)
+	switch sType {
+	case blockstorage.TypeEBS:
+		config[awsebs.ConfigRegion] = region
+		config[awsebs.AccessKeyID] = profile.Credential.KeyPair.ID
+		config[awsebs.SecretAccessKey] = profile.Credential.KeyPair.Secret
+	case blockstorage.TypeGPD:
+		config[blockstorage.GoogleProjectID] = profile.Credential.KeyPair.ID
+		config[blockstorage.GoogleServiceKey] = profile.Credential.KeyPair.Secret
+	}
+	return config
+}
+
+// destinationZone returns the zone in which volumes are restored from the
+// copy of a snapshot. Unless a zone is given, it is the zone of the source
+// volume with the source region replaced, e.g. us-west-2a becomes
+// us-east-1a.
+func destinationZone(az, region, destRegion, destZone string) string {
+	switch {
+	case destZone != "":
+		return destZone
+	case region == destRegion || !strings.HasPrefix(az, region):
+		return az
+	default:
+		return destRegion + strings.TrimPrefix(az, region)
+	}
+}
+
+func (*copyVolumeSnapshotFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var snapshotinfo, destRegion, destZone, destProfileName, destProfileNamespace string
+	if err := Arg(args, CopyVolumeSnapshotManifestArg, &snapshotinfo); err != nil {
+		return nil, err
+	}
+	if err := OptArg(args, CopyVolumeSnapshotDestRegionArg, &destRegion, ""); err != nil {
+		return nil, err
+	}
+	if err := OptArg(args, CopyVolumeSnapshotDestZoneArg, &destZone, ""); err != nil {
+		return nil, err
+	}
+	if err := OptArg(args, CopyVolumeSnapshotDestProfileArg, &destProfileName, ""); err != nil {
+		return nil, err
+	}
+	if err := OptArg(args, CopyVolumeSnapshotDestProfileNamespaceArg, &destProfileNamespace, ""); err != nil {
+		return nil, err
+	}
+	if (destProfileName == "") != (destProfileNamespace == "") {
+		return nil, errors.New("Both destinationProfile and destinationProfileNamespace must be set")
+	}
+	destProfile := tp.Profile
+	if destProfileName != "" {
+		var err error
+		if destProfile, err = fetchDestinationProfile(ctx, destProfileName, destProfileNamespace); err != nil {
+			return nil, err
+		}
+		if destRegion == "" {
+			destRegion = destProfile.Location.Region
+		}
+	}
+	if destRegion == "" && destProfileName == "" {
+		return nil, errors.New("Either destinationRegion or destinationProfile must be set")
+	}
+	manifest, err := copyVolumeSnapshots(ctx, snapshotinfo, tp.Profile, destProfile, destRegion, destZone, getter.New())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{CopyVolumeSnapshotOutputVolumeSnapshotInfo: manifest}, nil
+}
+
+func (*copyVolumeSnapshotFunc) RequiredArgs() []string {
+	return []string{CopyVolumeSnapshotManifestArg}
+}
@@ -0,0 +1,135 @@
+package function
+
+import (
+	"context"
+	"encoding/json"
+
+	. "gopkg.in/check.v1"
+
+	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/blockstorage"
+	"github.com/kanisterio/kanister/pkg/blockstorage/awsebs"
+	"github.com/kanisterio/kanister/pkg/param"
+	"github.com/kanisterio/kanister/pkg/testutil/mockblockstorage"
+)
+
+type CopyVolumeSnapshotTestSuite struct{}
+
+var _ = Suite(&CopyVolumeSnapshotTestSuite{})
+
+func (s *CopyVolumeSnapshotTestSuite) TestCopy(c *C) {
+	ctx := context.Background()
+	mockGetter := mockblockstorage.NewGetter()
+	profile := &param.Profile{
+		Location: crv1alpha1.Location{
+			Type:   crv1alpha1.LocationTypeS3Compliant,
+			Region: "us-west-2",
+		},
+		Credential: param.Credential{
+			Type: param.CredentialTypeKeyPair,
+			KeyPair: &param.KeyPair{
+				ID:     "foo",
+				Secret: "bar",
+			},
+		},
+	}
+	pvcData := []VolumeSnapshotInfo{
+		{SnapshotID: "snap-1", Type: blockstorage.TypeEBS, Region: "us-west-2", PVCName: "pvc-1", Az: "us-west-2a", VolumeType: "ssd"},
+		{SnapshotID: "snap-2", Type: blockstorage.TypeEBS, Region: "us-west-2", PVCName: "pvc-2", Az: "us-west-2b", VolumeType: "ssd"},
+	}
+	info, err := json.Marshal(pvcData)
+	c.Assert(err, IsNil)
+	for _, tc := range []struct {
+		destRegion string
+		destZone   string
+		zones      []string
+	}{
+		{destRegion: "us-east-1", zones: []string{"us-east-1a", "us-east-1b"}},
+		{destRegion: "us-east-1", destZone: "us-east-1c", zones: []string{"us-east-1c", "us-east-1c"}},
+		{destRegion: "us-west-2", zones: []string{"us-west-2a", "us-west-2b"}},
+	} {
+		manifest, err := copyVolumeSnapshots(ctx, string(info), profile, profile, tc.destRegion, tc.destZone, mockGetter)
+		c.Assert(err, IsNil)
+		var copies []VolumeSnapshotInfo
+		err = json.Unmarshal([]byte(manifest), &copies)
+		c.Assert(err, IsNil)
+		c.Assert(copies, HasLen, len(pvcData))
+		for i, cp := range copies {
+			c.Assert(cp.SnapshotID, Not(Equals), "")
+			c.Assert(cp.Region, Equals, tc.destRegion)
+			c.Assert(cp.Az, Equals, tc.zones[i])
+			c.Assert(cp.PVCName, Equals, pvcData[i].PVCName)
+			c.Assert(cp.VolumeType, Equals, pvcData[i].VolumeType)
+		}
+	}
+}
+
+// sharingProvider is a mock provider in the account of its credentials that
+// records the snapshots shared with other accounts
+type sharingProvider struct {
+	*mockblockstorage.Provider
+	account string
+	shared  map[string][]string
+}
+
+func (p *sharingProvider) AccountID(context.Context) (string, error) {
+	return p.account, nil
+}
+
+func (p *sharingProvider) SnapshotShare(ctx context.Context, snapshot blockstorage.Snapshot, accountID string) error {
+	p.shared[snapshot.ID] = append(p.shared[snapshot.ID], accountID)
+	return nil
+}
+
+type sharingGetter struct {
+	shared map[string][]string
+}
+
+func (g sharingGetter) Get(storageType blockstorage.Type, config map[string]string) (blockstorage.Provider, error) {
+	return &sharingProvider{
+		Provider: mockblockstorage.Get(storageType),
+		account:  config[awsebs.AccessKeyID],
+		shared:   g.shared,
+	}, nil
+}
+
+func (s *CopyVolumeSnapshotTestSuite) TestCopyAcrossAccounts(c *C) {
+	ctx := context.Background()
+	newProfile := func(id string) *param.Profile {
+		return &param.Profile{
+			Location: crv1alpha1.Location{
+				Type:   crv1alpha1.LocationTypeS3Compliant,
+				Region: "us-west-2",
+			},
+			Credential: param.Credential{
+				Type:    param.CredentialTypeKeyPair,
+				KeyPair: &param.KeyPair{ID: id, Secret: "bar"},
+			},
+		}
+	}
+	profile := newProfile("source")
+	pvcData := []VolumeSnapshotInfo{
+		{SnapshotID: "snap-1", Type: blockstorage.TypeEBS, Region: "us-west-2", PVCName: "pvc-1", Az: "us-west-2a"},
+	}
+	info, err := json.Marshal(pvcData)
+	c.Assert(err, IsNil)
+
+	// The snapshot is shared with the account of the destination Profile
+	g := sharingGetter{shared: map[string][]string{}}
+	_, err = copyVolumeSnapshots(ctx, string(info), profile, newProfile("destination"), "us-east-1", "", g)
+	c.Assert(err, IsNil)
+	c.Assert(g.shared, HasLen, 1)
+	for _, accounts := range g.shared {
+		c.Assert(accounts, DeepEquals, []string{"destination"})
+	}
+
+	// Nothing is shared within an account
+	g = sharingGetter{shared: map[string][]string{}}
+	_, err = copyVolumeSnapshots(ctx, string(info), profile, newProfile("source"), "us-east-1", "", g)
+	c.Assert(err, IsNil)
+	c.Assert(g.shared, HasLen, 0)
+
+	// Providers that cannot share snapshots cannot copy them across accounts
+	_, err = copyVolumeSnapshots(ctx, string(info), profile, newProfile("destination"), "us-east-1", "", mockblockstorage.NewGetter())
+	c.Assert(err, ErrorMatches, ".*cannot be shared with another account")
+}
@@ -30,7 +30,8 @@ type DataSuite struct {
 }
 
 const (
-	testBucketName = "kio-store-tests"
+	testBucketName               = "kio-store-tests"
+	testRepositoryPasswordSecret = "kanister-test-repository-passwords"
 )
 
 var _ = Suite(&DataSuite{providerType: objectstore.ProviderTypeS3})
@@ -149,6 +150,33 @@ func newBackupDataBlueprint() *crv1alpha1.Blueprint {
 	}
 }
 
+func newMaintainRepositoryBlueprint() *crv1alpha1.Blueprint {
+	return &crv1alpha1.Blueprint{
+		Actions: map[string]*crv1alpha1.BlueprintAction{
+			"maintain": &crv1alpha1.BlueprintAction{
+				Kind: param.StatefulSetKind,
+				SecretNames: []string{
+					"backupKey",
+				},
+				Phases: []crv1alpha1.BlueprintPhase{
+					crv1alpha1.BlueprintPhase{
+						Name: "testMaintainRepository",
+						Func: "MaintainRepository",
+						Args: map[string]interface{}{
+							MaintainRepositoryNamespaceArg:            "{{ .StatefulSet.Namespace }}",
+							MaintainRepositoryBackupArtifactPrefixArg: "{{ .Profile.Location.Bucket }}/{{ .Profile.Location.Prefix }}",
+							MaintainRepositoryEncryptionKeyArg:        "{{ .Secrets.backupKey.Data.password | toString }}",
+							MaintainRepositoryUnlockArg:               true,
+							MaintainRepositoryReadDataSubsetArg:       "1/2",
+							MaintainRepositoryKeepLastArg:             1,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
 func newLocationDeleteBlueprint() *crv1alpha1.Blueprint {
 	return &crv1alpha1.Blueprint{
 		Actions: map[string]*crv1alpha1.BlueprintAction{
@@ -182,6 +210,7 @@ func newBackupDataAllBlueprint() *crv1alpha1.Blueprint {
 							BackupDataAllContainerArg:            "{{ index .StatefulSet.Containers 0 0 }}",
 							BackupDataAllIncludePathArg:          "/etc",
 							BackupDataAllBackupArtifactPrefixArg: "{{ .Profile.Location.Bucket }}/{{ .Profile.Location.Prefix }}",
+							RepositoryPasswordSecretArg:          testRepositoryPasswordSecret,
 						},
 					},
 				},
@@ -205,6 +234,7 @@ func newRestoreDataAllBlueprint() *crv1alpha1.Blueprint {
 							RestoreDataAllBackupArtifactPrefixArg: "{{ .Profile.Location.Bucket }}/{{ .Profile.Location.Prefix }}",
 							RestoreDataAllBackupInfo:              fmt.Sprintf("{{ .Options.%s }}", BackupDataAllOutput),
 							RestoreDataAllRestorePathArg:          "/mnt/data",
+							RepositoryPasswordSecretArg:           testRepositoryPasswordSecret,
 						},
 					},
 				},
@@ -227,6 +257,7 @@ func newDeleteDataAllBlueprint() *crv1alpha1.Blueprint {
 							DeleteDataAllBackupArtifactPrefixArg: "{{ .Profile.Location.Bucket }}/{{ .Profile.Location.Prefix }}",
 							DeleteDataAllBackupInfo:              fmt.Sprintf("{{ .Options.%s }}", BackupDataAllOutput),
 							DeleteDataAllReclaimSpace:            true,
+							RepositoryPasswordSecretArg:          testRepositoryPasswordSecret,
 						},
 					},
 				},
@@ -298,6 +329,8 @@ func (s *DataSuite) TestBackupRestoreDeleteData(c *C) {
 		out := runAction(c, bp, "backup", tp)
 		c.Assert(out[BackupDataOutputBackupID].(string), Not(Equals), "")
 		c.Assert(out[BackupDataOutputBackupTag].(string), Not(Equals), "")
+		c.Assert(out[BackupDataOutputFileCount].(int64) > 0, Equals, true)
+		c.Assert(out[BackupDataOutputSize].(int64) > 0, Equals, true)
 
 		options := map[string]string{
 			BackupDataOutputBackupID:  out[BackupDataOutputBackupID].(string),
@@ -309,6 +342,12 @@ func (s *DataSuite) TestBackupRestoreDeleteData(c *C) {
 		bp = *newRestoreDataBlueprint(pvc, RestoreDataBackupTagArg, BackupDataOutputBackupTag)
 		_ = runAction(c, bp, "restore", tp)
 
+		// Test maintenance
+		bp = *newMaintainRepositoryBlueprint()
+		out = runAction(c, bp, "maintain", tp)
+		c.Assert(out[MaintainRepositoryOutputSnapshotCount], Equals, 1)
+		c.Assert(out[MaintainRepositoryOutputRepositorySize].(int64) > 0, Equals, true)
+
 		bp = *newLocationDeleteBlueprint()
 		_ = runAction(c, bp, "delete", tp)
 	}
@@ -397,6 +436,7 @@ func newCopyDataTestBlueprint() crv1alpha1.Blueprint {
 							CopyVolumeDataNamespaceArg:      "{{ .PVC.Namespace }}",
 							CopyVolumeDataVolumeArg:         "{{ .PVC.Name }}",
 							CopyVolumeDataArtifactPrefixArg: "{{ .Profile.Location.Bucket }}/{{ .Profile.Location.Prefix }}/{{ .PVC.Namespace }}/{{ .PVC.Name }}",
+							RepositoryPasswordSecretArg:     testRepositoryPasswordSecret,
 						},
 					},
 				},
@@ -411,6 +451,7 @@ func newCopyDataTestBlueprint() crv1alpha1.Blueprint {
 							RestoreDataImageArg:                "kanisterio/kanister-tools:0.20.0",
 							RestoreDataBackupArtifactPrefixArg: fmt.Sprintf("{{ .Options.%s }}", CopyVolumeDataOutputBackupArtifactLocation),
 							RestoreDataBackupTagArg:            fmt.Sprintf("{{ .Options.%s }}", CopyVolumeDataOutputBackupTag),
+							RepositoryPasswordSecretArg:        testRepositoryPasswordSecret,
 							RestoreDataVolsArg: map[string]string{
 								"{{ .PVC.Name }}": fmt.Sprintf("{{ .Options.%s }}", CopyVolumeDataOutputBackupRoot),
 							},
@@ -444,6 +485,7 @@ func newCopyDataTestBlueprint() crv1alpha1.Blueprint {
 							DeleteDataNamespaceArg:            "{{ .PVC.Namespace }}",
 							DeleteDataBackupArtifactPrefixArg: fmt.Sprintf("{{ .Options.%s }}", CopyVolumeDataOutputBackupArtifactLocation),
 							DeleteDataBackupIdentifierArg:     fmt.Sprintf("{{ .Options.%s }}", CopyVolumeDataOutputBackupID),
+							RepositoryPasswordSecretArg:       testRepositoryPasswordSecret,
 						},
 					},
 				},
@@ -468,6 +510,8 @@ func (s *DataSuite) TestCopyData(c *C) {
 	c.Assert(out[CopyVolumeDataOutputBackupRoot].(string), Not(Equals), "")
 	c.Assert(out[CopyVolumeDataOutputBackupArtifactLocation].(string), Not(Equals), "")
 	c.Assert(out[CopyVolumeDataOutputBackupTag].(string), Not(Equals), "")
+	c.Assert(out[CopyVolumeDataOutputBackupFileCount].(int64) > 0, Equals, true)
+	c.Assert(out[CopyVolumeDataOutputBackupSize].(int64) > 0, Equals, true)
 	options := map[string]string{
 		CopyVolumeDataOutputBackupID:               out[CopyVolumeDataOutputBackupID].(string),
 		CopyVolumeDataOutputBackupRoot:             out[CopyVolumeDataOutputBackupRoot].(string),
@@ -9,10 +9,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/kanisterio/kanister/pkg"
-	"github.com/kanisterio/kanister/pkg/format"
+	"github.com/kanisterio/kanister/pkg/datamover"
 	"github.com/kanisterio/kanister/pkg/kube"
 	"github.com/kanisterio/kanister/pkg/param"
-	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 const (
@@ -43,19 +42,20 @@ func (*deleteDataFunc) Name() string {
 	return "DeleteData"
 }
 
-func deleteData(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, reclaimSpace bool, namespace, encryptionKey string, targetPaths, deleteTags, deleteIdentifiers []string, jobPrefix string) (map[string]interface{}, error) {
+func deleteData(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, reclaimSpace bool, namespace string, passwords repositoryPasswords, targetPaths, deleteTags, deleteIdentifiers []string, jobPrefix string) (map[string]interface{}, error) {
 	options := &kube.PodOptions{
 		Namespace:    namespace,
 		GenerateName: jobPrefix,
 		Image:        kanisterToolsImage,
 		Command:      []string{"sh", "-c", "tail -f /dev/null"},
+		Volumes:      locationVolumes(tp.Profile),
 	}
 	pr := kube.NewPodRunner(cli, options)
-	podFunc := deleteDataPodFunc(cli, tp, reclaimSpace, namespace, encryptionKey, targetPaths, deleteTags, deleteIdentifiers)
+	podFunc := deleteDataPodFunc(cli, tp, reclaimSpace, namespace, passwords, targetPaths, deleteTags, deleteIdentifiers)
 	return pr.Run(ctx, podFunc)
 }
 
-func deleteDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, reclaimSpace bool, namespace, encryptionKey string, targetPaths, deleteTags, deleteIdentifiers []string) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+func deleteDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, reclaimSpace bool, namespace string, passwords repositoryPasswords, targetPaths, deleteTags, deleteIdentifiers []string) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
 	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
 		// Wait for pod to reach running state
 		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
@@ -69,31 +69,32 @@ func deleteDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, reclai
 			return nil, err
 		}
 		defer cleanUpCredsFile(ctx, pw, pod.Namespace, pod.Name, pod.Spec.Containers[0].Name)
-		for i, deleteTag := range deleteTags {
-			cmd := restic.SnapshotsCommandByTag(tp.Profile, targetPaths[i], deleteTag, encryptionKey)
-			stdout, stderr, err := kube.Exec(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, cmd, nil)
-			format.Log(pod.Name, pod.Spec.Containers[0].Name, stdout)
-			format.Log(pod.Name, pod.Spec.Containers[0].Name, stderr)
+		movers := make([]datamover.DataMover, len(targetPaths))
+		for i, targetPath := range targetPaths {
+			encryptionKey, err := passwords.get(targetPath)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Failed to forget data, could not get snapshotID from tag, Tag: %s", deleteTag)
+				return nil, err
 			}
-			deleteIdentifier, err := restic.SnapshotIDFromSnapshotLog(stdout)
+			if movers[i], err = newDataMover(cli, tp.Profile, namespace, pod.Name, pod.Spec.Containers[0].Name, targetPath, encryptionKey); err != nil {
+				return nil, err
+			}
+		}
+		for i, deleteTag := range deleteTags {
+			snapshots, err := movers[i].Snapshots(ctx, deleteTag)
 			if err != nil {
 				return nil, errors.Wrapf(err, "Failed to forget data, could not get snapshotID from tag, Tag: %s", deleteTag)
 			}
-			deleteIdentifiers = append(deleteIdentifiers, deleteIdentifier)
+			if len(snapshots) != 1 {
+				return nil, errors.Errorf("Failed to forget data, could not get snapshotID from tag, Tag: %s: Snapshot not found", deleteTag)
+			}
+			deleteIdentifiers = append(deleteIdentifiers, snapshots[0].ID)
 		}
 		for i, deleteIdentifier := range deleteIdentifiers {
-			cmd := restic.ForgetCommandByID(tp.Profile, targetPaths[i], deleteIdentifier, encryptionKey)
-			stdout, stderr, err := kube.Exec(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, cmd, nil)
-			format.Log(pod.Name, pod.Spec.Containers[0].Name, stdout)
-			format.Log(pod.Name, pod.Spec.Containers[0].Name, stderr)
-			if err != nil {
-				return nil, errors.Wrapf(err, "Failed to forget data")
+			if err = movers[i].Forget(ctx, deleteIdentifier); err != nil {
+				return nil, err
 			}
 			if reclaimSpace {
-				err := pruneData(cli, tp, pod, namespace, encryptionKey, targetPaths[i])
-				if err != nil {
+				if err = movers[i].Prune(ctx); err != nil {
 					return nil, errors.Wrapf(err, "Error executing prune command")
 				}
 			}
@@ -103,16 +104,8 @@ func deleteDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, reclai
 	}
 }
 
-func pruneData(cli kubernetes.Interface, tp param.TemplateParams, pod *v1.Pod, namespace, encryptionKey, targetPath string) error {
-	cmd := restic.PruneCommand(tp.Profile, targetPath, encryptionKey)
-	stdout, stderr, err := kube.Exec(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, cmd, nil)
-	format.Log(pod.Name, pod.Spec.Containers[0].Name, stdout)
-	format.Log(pod.Name, pod.Spec.Containers[0].Name, stderr)
-	return errors.Wrapf(err, "Failed to prune data after forget")
-}
-
 func (*deleteDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
-	var namespace, deleteArtifactPrefix, deleteIdentifier, deleteTag, encryptionKey string
+	var namespace, deleteArtifactPrefix, deleteIdentifier, deleteTag string
 	var reclaimSpace bool
 	var err error
 	if err = Arg(args, DeleteDataNamespaceArg, &namespace); err != nil {
@@ -127,9 +120,6 @@ func (*deleteDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args m
 	if err = OptArg(args, DeleteDataBackupTagArg, &deleteTag, ""); err != nil {
 		return nil, err
 	}
-	if err = OptArg(args, DeleteDataEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
-		return nil, err
-	}
 	if err = OptArg(args, DeleteDataReclaimSpace, &reclaimSpace, false); err != nil {
 		return nil, err
 	}
@@ -137,11 +127,18 @@ func (*deleteDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args m
 	if err = validateProfile(tp.Profile); err != nil {
 		return nil, err
 	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
 	cli, err := kube.NewClient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
-	return deleteData(ctx, cli, tp, reclaimSpace, namespace, encryptionKey, strings.Fields(deleteArtifactPrefix), strings.Fields(deleteTag), strings.Fields(deleteIdentifier), deleteDataJobPrefix)
+	passwords, err := newRepositoryPasswords(cli, tp, args, DeleteDataEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	return deleteData(ctx, cli, tp, reclaimSpace, namespace, passwords, strings.Fields(deleteArtifactPrefix), strings.Fields(deleteTag), strings.Fields(deleteIdentifier), deleteDataJobPrefix)
 }
 
 func (*deleteDataFunc) RequiredArgs() []string {
@@ -10,7 +10,6 @@ import (
 	kanister "github.com/kanisterio/kanister/pkg"
 	"github.com/kanisterio/kanister/pkg/kube"
 	"github.com/kanisterio/kanister/pkg/param"
-	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 const (
@@ -40,7 +39,7 @@ func (*deleteDataAllFunc) Name() string {
 }
 
 func (*deleteDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
-	var namespace, deleteArtifactPrefix, backupInfo, encryptionKey string
+	var namespace, deleteArtifactPrefix, backupInfo string
 	var reclaimSpace bool
 	var err error
 	if err = Arg(args, DeleteDataAllNamespaceArg, &namespace); err != nil {
@@ -52,9 +51,6 @@ func (*deleteDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, arg
 	if err = Arg(args, DeleteDataAllBackupInfo, &backupInfo); err != nil {
 		return nil, err
 	}
-	if err = OptArg(args, DeleteDataAllEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
-		return nil, err
-	}
 	if err = OptArg(args, DeleteDataAllReclaimSpace, &reclaimSpace, false); err != nil {
 		return nil, err
 	}
@@ -62,10 +58,17 @@ func (*deleteDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, arg
 	if err = validateProfile(tp.Profile); err != nil {
 		return nil, err
 	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
 	cli, err := kube.NewClient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
+	passwords, err := newRepositoryPasswords(cli, tp, args, DeleteDataAllEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
 	input := make(map[string]BackupInfo)
 	err = json.Unmarshal([]byte(backupInfo), &input)
 	if err != nil {
@@ -78,7 +81,7 @@ func (*deleteDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, arg
 		deleteIdentifiers = append(deleteIdentifiers, info.BackupID)
 	}
 
-	return deleteData(ctx, cli, tp, reclaimSpace, namespace, encryptionKey, targetPaths, nil, deleteIdentifiers, deleteDataAllJobPrefix)
+	return deleteData(ctx, cli, tp, reclaimSpace, namespace, passwords, targetPaths, nil, deleteIdentifiers, deleteDataAllJobPrefix)
 }
 
 func (*deleteDataAllFunc) RequiredArgs() []string {
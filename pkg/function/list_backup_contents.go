@@ -0,0 +1,157 @@
+package function
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/param"
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+const (
+	// ListBackupContentsNamespaceArg provides the namespace in which the listing pod runs
+	ListBackupContentsNamespaceArg = "namespace"
+	// ListBackupContentsBackupArtifactPrefixArg provides the path of the backed up artifact
+	ListBackupContentsBackupArtifactPrefixArg = "backupArtifactPrefix"
+	// ListBackupContentsBackupIdentifierArg provides the ID of the backup
+	ListBackupContentsBackupIdentifierArg = "backupID"
+	// ListBackupContentsBackupTagArg provides the tag of the backup
+	ListBackupContentsBackupTagArg = "backupTag"
+	// ListBackupContentsPathsArg provides the paths in the backup to be listed
+	ListBackupContentsPathsArg = "paths"
+	// ListBackupContentsEncryptionKeyArg provides the encryption key used during backup
+	ListBackupContentsEncryptionKeyArg = "encryptionKey"
+	// ListBackupContentsMaxNodesArg provides the maximum number of nodes in the listing
+	ListBackupContentsMaxNodesArg = "maxNodes"
+	// ListBackupContentsOutput is the key used for returning the listing as JSON
+	ListBackupContentsOutput    = "contents"
+	listBackupContentsJobPrefix = "list-backup-contents-"
+	// defaultListBackupContentsMaxNodes keeps the listing small enough to be
+	// stored in the ActionSet status
+	defaultListBackupContentsMaxNodes = 1000
+)
+
+func init() {
+	kanister.Register(&listBackupContentsFunc{})
+}
+
+var _ kanister.Func = (*listBackupContentsFunc)(nil)
+
+type listBackupContentsFunc struct{}
+
+func (*listBackupContentsFunc) Name() string {
+	return "ListBackupContents"
+}
+
+// lsCommand returns the command that lists the files of the backup with the
+// ID or, if it is empty, of the latest backup with the tag
+func lsCommand(profile *param.Profile, repository, backupID, backupTag string, paths []string) []string {
+	if backupID != "" {
+		return restic.LsCommandByID(profile, repository, backupID, paths)
+	}
+	return restic.LsCommandByTag(profile, repository, backupTag, paths)
+}
+
+func listBackupContents(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, encryptionKey string, maxNodes int, cmd []string) (map[string]interface{}, error) {
+	options := &kube.PodOptions{
+		Namespace:    namespace,
+		GenerateName: listBackupContentsJobPrefix,
+		Image:        kanisterToolsImage,
+		Command:      []string{"sh", "-c", "tail -f /dev/null"},
+		Volumes:      locationVolumes(tp.Profile),
+	}
+	pr := kube.NewPodRunner(cli, options)
+	podFunc := listBackupContentsPodFunc(cli, tp, namespace, encryptionKey, maxNodes, cmd)
+	return pr.Run(ctx, podFunc)
+}
+
+func listBackupContentsPodFunc(cli kubernetes.Interface, tp param.TemplateParams, namespace, encryptionKey string, maxNodes int, cmd []string) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+		// Wait for pod to reach running state
+		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
+			return nil, errors.Wrapf(err, "Failed while waiting for Pod %s to be ready", pod.Name)
+		}
+		pw, err := getPodWriter(cli, ctx, pod.Namespace, pod.Name, pod.Spec.Containers[0].Name, tp.Profile)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanUpCredsFile(ctx, pw, pod.Namespace, pod.Name, pod.Spec.Containers[0].Name)
+		// The output is parsed as it is streamed, so that only maxNodes
+		// nodes are held in memory however large the backup is
+		lw := restic.NewLsWriter(maxNodes)
+		if _, err := restic.ExecStream(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, cmd, tp.Profile, encryptionKey, lw); err != nil {
+			return nil, errors.Wrap(err, "Failed to list backup contents")
+		}
+		listing, err := lw.Listing()
+		if err != nil {
+			return nil, err
+		}
+		contents, err := json.Marshal(listing)
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to encode JSON data")
+		}
+		return map[string]interface{}{ListBackupContentsOutput: string(contents)}, nil
+	}
+}
+
+func (*listBackupContentsFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var namespace, backupArtifactPrefix, backupID, backupTag string
+	var paths []string
+	var maxNodes int
+	var err error
+	if err = Arg(args, ListBackupContentsNamespaceArg, &namespace); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, ListBackupContentsBackupArtifactPrefixArg, &backupArtifactPrefix); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, ListBackupContentsBackupIdentifierArg, &backupID, ""); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, ListBackupContentsBackupTagArg, &backupTag, ""); err != nil {
+		return nil, err
+	}
+	if (backupID != "") == (backupTag != "") {
+		return nil, errors.Errorf("Require one argument: %s or %s", ListBackupContentsBackupIdentifierArg, ListBackupContentsBackupTagArg)
+	}
+	if err = OptArg(args, ListBackupContentsPathsArg, &paths, nil); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, ListBackupContentsMaxNodesArg, &maxNodes, defaultListBackupContentsMaxNodes); err != nil {
+		return nil, err
+	}
+	if maxNodes <= 0 {
+		return nil, errors.Errorf("%s must be positive", ListBackupContentsMaxNodesArg)
+	}
+	// Validate profile
+	if err = validateProfile(tp.Profile); err != nil {
+		return nil, err
+	}
+	if err = checkDataMoverEncryption(ctx, tp.Profile); err != nil {
+		return nil, err
+	}
+	cli, err := kube.NewClient()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
+	}
+	passwords, err := newRepositoryPasswords(cli, tp, args, ListBackupContentsEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, err := passwords.get(backupArtifactPrefix)
+	if err != nil {
+		return nil, err
+	}
+	cmd := lsCommand(tp.Profile, backupArtifactPrefix, backupID, backupTag, paths)
+	return listBackupContents(ctx, cli, tp, namespace, encryptionKey, maxNodes, cmd)
+}
+
+func (*listBackupContentsFunc) RequiredArgs() []string {
+	return []string{ListBackupContentsNamespaceArg, ListBackupContentsBackupArtifactPrefixArg}
+}
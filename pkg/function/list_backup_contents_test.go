@@ -0,0 +1,50 @@
+package function
+
+import (
+	"context"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+type ListBackupContentsSuite struct{}
+
+var _ = Suite(&ListBackupContentsSuite{})
+
+func (s *ListBackupContentsSuite) TestLsCommand(c *C) {
+	profile := &param.Profile{
+		Location: v1alpha1.Location{
+			Type: v1alpha1.LocationTypeFileSystem,
+			Path: "/mnt/backups",
+		},
+	}
+	cmd := lsCommand(profile, "repo", "1a2b3c4d", "", []string{"/mnt/data"})
+	c.Assert(cmd[len(cmd)-1], Matches, "(?s).*restic ls --json '1a2b3c4d' '/mnt/data'$")
+	cmd = lsCommand(profile, "repo", "", "tag", nil)
+	c.Assert(cmd[len(cmd)-1], Matches, "(?s).*restic ls --json --tag 'tag' latest$")
+}
+
+func (s *ListBackupContentsSuite) TestBackupIdentifierArgs(c *C) {
+	for _, args := range []map[string]interface{}{
+		{},
+		{ListBackupContentsBackupIdentifierArg: "1a2b3c4d", ListBackupContentsBackupTagArg: "tag"},
+	} {
+		args[ListBackupContentsNamespaceArg] = "ns"
+		args[ListBackupContentsBackupArtifactPrefixArg] = "repo"
+		_, err := (&listBackupContentsFunc{}).Exec(context.Background(), param.TemplateParams{}, args)
+		c.Check(err, ErrorMatches, "Require one argument.*")
+	}
+}
+
+func (s *ListBackupContentsSuite) TestMaxNodesArg(c *C) {
+	args := map[string]interface{}{
+		ListBackupContentsNamespaceArg:            "ns",
+		ListBackupContentsBackupArtifactPrefixArg: "repo",
+		ListBackupContentsBackupIdentifierArg:     "1a2b3c4d",
+		ListBackupContentsMaxNodesArg:             0,
+	}
+	_, err := (&listBackupContentsFunc{}).Exec(context.Background(), param.TemplateParams{}, args)
+	c.Check(err, ErrorMatches, "maxNodes must be positive")
+}
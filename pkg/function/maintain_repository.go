@@ -0,0 +1,227 @@
+package function
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/param"
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+const (
+	// MaintainRepositoryNamespaceArg provides the namespace in which the maintenance pod runs
+	MaintainRepositoryNamespaceArg = "namespace"
+	// MaintainRepositoryBackupArtifactPrefixArg provides the path of the repository on the object store
+	MaintainRepositoryBackupArtifactPrefixArg = "backupArtifactPrefix"
+	// MaintainRepositoryEncryptionKeyArg provides the encryption key of the repository
+	MaintainRepositoryEncryptionKeyArg = "encryptionKey"
+	// MaintainRepositoryUnlockArg removes stale locks before the maintenance
+	MaintainRepositoryUnlockArg = "unlock"
+	// MaintainRepositoryCheckArg checks the integrity of the repository
+	MaintainRepositoryCheckArg = "check"
+	// MaintainRepositoryReadDataSubsetArg provides the part of the data, e.g. "1/10", that is read by the check
+	MaintainRepositoryReadDataSubsetArg = "readDataSubset"
+	// MaintainRepositoryKeepLastArg and the other keep args provide the policy for forgetting snapshots
+	MaintainRepositoryKeepLastArg    = "keepLast"
+	MaintainRepositoryKeepHourlyArg  = "keepHourly"
+	MaintainRepositoryKeepDailyArg   = "keepDaily"
+	MaintainRepositoryKeepWeeklyArg  = "keepWeekly"
+	MaintainRepositoryKeepMonthlyArg = "keepMonthly"
+	MaintainRepositoryKeepYearlyArg  = "keepYearly"
+	MaintainRepositoryKeepWithinArg  = "keepWithin"
+	// MaintainRepositoryOutputSnapshotCount is the number of snapshots left in the repository
+	MaintainRepositoryOutputSnapshotCount = "snapshotCount"
+	// MaintainRepositoryOutputForgottenSnapshotCount is the number of snapshots that were forgotten
+	MaintainRepositoryOutputForgottenSnapshotCount = "forgottenSnapshotCount"
+	// MaintainRepositoryOutputRepositorySize is the size in bytes of the data stored in the repository
+	MaintainRepositoryOutputRepositorySize = "repositorySize"
+	maintainRepositoryJobPrefix            = "maintain-repository-"
+)
+
+func init() {
+	kanister.Register(&maintainRepositoryFunc{})
+}
+
+var _ kanister.Func = (*maintainRepositoryFunc)(nil)
+
+type maintainRepositoryFunc struct{}
+
+func (*maintainRepositoryFunc) Name() string {
+	return "MaintainRepository"
+}
+
+// maintenance lists the restic commands run on the repository
+type maintenance struct {
+	unlock         bool
+	check          bool
+	readDataSubset string
+	keep           restic.KeepPolicy
+}
+
+func maintainRepository(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, repository, encryptionKey string, m maintenance) (map[string]interface{}, error) {
+	options := &kube.PodOptions{
+		Namespace:    namespace,
+		GenerateName: maintainRepositoryJobPrefix,
+		Image:        kanisterToolsImage,
+		Command:      []string{"sh", "-c", "tail -f /dev/null"},
+		Volumes:      locationVolumes(tp.Profile),
+	}
+	pr := kube.NewPodRunner(cli, options)
+	podFunc := maintainRepositoryPodFunc(cli, tp, namespace, repository, encryptionKey, m)
+	return pr.Run(ctx, podFunc)
+}
+
+func maintainRepositoryPodFunc(cli kubernetes.Interface, tp param.TemplateParams, namespace, repository, encryptionKey string, m maintenance) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+		// Wait for pod to reach running state
+		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
+			return nil, errors.Wrapf(err, "Failed while waiting for Pod %s to be ready", pod.Name)
+		}
+		container := pod.Spec.Containers[0].Name
+		pw, err := getPodWriter(cli, ctx, pod.Namespace, pod.Name, container, tp.Profile)
+		if err != nil {
+			return nil, err
+		}
+		defer cleanUpCredsFile(ctx, pw, pod.Namespace, pod.Name, container)
+		run := func(cmd []string) (string, error) {
+			stdout, _, err := restic.Exec(cli, namespace, pod.Name, container, cmd, tp.Profile, encryptionKey)
+			return stdout, err
+		}
+		// The JSON output of snapshots and stats is not logged
+		runJSON := func(cmd []string) (string, error) {
+			stdout, _, err := restic.ExecJSON(cli, namespace, pod.Name, container, cmd, tp.Profile, encryptionKey)
+			return stdout, err
+		}
+		snapshotCount := func() (int, error) {
+			stdout, err := runJSON(restic.SnapshotsCommand(tp.Profile, repository))
+			if err != nil {
+				return 0, errors.Wrap(err, "Failed to list snapshots")
+			}
+			return restic.SnapshotCountFromSnapshotLog(stdout)
+		}
+		if m.unlock {
+			if _, err := run(restic.UnlockCommand(tp.Profile, repository)); err != nil {
+				return nil, errors.Wrap(err, "Failed to remove stale locks")
+			}
+		}
+		before, err := snapshotCount()
+		if err != nil {
+			return nil, err
+		}
+		if !m.keep.IsEmpty() {
+			if _, err := run(restic.ForgetAndPruneCommand(tp.Profile, repository, m.keep)); err != nil {
+				return nil, errors.Wrap(err, "Failed to forget and prune snapshots")
+			}
+		}
+		if m.check {
+			if _, err := run(restic.CheckCommand(tp.Profile, repository, m.readDataSubset)); err != nil {
+				return nil, errors.Wrap(err, "Repository check failed")
+			}
+		}
+		after, err := snapshotCount()
+		if err != nil {
+			return nil, err
+		}
+		stdout, err := runJSON(restic.StatsCommand(tp.Profile, repository))
+		if err != nil {
+			return nil, errors.Wrap(err, "Failed to get repository stats")
+		}
+		size, err := restic.RepositorySizeFromStatsLog(stdout)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			MaintainRepositoryOutputSnapshotCount:          after,
+			MaintainRepositoryOutputForgottenSnapshotCount: before - after,
+			MaintainRepositoryOutputRepositorySize:         size,
+		}, nil
+	}
+}
+
+// maintenanceArgs reads the optional args that select the maintenance
+func maintenanceArgs(args map[string]interface{}) (maintenance, error) {
+	m := maintenance{}
+	if err := OptArg(args, MaintainRepositoryUnlockArg, &m.unlock, false); err != nil {
+		return m, err
+	}
+	if err := OptArg(args, MaintainRepositoryCheckArg, &m.check, true); err != nil {
+		return m, err
+	}
+	if err := OptArg(args, MaintainRepositoryReadDataSubsetArg, &m.readDataSubset, ""); err != nil {
+		return m, err
+	}
+	for arg, n := range map[string]*int{
+		MaintainRepositoryKeepLastArg:    &m.keep.Last,
+		MaintainRepositoryKeepHourlyArg:  &m.keep.Hourly,
+		MaintainRepositoryKeepDailyArg:   &m.keep.Daily,
+		MaintainRepositoryKeepWeeklyArg:  &m.keep.Weekly,
+		MaintainRepositoryKeepMonthlyArg: &m.keep.Monthly,
+		MaintainRepositoryKeepYearlyArg:  &m.keep.Yearly,
+	} {
+		if err := OptArg(args, arg, n, 0); err != nil {
+			return m, err
+		}
+		if *n < 0 {
+			return m, errors.Errorf("%s must not be negative", arg)
+		}
+	}
+	if err := OptArg(args, MaintainRepositoryKeepWithinArg, &m.keep.Within, ""); err != nil {
+		return m, err
+	}
+	if err := m.keep.Validate(); err != nil {
+		return m, errors.Wrapf(err, "Invalid %s", MaintainRepositoryKeepWithinArg)
+	}
+	if m.readDataSubset == "" {
+		return m, nil
+	}
+	if !m.check {
+		return m, errors.Errorf("%s requires %s", MaintainRepositoryReadDataSubsetArg, MaintainRepositoryCheckArg)
+	}
+	if err := restic.ValidateReadDataSubset(m.readDataSubset); err != nil {
+		return m, errors.Wrapf(err, "Invalid %s", MaintainRepositoryReadDataSubsetArg)
+	}
+	return m, nil
+}
+
+func (*maintainRepositoryFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var namespace, repository string
+	if err := Arg(args, MaintainRepositoryNamespaceArg, &namespace); err != nil {
+		return nil, err
+	}
+	if err := Arg(args, MaintainRepositoryBackupArtifactPrefixArg, &repository); err != nil {
+		return nil, err
+	}
+	m, err := maintenanceArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	// Validate profile
+	if err = validateProfile(tp.Profile); err != nil {
+		return nil, err
+	}
+	if err = checkDataMoverEncryption(ctx, tp.Profile); err != nil {
+		return nil, err
+	}
+	cli, err := kube.NewClient()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
+	}
+	passwords, err := newRepositoryPasswords(cli, tp, args, MaintainRepositoryEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, err := passwords.get(repository)
+	if err != nil {
+		return nil, err
+	}
+	return maintainRepository(ctx, cli, tp, namespace, repository, encryptionKey, m)
+}
+
+func (*maintainRepositoryFunc) RequiredArgs() []string {
+	return []string{MaintainRepositoryNamespaceArg, MaintainRepositoryBackupArtifactPrefixArg}
+}
@@ -0,0 +1,75 @@
+package function
+
+import (
+	. "gopkg.in/check.v1"
+
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+type MaintainRepositorySuite struct{}
+
+var _ = Suite(&MaintainRepositorySuite{})
+
+func (s *MaintainRepositorySuite) TestMaintenanceArgs(c *C) {
+	for _, tc := range []struct {
+		args       map[string]interface{}
+		m          maintenance
+		errChecker Checker
+	}{
+		{
+			args:       map[string]interface{}{},
+			m:          maintenance{check: true},
+			errChecker: IsNil,
+		},
+		{
+			args: map[string]interface{}{
+				MaintainRepositoryUnlockArg:         true,
+				MaintainRepositoryReadDataSubsetArg: "1/10",
+				MaintainRepositoryKeepLastArg:       3,
+				MaintainRepositoryKeepDailyArg:      7,
+				MaintainRepositoryKeepWithinArg:     "1y",
+			},
+			m: maintenance{
+				unlock:         true,
+				check:          true,
+				readDataSubset: "1/10",
+				keep:           restic.KeepPolicy{Last: 3, Daily: 7, Within: "1y"},
+			},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryKeepWeeklyArg: -1},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryCheckArg: false, MaintainRepositoryReadDataSubsetArg: "1/10"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryKeepLastArg: "three"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryReadDataSubsetArg: "1/10; rm -rf /"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryReadDataSubsetArg: "11/10"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryKeepWithinArg: "1y $(id)"},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{MaintainRepositoryKeepWithinArg: "1w"},
+			errChecker: NotNil,
+		},
+	} {
+		m, err := maintenanceArgs(tc.args)
+		c.Assert(err, tc.errChecker)
+		if err == nil {
+			c.Assert(m, DeepEquals, tc.m)
+		}
+	}
+}
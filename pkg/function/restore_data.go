@@ -9,10 +9,9 @@ import (
 	"k8s.io/client-go/kubernetes"
 
 	kanister "github.com/kanisterio/kanister/pkg"
-	"github.com/kanisterio/kanister/pkg/format"
+	"github.com/kanisterio/kanister/pkg/datamover"
 	"github.com/kanisterio/kanister/pkg/kube"
 	"github.com/kanisterio/kanister/pkg/param"
-	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 const (
@@ -35,6 +34,16 @@ const (
 	RestoreDataEncryptionKeyArg = "encryptionKey"
 	// RestoreDataBackupTagArg provides a unique tag added to the backup artifacts
 	RestoreDataBackupTagArg = "backupTag"
+	// RestoreDataUploadLimitArg provides the maximum upload rate in KiB/s
+	RestoreDataUploadLimitArg = "uploadLimit"
+	// RestoreDataDownloadLimitArg provides the maximum download rate in KiB/s
+	RestoreDataDownloadLimitArg = "downloadLimit"
+	// RestoreDataIncludePathsArg provides the patterns of the paths to be restored
+	RestoreDataIncludePathsArg = "includePaths"
+	// RestoreDataExcludePathsArg provides the patterns of the paths not to be restored
+	RestoreDataExcludePathsArg = "excludePaths"
+	// RestoreDataVerifyArg verifies the content of the restored files
+	RestoreDataVerifyArg = "verify"
 )
 
 func init() {
@@ -49,38 +58,54 @@ func (*restoreDataFunc) Name() string {
 	return "RestoreData"
 }
 
-func validateAndGetOptArgs(args map[string]interface{}) (string, string, string, map[string]string, string, string, error) {
-	var restorePath, encryptionKey, pod, tag, id string
+func validateAndGetOptArgs(args map[string]interface{}) (string, string, map[string]string, string, string, error) {
+	var restorePath, pod, tag, id string
 	var vols map[string]string
 	var err error
 
 	if err = OptArg(args, RestoreDataRestorePathArg, &restorePath, "/"); err != nil {
-		return restorePath, encryptionKey, pod, vols, tag, id, err
-	}
-	if err = OptArg(args, RestoreDataEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
-		return restorePath, encryptionKey, pod, vols, tag, id, err
+		return restorePath, pod, vols, tag, id, err
 	}
 	if err = OptArg(args, RestoreDataPodArg, &pod, ""); err != nil {
-		return restorePath, encryptionKey, pod, vols, tag, id, err
+		return restorePath, pod, vols, tag, id, err
 	}
 	if err = OptArg(args, RestoreDataVolsArg, &vols, nil); err != nil {
-		return restorePath, encryptionKey, pod, vols, tag, id, err
+		return restorePath, pod, vols, tag, id, err
 	}
 	if (pod != "") == (len(vols) > 0) {
-		return restorePath, encryptionKey, pod, vols, tag, id,
+		return restorePath, pod, vols, tag, id,
 			errors.Errorf("Require one argument: %s or %s", RestoreDataPodArg, RestoreDataVolsArg)
 	}
 	if err = OptArg(args, RestoreDataBackupTagArg, &tag, nil); err != nil {
-		return restorePath, encryptionKey, pod, vols, tag, id, err
+		return restorePath, pod, vols, tag, id, err
 	}
 	if err = OptArg(args, RestoreDataBackupIdentifierArg, &id, nil); err != nil {
-		return restorePath, encryptionKey, pod, vols, tag, id, err
+		return restorePath, pod, vols, tag, id, err
 	}
 	if (tag != "") == (id != "") {
-		return restorePath, encryptionKey, pod, vols, tag, id,
+		return restorePath, pod, vols, tag, id,
 			errors.Errorf("Require one argument: %s or %s", RestoreDataBackupTagArg, RestoreDataBackupIdentifierArg)
 	}
-	return restorePath, encryptionKey, pod, vols, tag, id, nil
+	return restorePath, pod, vols, tag, id, nil
+}
+
+// restoreOptions reads the args that select the restored files
+func restoreOptions(args map[string]interface{}, includeArg, excludeArg, verifyArg string) (datamover.RestoreInput, error) {
+	var opts datamover.RestoreInput
+	if err := OptArg(args, includeArg, &opts.Include, nil); err != nil {
+		return opts, err
+	}
+	if err := OptArg(args, excludeArg, &opts.Exclude, nil); err != nil {
+		return opts, err
+	}
+	if err := OptArg(args, verifyArg, &opts.Verify, false); err != nil {
+		return opts, err
+	}
+	// restic does not combine include and exclude patterns
+	if len(opts.Include) > 0 && len(opts.Exclude) > 0 {
+		return opts, errors.Errorf("Require at most one argument: %s or %s", includeArg, excludeArg)
+	}
+	return opts, nil
 }
 
 func fetchPodVolumes(pod string, tp param.TemplateParams) (map[string]string, error) {
@@ -100,7 +125,7 @@ func fetchPodVolumes(pod string, tp param.TemplateParams) (map[string]string, er
 	}
 }
 
-func restoreData(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID, jobPrefix string, vols map[string]string) (map[string]interface{}, error) {
+func restoreData(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID, jobPrefix string, vols map[string]string, opts datamover.RestoreInput) (map[string]interface{}, error) {
 	// Validate volumes
 	for pvc := range vols {
 		if _, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(pvc, metav1.GetOptions{}); err != nil {
@@ -112,14 +137,14 @@ func restoreData(ctx context.Context, cli kubernetes.Interface, tp param.Templat
 		GenerateName: jobPrefix,
 		Image:        kanisterToolsImage,
 		Command:      []string{"sh", "-c", "tail -f /dev/null"},
-		Volumes:      vols,
+		Volumes:      withLocationVolumes(vols, tp.Profile),
 	}
 	pr := kube.NewPodRunner(cli, options)
-	podFunc := restoreDataPodFunc(cli, tp, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID)
+	podFunc := restoreDataPodFunc(cli, tp, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID, opts)
 	return pr.Run(ctx, podFunc)
 }
 
-func restoreDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID string) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+func restoreDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID string, opts datamover.RestoreInput) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
 	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
 		// Wait for pod to reach running state
 		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
@@ -130,21 +155,17 @@ func restoreDataPodFunc(cli kubernetes.Interface, tp param.TemplateParams, names
 			return nil, err
 		}
 		defer cleanUpCredsFile(ctx, pw, pod.Namespace, pod.Name, pod.Spec.Containers[0].Name)
-		var cmd []string
-		// Generate restore command based on the identifier passed
-		if backupTag != "" {
-			cmd = restic.RestoreCommandByTag(tp.Profile, backupArtifactPrefix, backupTag, restorePath, encryptionKey)
-		} else if backupID != "" {
-			cmd = restic.RestoreCommandByID(tp.Profile, backupArtifactPrefix, backupID, restorePath, encryptionKey)
-		}
-		stdout, stderr, err := kube.Exec(cli, namespace, pod.Name, pod.Spec.Containers[0].Name, cmd, nil)
-		format.Log(pod.Name, pod.Spec.Containers[0].Name, stdout)
-		format.Log(pod.Name, pod.Spec.Containers[0].Name, stderr)
+		mover, err := newDataMover(cli, tp.Profile, namespace, pod.Name, pod.Spec.Containers[0].Name, backupArtifactPrefix, encryptionKey)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Failed to restore backup")
+			return nil, err
 		}
-		out, err := parseLogAndCreateOutput(stdout)
-		return out, errors.Wrap(err, "Failed to parse phase output")
+		// The tag takes precedence over the ID
+		opts.Tag = backupTag
+		if backupTag == "" {
+			opts.ID = backupID
+		}
+		opts.Target = restorePath
+		return nil, mover.Restore(ctx, opts)
 	}
 }
 
@@ -161,7 +182,11 @@ func (*restoreDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args
 		return nil, err
 	}
 	// Validate and get optional arguments
-	restorePath, encryptionKey, pod, vols, backupTag, backupID, err := validateAndGetOptArgs(args)
+	restorePath, pod, vols, backupTag, backupID, err := validateAndGetOptArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := restoreOptions(args, RestoreDataIncludePathsArg, RestoreDataExcludePathsArg, RestoreDataVerifyArg)
 	if err != nil {
 		return nil, err
 	}
@@ -169,6 +194,12 @@ func (*restoreDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args
 	if err = validateProfile(tp.Profile); err != nil {
 		return nil, err
 	}
+	if tp.Profile, err = withBandwidthLimit(args, RestoreDataUploadLimitArg, RestoreDataDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
 	if len(vols) == 0 {
 		// Fetch Volumes
 		vols, err = fetchPodVolumes(pod, tp)
@@ -180,7 +211,15 @@ func (*restoreDataFunc) Exec(ctx context.Context, tp param.TemplateParams, args
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
-	return restoreData(ctx, cli, tp, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID, restoreDataJobPrefix, vols)
+	passwords, err := newRepositoryPasswords(cli, tp, args, RestoreDataEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
+	encryptionKey, err := passwords.get(backupArtifactPrefix)
+	if err != nil {
+		return nil, err
+	}
+	return restoreData(ctx, cli, tp, namespace, encryptionKey, backupArtifactPrefix, restorePath, backupTag, backupID, restoreDataJobPrefix, vols, opts)
 }
 
 func (*restoreDataFunc) RequiredArgs() []string {
@@ -11,7 +11,6 @@ import (
 	kanister "github.com/kanisterio/kanister/pkg"
 	"github.com/kanisterio/kanister/pkg/kube"
 	"github.com/kanisterio/kanister/pkg/param"
-	"github.com/kanisterio/kanister/pkg/restic"
 )
 
 const (
@@ -30,6 +29,16 @@ const (
 	RestoreDataAllEncryptionKeyArg = "encryptionKey"
 	// RestoreDataAllBackupInfo provides backup info required for restore
 	RestoreDataAllBackupInfo = "backupInfo"
+	// RestoreDataAllUploadLimitArg provides the maximum upload rate in KiB/s
+	RestoreDataAllUploadLimitArg = "uploadLimit"
+	// RestoreDataAllDownloadLimitArg provides the maximum download rate in KiB/s
+	RestoreDataAllDownloadLimitArg = "downloadLimit"
+	// RestoreDataAllIncludePathsArg provides the patterns of the paths to be restored
+	RestoreDataAllIncludePathsArg = "includePaths"
+	// RestoreDataAllExcludePathsArg provides the patterns of the paths not to be restored
+	RestoreDataAllExcludePathsArg = "excludePaths"
+	// RestoreDataAllVerifyArg verifies the content of the restored files
+	RestoreDataAllVerifyArg = "verify"
 )
 
 func init() {
@@ -44,19 +53,16 @@ func (*restoreDataAllFunc) Name() string {
 	return "RestoreDataAll"
 }
 
-func validateAndGetRestoreAllOptArgs(args map[string]interface{}, tp param.TemplateParams) (string, string, []string, error) {
-	var restorePath, encryptionKey, pods string
+func validateAndGetRestoreAllOptArgs(args map[string]interface{}, tp param.TemplateParams) (string, []string, error) {
+	var restorePath, pods string
 	var ps []string
 	var err error
 
 	if err = OptArg(args, RestoreDataAllRestorePathArg, &restorePath, "/"); err != nil {
-		return restorePath, encryptionKey, ps, err
-	}
-	if err = OptArg(args, RestoreDataAllEncryptionKeyArg, &encryptionKey, restic.GeneratePassword()); err != nil {
-		return restorePath, encryptionKey, ps, err
+		return restorePath, ps, err
 	}
 	if err = OptArg(args, RestoreDataAllPodsArg, &pods, ""); err != nil {
-		return restorePath, encryptionKey, ps, err
+		return restorePath, ps, err
 	}
 
 	if pods != "" {
@@ -68,11 +74,11 @@ func validateAndGetRestoreAllOptArgs(args map[string]interface{}, tp param.Templ
 		case tp.StatefulSet != nil:
 			ps = tp.StatefulSet.Pods
 		default:
-			return restorePath, encryptionKey, ps, errors.New("Unsupported workload type")
+			return restorePath, ps, errors.New("Unsupported workload type")
 		}
 	}
 
-	return restorePath, encryptionKey, ps, nil
+	return restorePath, ps, nil
 }
 
 func (*restoreDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
@@ -91,7 +97,11 @@ func (*restoreDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, ar
 		return nil, err
 	}
 	// Validate and get optional arguments
-	restorePath, encryptionKey, pods, err := validateAndGetRestoreAllOptArgs(args, tp)
+	restorePath, pods, err := validateAndGetRestoreAllOptArgs(args, tp)
+	if err != nil {
+		return nil, err
+	}
+	opts, err := restoreOptions(args, RestoreDataAllIncludePathsArg, RestoreDataAllExcludePathsArg, RestoreDataAllVerifyArg)
 	if err != nil {
 		return nil, err
 	}
@@ -99,10 +109,20 @@ func (*restoreDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, ar
 	if err = validateProfile(tp.Profile); err != nil {
 		return nil, err
 	}
+	if tp.Profile, err = withBandwidthLimit(args, RestoreDataAllUploadLimitArg, RestoreDataAllDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withDataMover(ctx, args, tp.Profile); err != nil {
+		return nil, err
+	}
 	cli, err := kube.NewClient()
 	if err != nil {
 		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
 	}
+	passwords, err := newRepositoryPasswords(cli, tp, args, RestoreDataAllEncryptionKeyArg, namespace)
+	if err != nil {
+		return nil, err
+	}
 	input := make(map[string]BackupInfo)
 	err = json.Unmarshal([]byte(backupInfo), &input)
 	if err != nil {
@@ -121,7 +141,11 @@ func (*restoreDataAllFunc) Exec(ctx context.Context, tp param.TemplateParams, ar
 				outputChan <- out
 				return
 			}
-			out, err = restoreData(ctx, cli, tp, namespace, encryptionKey, fmt.Sprintf("%s/%s", backupArtifactPrefix, pod), restorePath, "", input[pod].BackupID, restoreDataAllJobPrefix, vols)
+			repository := fmt.Sprintf("%s/%s", backupArtifactPrefix, pod)
+			encryptionKey, err := passwords.get(repository)
+			if err == nil {
+				out, err = restoreData(ctx, cli, tp, namespace, encryptionKey, repository, restorePath, "", input[pod].BackupID, restoreDataAllJobPrefix, vols, opts)
+			}
 			errChan <- errors.Wrapf(err, "Failed to restore data for pod %s", pod)
 			outputChan <- out
 		}(pod)
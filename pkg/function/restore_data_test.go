@@ -3,6 +3,7 @@ package function
 import (
 	. "gopkg.in/check.v1"
 
+	"github.com/kanisterio/kanister/pkg/datamover"
 	"github.com/kanisterio/kanister/pkg/param"
 )
 
@@ -159,7 +160,53 @@ func (s *RestoreDataTestSuite) TestValidateAndGetOptArgs(c *C) {
 		},
 	}
 	for _, tc := range testCases {
-		_, _, _, _, _, _, err := validateAndGetOptArgs(tc.args)
+		_, _, _, _, _, err := validateAndGetOptArgs(tc.args)
 		c.Check(err, tc.errChecker, Commentf("Case %s failed", tc.name))
 	}
 }
+
+func (s *RestoreDataTestSuite) TestRestoreOptions(c *C) {
+	for _, tc := range []struct {
+		args       map[string]interface{}
+		opts       datamover.RestoreInput
+		errChecker Checker
+	}{
+		{
+			args:       map[string]interface{}{},
+			errChecker: IsNil,
+		},
+		{
+			args: map[string]interface{}{
+				RestoreDataIncludePathsArg: []string{"/mnt/data/db/table1.ibd", "/mnt/data/conf"},
+				RestoreDataVerifyArg:       true,
+			},
+			opts: datamover.RestoreInput{
+				Include: []string{"/mnt/data/db/table1.ibd", "/mnt/data/conf"},
+				Verify:  true,
+			},
+			errChecker: IsNil,
+		},
+		{
+			args:       map[string]interface{}{RestoreDataExcludePathsArg: []interface{}{"*.log"}},
+			opts:       datamover.RestoreInput{Exclude: []string{"*.log"}},
+			errChecker: IsNil,
+		},
+		{
+			args: map[string]interface{}{
+				RestoreDataIncludePathsArg: []string{"/mnt/data/conf"},
+				RestoreDataExcludePathsArg: []string{"*.log"},
+			},
+			errChecker: NotNil,
+		},
+		{
+			args:       map[string]interface{}{RestoreDataVerifyArg: "yes"},
+			errChecker: NotNil,
+		},
+	} {
+		opts, err := restoreOptions(tc.args, RestoreDataIncludePathsArg, RestoreDataExcludePathsArg, RestoreDataVerifyArg)
+		c.Assert(err, tc.errChecker)
+		if err == nil {
+			c.Assert(opts, DeepEquals, tc.opts)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package function
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	kanister "github.com/kanisterio/kanister/pkg"
+	"github.com/kanisterio/kanister/pkg/kube"
+	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+const (
+	// RestoreVolumeTarNamespaceArg provides the namespace of the PVC
+	RestoreVolumeTarNamespaceArg = "namespace"
+	// RestoreVolumeTarVolumeArg provides the name of the PVC into which the archive is restored
+	RestoreVolumeTarVolumeArg = "volume"
+	// RestoreVolumeTarPathArg provides the directory in the volume into which the archive is restored. It defaults to the root of the volume.
+	RestoreVolumeTarPathArg = "path"
+	// RestoreVolumeTarArtifactArg provides the path of the archive on the object store
+	RestoreVolumeTarArtifactArg = "backupArtifactLocation"
+	// RestoreVolumeTarUploadLimitArg provides the maximum upload rate in KiB/s
+	RestoreVolumeTarUploadLimitArg = "uploadLimit"
+	// RestoreVolumeTarDownloadLimitArg provides the maximum download rate in KiB/s
+	RestoreVolumeTarDownloadLimitArg = "downloadLimit"
+	// RestoreVolumeTarOutputArtifact is the path of the restored archive on the object store
+	RestoreVolumeTarOutputArtifact = "backupArtifactLocation"
+	// RestoreVolumeTarOutputSize is the size of the uncompressed archive in bytes
+	RestoreVolumeTarOutputSize = "size"
+)
+
+func init() {
+	kanister.Register(&restoreVolumeTarFunc{})
+}
+
+var _ kanister.Func = (*restoreVolumeTarFunc)(nil)
+
+type restoreVolumeTarFunc struct{}
+
+func (*restoreVolumeTarFunc) Name() string {
+	return "RestoreVolumeTar"
+}
+
+// untarCommand extracts the tar archive read from stdin into the directory,
+// which is created if it does not exist
+func untarCommand(dir string) []string {
+	return []string{"sh", "-c", `mkdir -p "$1" && tar -xf - -C "$1"`, "sh", dir}
+}
+
+func restoreVolumeTar(ctx context.Context, cli kubernetes.Interface, tp param.TemplateParams, namespace, pvc, dir, artifact string) (map[string]interface{}, error) {
+	// Validate PVC exists
+	if _, err := cli.CoreV1().PersistentVolumeClaims(namespace).Get(pvc, metav1.GetOptions{}); err != nil {
+		return nil, errors.Wrapf(err, "Failed to retrieve PVC. Namespace %s, Name %s", namespace, pvc)
+	}
+	mountPoint := fmt.Sprintf(copyVolumeDataMountPoint, pvc)
+	options := &kube.PodOptions{
+		Namespace:    namespace,
+		GenerateName: volumeTarJobPrefix,
+		Image:        kanisterToolsImage,
+		Command:      []string{"sh", "-c", "tail -f /dev/null"},
+		Volumes:      map[string]string{pvc: mountPoint},
+	}
+	pr := kube.NewPodRunner(cli, options)
+	podFunc := restoreVolumeTarPodFunc(cli, tp, volumeTarDir(mountPoint, dir), artifact)
+	return pr.Run(ctx, podFunc)
+}
+
+func restoreVolumeTarPodFunc(cli kubernetes.Interface, tp param.TemplateParams, dir, artifact string) func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+	return func(ctx context.Context, pod *v1.Pod) (map[string]interface{}, error) {
+		// Wait for pod to reach running state
+		if err := kube.WaitForPodReady(ctx, cli, pod.Namespace, pod.Name); err != nil {
+			return nil, errors.Wrapf(err, "Failed while waiting for Pod %s to be ready", pod.Name)
+		}
+		pr, pw := io.Pipe()
+		cw := &countingWriter{w: pw}
+		readErr := make(chan error, 1)
+		go func() {
+			err := location.Read(ctx, cw, *tp.Profile, artifact)
+			// A failed download fails the extraction
+			pw.CloseWithError(err)
+			readErr <- err
+		}()
+		execOpts := kube.ExecOptions{
+			Command:       untarCommand(dir),
+			Namespace:     pod.Namespace,
+			PodName:       pod.Name,
+			ContainerName: pod.Spec.Containers[0].Name,
+			Stdin:         pr,
+			CaptureStderr: true,
+		}
+		_, stderr, err := kube.ExecWithOptions(cli, execOpts)
+		if err != nil {
+			// Stop the download
+			pr.CloseWithError(err)
+			<-readErr
+			return nil, errors.Wrapf(err, "Failed to extract archive %s into %s: %s", artifact, dir, stderr)
+		}
+		// tar stops reading at the end of the archive. The rest of the
+		// artifact is read so that it is checked against its checksum.
+		io.Copy(ioutil.Discard, pr) // nolint: errcheck
+		if err := <-readErr; err != nil {
+			return nil, errors.Wrapf(err, "Failed to download archive %s", artifact)
+		}
+		return map[string]interface{}{
+			RestoreVolumeTarOutputArtifact: artifact,
+			RestoreVolumeTarOutputSize:     cw.n,
+		}, nil
+	}
+}
+
+func (*restoreVolumeTarFunc) Exec(ctx context.Context, tp param.TemplateParams, args map[string]interface{}) (map[string]interface{}, error) {
+	var namespace, vol, dir, artifact string
+	var err error
+	if err = Arg(args, RestoreVolumeTarNamespaceArg, &namespace); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, RestoreVolumeTarVolumeArg, &vol); err != nil {
+		return nil, err
+	}
+	if err = Arg(args, RestoreVolumeTarArtifactArg, &artifact); err != nil {
+		return nil, err
+	}
+	if err = OptArg(args, RestoreVolumeTarPathArg, &dir, ""); err != nil {
+		return nil, err
+	}
+	if tp.Profile, err = withBandwidthLimit(args, RestoreVolumeTarUploadLimitArg, RestoreVolumeTarDownloadLimitArg, tp.Profile); err != nil {
+		return nil, err
+	}
+	// Validate the Profile
+	if err = validateProfile(tp.Profile); err != nil {
+		return nil, errors.Wrapf(err, "Failed to validate Profile")
+	}
+	cli, err := kube.NewClient()
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to create Kubernetes client")
+	}
+	return restoreVolumeTar(ctx, cli, tp, namespace, vol, dir, artifact)
+}
+
+func (*restoreVolumeTarFunc) RequiredArgs() []string {
+	return []string{RestoreVolumeTarNamespaceArg, RestoreVolumeTarVolumeArg, RestoreVolumeTarArtifactArg}
+}
@@ -6,10 +6,12 @@ import (
 	"io/ioutil"
 	"os"
 	"reflect"
+	"strings"
 
 	"github.com/ghodss/yaml"
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"golang.org/x/crypto/ssh"
 	"golang.org/x/oauth2/google"
 	compute "google.golang.org/api/compute/v1"
 	"k8s.io/api/core/v1"
@@ -33,6 +35,12 @@ const (
 	gcpServiceKeyFlag       = "service-key"
 	AzureStorageAccountFlag = "storage-account"
 	AzureStorageKeyFlag     = "storage-key"
+	sftpHostFlag            = "host"
+	sftpPortFlag            = "port"
+	sftpPathFlag            = "path"
+	sftpUsernameFlag        = "username"
+	sftpSSHKeyFlag          = "ssh-key"
+	sftpHostKeyFlag         = "host-key"
 
 	idField           = "access_key_id"
 	secretField       = "secret_access_key"
@@ -54,6 +62,10 @@ type locationParams struct {
 	endpoint      string
 	prefix        string
 	region        string
+	host          string
+	port          int
+	path          string
+	hostKey       string
 	skipSSLVerify bool
 }
 
@@ -67,6 +79,7 @@ func newProfileCommand() *cobra.Command {
 	cmd.AddCommand(newS3CompliantProfileCmd())
 	cmd.AddCommand(newGCPProfileCmd())
 	cmd.AddCommand(newAzureProfileCmd())
+	cmd.AddCommand(newSFTPProfileCmd())
 	cmd.PersistentFlags().StringP(bucketFlag, "b", "", "object store bucket name")
 	cmd.PersistentFlags().StringP(endpointFlag, "e", "", "endpoint URL of the object store bucket")
 	cmd.PersistentFlags().StringP(prefixFlag, "p", "", "prefix URL of the object store bucket")
@@ -129,6 +142,30 @@ func newAzureProfileCmd() *cobra.Command {
 	return cmd
 }
 
+func newSFTPProfileCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "sftp",
+		Short: "Create new sftp profile",
+		Args:  cobra.ExactArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return createNewProfile(cmd, args)
+		},
+	}
+
+	cmd.Flags().String(sftpHostFlag, "", "Host name or address of the sftp server")
+	cmd.Flags().Int(sftpPortFlag, 22, "Port of the sftp server")
+	cmd.Flags().String(sftpPathFlag, "", "Directory on the sftp server under which artifacts are stored")
+	cmd.Flags().StringP(sftpUsernameFlag, "a", "", "User name used to log in to the sftp server")
+	cmd.Flags().StringP(sftpSSHKeyFlag, "s", "", "Path to the private key used to log in to the sftp server")
+	cmd.Flags().String(sftpHostKeyFlag, "", "Path to the public host key of the sftp server")
+
+	cmd.MarkFlagRequired(sftpHostFlag)
+	cmd.MarkFlagRequired(sftpPathFlag)
+	cmd.MarkFlagRequired(sftpUsernameFlag)
+	cmd.MarkFlagRequired(sftpSSHKeyFlag)
+	return cmd
+}
+
 func createNewProfile(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		return newArgsLengthError("expected 0 args. Got %#v", args)
@@ -199,11 +236,14 @@ func getLocationParams(cmd *cobra.Command) (*locationParams, error) {
 	case "azure":
 		lType = v1alpha1.LocationTypeAzure
 		profileName = "azure-profile-"
+	case "sftp":
+		lType = v1alpha1.LocationTypeSFTP
+		profileName = "sftp-profile-"
 	default:
 		return nil, errors.New("Profile type not supported: " + cmd.Name())
 	}
 	skipSSLVerify, _ := cmd.Flags().GetBool(skipSSLVerifyFlag)
-	return &locationParams{
+	lP := &locationParams{
 		locationType:  lType,
 		profileName:   profileName,
 		namespace:     ns,
@@ -212,7 +252,18 @@ func getLocationParams(cmd *cobra.Command) (*locationParams, error) {
 		prefix:        prefix,
 		region:        region,
 		skipSSLVerify: skipSSLVerify,
-	}, nil
+	}
+	if lType == v1alpha1.LocationTypeSFTP {
+		lP.host, _ = cmd.Flags().GetString(sftpHostFlag)
+		lP.port, _ = cmd.Flags().GetInt(sftpPortFlag)
+		lP.path, _ = cmd.Flags().GetString(sftpPathFlag)
+		if hostKeyFile, _ := cmd.Flags().GetString(sftpHostKeyFlag); hostKeyFile != "" {
+			if lP.hostKey, err = getHostKey(hostKeyFile); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return lP, nil
 }
 
 func constructProfile(lP *locationParams, secret *v1.Secret) *v1alpha1.Profile {
@@ -227,6 +278,10 @@ func constructProfile(lP *locationParams, secret *v1.Secret) *v1alpha1.Profile {
 			Endpoint: lP.endpoint,
 			Prefix:   lP.prefix,
 			Region:   lP.region,
+			Host:     lP.host,
+			Port:     lP.port,
+			Path:     lP.path,
+			HostKey:  lP.hostKey,
 		},
 		Credential: v1alpha1.Credential{
 			Type: v1alpha1.CredentialTypeKeyPair,
@@ -269,6 +324,16 @@ func constructSecret(ctx context.Context, lP *locationParams, cmd *cobra.Command
 		data[idField] = storageAccount
 		data[secretField] = storageKey
 		secretname = "azure"
+	case v1alpha1.LocationTypeSFTP:
+		username, _ := cmd.Flags().GetString(sftpUsernameFlag)
+		filePath, _ := cmd.Flags().GetString(sftpSSHKeyFlag)
+		sshKey, err := getSSHKey(filePath)
+		if err != nil {
+			return nil, err
+		}
+		data[idField] = username
+		data[secretField] = sshKey
+		secretname = "sftp"
 	}
 	return &v1.Secret{
 		ObjectMeta: metav1.ObjectMeta{
@@ -426,3 +491,26 @@ func getServiceKey(ctx context.Context, filename string) (string, error) {
 	}
 	return string(b), nil
 }
+
+func getSSHKey(filename string) (string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	if _, err = ssh.ParsePrivateKey(b); err != nil {
+		return "", errors.Wrap(err, "Failed to parse SSH private key")
+	}
+	return string(b), nil
+}
+
+func getHostKey(filename string) (string, error) {
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return "", err
+	}
+	key, _, _, _, err := ssh.ParseAuthorizedKey(b)
+	if err != nil {
+		return "", errors.Wrap(err, "Failed to parse host key")
+	}
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key))), nil
+}
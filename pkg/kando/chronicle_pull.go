@@ -38,7 +38,7 @@ func unmarshalProfile(prof string) (*param.Profile, error) {
 }
 
 func runChroniclePull(cmd *cobra.Command, p locationParams, arg string) error {
-	target, err := targetWriter(arg)
+	target, err := targetWriter(arg, 0)
 	if err != nil {
 		return err
 	}
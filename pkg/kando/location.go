@@ -2,6 +2,8 @@ package kando
 
 import (
 	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
@@ -10,21 +12,27 @@ import (
 )
 
 const (
-	pathFlagName    = "path"
-	profileFlagName = "profile"
+	pathFlagName              = "path"
+	profileFlagName           = "profile"
+	encryptionKeyIDFlagName   = "encryption-key-id"
+	encryptionKeyFileFlagName = "encryption-key-file"
 )
 
 func newLocationCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "location <command>",
-		Short: "Push, pull and delete from object storage",
+		Short: "Push, pull, delete, verify and copy artifacts in object storage",
 	}
 	cmd.AddCommand(newLocationPushCommand())
 	cmd.AddCommand(newLocationPullCommand())
 	cmd.AddCommand(newLocationDeleteCommand())
+	cmd.AddCommand(newLocationVerifyCommand())
+	cmd.AddCommand(newLocationCopyCommand())
 	cmd.PersistentFlags().StringP(pathFlagName, "s", "", "Specify a path suffix (optional)")
 	cmd.PersistentFlags().StringP(profileFlagName, "p", "", "Pass a Profile as a JSON string (required)")
 	cmd.MarkFlagRequired(profileFlagName)
+	cmd.PersistentFlags().String(encryptionKeyIDFlagName, "", "Specify the ID of the Profile encryption key used to encrypt pushed data (optional)")
+	cmd.PersistentFlags().String(encryptionKeyFileFlagName, "", "Specify a file with the 32 byte key used to encrypt and decrypt data instead of the Profile encryption keys (optional)")
 	return cmd
 }
 
@@ -33,8 +41,50 @@ func pathFlag(cmd *cobra.Command) string {
 }
 
 func unmarshalProfileFlag(cmd *cobra.Command) (*param.Profile, error) {
+	p, err := profileFlag(cmd)
+	if err != nil {
+		return nil, err
+	}
+	return p, applyEncryptionFlags(cmd, p)
+}
+
+func profileFlag(cmd *cobra.Command) (*param.Profile, error) {
 	profileJSON := cmd.Flag(profileFlagName).Value.String()
 	p := &param.Profile{}
-	err := json.Unmarshal([]byte(profileJSON), p)
-	return p, errors.Wrap(err, "failed to unmarshal profile")
+	if err := json.Unmarshal([]byte(profileJSON), p); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal profile")
+	}
+	return p, nil
+}
+
+// applyEncryptionFlags overrides the encryption keys of the profile with
+// the ones selected by the flags
+func applyEncryptionFlags(cmd *cobra.Command, p *param.Profile) error {
+	keyID := cmd.Flag(encryptionKeyIDFlagName).Value.String()
+	keyFile := cmd.Flag(encryptionKeyFileFlagName).Value.String()
+	if keyFile != "" {
+		key, err := ioutil.ReadFile(keyFile)
+		if err != nil {
+			return errors.Wrap(err, "failed to read encryption key")
+		}
+		if keyID == "" {
+			keyID = filepath.Base(keyFile)
+		}
+		p.Encryption = &param.Encryption{
+			KeyID: keyID,
+			Keys:  map[string][]byte{keyID: key},
+		}
+		return nil
+	}
+	if keyID == "" {
+		return nil
+	}
+	if p.Encryption == nil {
+		return errors.New("Profile has no encryption keys")
+	}
+	if _, ok := p.Encryption.Keys[keyID]; !ok {
+		return errors.Errorf("encryption key '%s' not found in Profile", keyID)
+	}
+	p.Encryption.KeyID = keyID
+	return nil
 }
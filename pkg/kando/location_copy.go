@@ -0,0 +1,97 @@
+package kando
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/objectstore"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+const (
+	destProfileFlagName = "destination-profile"
+	destPathFlagName    = "destination-path"
+	verifyFlagName      = "verify"
+)
+
+func newLocationCopyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "copy",
+		Short: "Copy an artifact or all the artifacts under a path to the location of another Profile",
+		// TODO: Example invocations
+		RunE: func(c *cobra.Command, args []string) error {
+			return runLocationCopy(c)
+		},
+	}
+	cmd.Flags().String(destProfileFlagName, "", "Pass the destination Profile as a JSON string (required)")
+	cmd.MarkFlagRequired(destProfileFlagName)
+	cmd.Flags().String(destPathFlagName, "", "Specify the destination path suffix. Defaults to the source path (optional)")
+	cmd.Flags().Bool(verifyFlagName, false, "Read back the copied artifacts and check them against their checksums (optional)")
+	cmd.Flags().Int(downloadConcurrencyFlagName, defaultDownloadConcurrency, "Specify the number of ranges downloaded in parallel")
+	cmd.Flags().String(chunkSizeFlagName, "", "Specify the size of the ranges downloaded in parallel, e.g. 32Mi (optional)")
+	cmd.Flags().String(partSizeFlagName, "", "Specify the part size of multipart uploads, e.g. 128Mi (optional)")
+	cmd.Flags().Int(uploadConcurrencyFlagName, objectstore.DefaultUploadConcurrency, "Specify the number of parts uploaded in parallel")
+	cmd.Flags().Int(partRetriesFlagName, objectstore.DefaultUploadPartRetries, "Specify the number of times a failed part is retried")
+	cmd.Flags().Int64(limitDownloadFlagName, 0, "Specify the maximum download rate in KiB/s. Defaults to the limit of the profile (optional)")
+	cmd.Flags().Int64(limitUploadFlagName, 0, "Specify the maximum upload rate in KiB/s. Defaults to the limit of the profile (optional)")
+	cmd.Flags().Bool(allowUnencryptedFlagName, false, "Read artifacts that are not encrypted although the Profile has encryption keys (optional)")
+	return cmd
+}
+
+func runLocationCopy(cmd *cobra.Command) error {
+	src, err := unmarshalProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+	dst := &param.Profile{}
+	if err := json.Unmarshal([]byte(cmd.Flag(destProfileFlagName).Value.String()), dst); err != nil {
+		return errors.Wrap(err, "failed to unmarshal destination profile")
+	}
+	read, err := readOptions(cmd)
+	if err != nil {
+		return err
+	}
+	upload, err := uploadOptions(cmd)
+	if err != nil {
+		return err
+	}
+	limit, err := uploadLimit(cmd)
+	if err != nil {
+		return err
+	}
+	verify, _ := cmd.Flags().GetBool(verifyFlagName)
+	s := pathFlag(cmd)
+	d := cmd.Flag(destPathFlagName).Value.String()
+	if d == "" {
+		d = s
+	}
+	cmd.SilenceUsage = true
+	ctx := context.Background()
+	opts := location.CopyOptions{
+		Read:        read,
+		Upload:      upload,
+		UploadLimit: limit,
+		Verify:      verify,
+		Progress:    printCopyProgress(cmd.OutOrStderr()),
+	}
+	return locationCopy(ctx, src, dst, s, d, opts)
+}
+
+// printCopyProgress returns a progress function that prints a line per
+// copied artifact
+func printCopyProgress(w io.Writer) func(location.CopyProgress) {
+	return func(p location.CopyProgress) {
+		fmt.Fprintf(w, "Copied %s to %s (%d bytes)\n", p.Source, p.Destination, p.Size)
+	}
+}
+
+func locationCopy(ctx context.Context, src, dst *param.Profile, srcPath, dstPath string, opts location.CopyOptions) error {
+	_, err := location.Copy(ctx, *src, *dst, srcPath, dstPath, opts)
+	return err
+}
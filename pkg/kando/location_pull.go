@@ -5,12 +5,24 @@ import (
 	"io"
 	"os"
 
+	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/kanisterio/kanister/pkg/location"
 	"github.com/kanisterio/kanister/pkg/param"
 )
 
+const (
+	offsetFlagName              = "offset"
+	downloadConcurrencyFlagName = "download-concurrency"
+	chunkSizeFlagName           = "chunk-size"
+	limitDownloadFlagName       = "limit-download"
+	allowUnencryptedFlagName    = "allow-unencrypted"
+
+	defaultDownloadConcurrency = 4
+)
+
 func newLocationPullCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "pull <target>",
@@ -21,12 +33,17 @@ func newLocationPullCommand() *cobra.Command {
 			return runLocationPull(c, args)
 		},
 	}
+	cmd.Flags().Int64(offsetFlagName, 0, "Specify the number of bytes to skip, e.g. to resume an interrupted pull (optional)")
+	cmd.Flags().Int(downloadConcurrencyFlagName, defaultDownloadConcurrency, "Specify the number of ranges downloaded in parallel")
+	cmd.Flags().String(chunkSizeFlagName, "", "Specify the size of the ranges downloaded in parallel, e.g. 32Mi (optional)")
+	cmd.Flags().Int64(limitDownloadFlagName, 0, "Specify the maximum download rate in KiB/s. Defaults to the limit of the profile (optional)")
+	cmd.Flags().Bool(allowUnencryptedFlagName, false, "Read artifacts that are not encrypted although the Profile has encryption keys (optional)")
 	return cmd
 
 }
 
 func runLocationPull(cmd *cobra.Command, args []string) error {
-	target, err := targetWriter(args[0])
+	opts, err := readOptions(cmd)
 	if err != nil {
 		return err
 	}
@@ -36,16 +53,75 @@ func runLocationPull(cmd *cobra.Command, args []string) error {
 	}
 	s := pathFlag(cmd)
 	ctx := context.Background()
-	return locationPull(ctx, p, s, target)
+	return locationPullTarget(ctx, p, s, args[0], opts)
 }
 
-func targetWriter(target string) (io.Writer, error) {
-	if target != usePipeParam {
-		return os.Open(target)
+func readOptions(cmd *cobra.Command) (location.ReadOptions, error) {
+	var opts location.ReadOptions
+	opts.Offset, _ = cmd.Flags().GetInt64(offsetFlagName)
+	if opts.Offset < 0 {
+		return opts, errors.Errorf("invalid offset %d", opts.Offset)
+	}
+	opts.Concurrency, _ = cmd.Flags().GetInt(downloadConcurrencyFlagName)
+	if cs := cmd.Flag(chunkSizeFlagName).Value.String(); cs != "" {
+		q, err := resource.ParseQuantity(cs)
+		if err != nil {
+			return opts, errors.Wrapf(err, "invalid chunk size '%s'", cs)
+		}
+		opts.ChunkSize = q.Value()
+	}
+	opts.DownloadLimit, _ = cmd.Flags().GetInt64(limitDownloadFlagName)
+	if opts.DownloadLimit < 0 {
+		return opts, errors.Errorf("invalid download limit %d", opts.DownloadLimit)
+	}
+	opts.AllowUnencrypted, _ = cmd.Flags().GetBool(allowUnencryptedFlagName)
+	return opts, nil
+}
+
+// locationPullTarget pulls the artifact to the target file or stdout. A file
+// whose data does not match the checksum of the artifact is removed.
+func locationPullTarget(ctx context.Context, p *param.Profile, path, target string, opts location.ReadOptions) error {
+	w, err := targetWriter(target, opts.Offset)
+	if err != nil {
+		return err
+	}
+	err = locationPull(ctx, p, path, w, opts)
+	f, ok := w.(*os.File)
+	if !ok || f == os.Stdout {
+		return err
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if location.IsChecksumMismatchError(err) {
+		// The data does not match what was pushed and must not be used
+		if rerr := os.Remove(target); rerr != nil {
+			return errors.Wrapf(err, "failed to remove %s", target)
+		}
+		return errors.Wrapf(err, "removed %s", target)
+	}
+	return err
+}
+
+// targetWriter returns a writer of the target. Files are written from
+// offset, so that the data of an interrupted pull is kept.
+func targetWriter(target string, offset int64) (io.Writer, error) {
+	if target == usePipeParam {
+		return os.Stdout, nil
+	}
+	f, err := os.OpenFile(target, os.O_WRONLY|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+	if err := f.Truncate(offset); err != nil {
+		return nil, errors.Wrapf(err, "failed to truncate %s", target)
+	}
+	if _, err := f.Seek(offset, io.SeekStart); err != nil {
+		return nil, errors.Wrapf(err, "failed to seek %s", target)
 	}
-	return os.Stdout, nil
+	return f, nil
 }
 
-func locationPull(ctx context.Context, p *param.Profile, path string, target io.Writer) error {
-	return location.Read(ctx, target, *p, path)
+func locationPull(ctx context.Context, p *param.Profile, path string, target io.Writer, opts location.ReadOptions) error {
+	return location.ReadWithOptions(ctx, target, *p, path, opts)
 }
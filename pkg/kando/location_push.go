@@ -7,11 +7,21 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/api/resource"
 
 	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/objectstore"
 	"github.com/kanisterio/kanister/pkg/param"
 )
 
+const (
+	compressionFlagName       = "compression"
+	partSizeFlagName          = "part-size"
+	uploadConcurrencyFlagName = "upload-concurrency"
+	partRetriesFlagName       = "part-retries"
+	limitUploadFlagName       = "limit-upload"
+)
+
 func newLocationPushCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "push <source>",
@@ -22,12 +32,17 @@ func newLocationPushCommand() *cobra.Command {
 			return runLocationPush(c, args)
 		},
 	}
+	cmd.Flags().StringP(compressionFlagName, "c", "", "Specify the codec used to compress the data: gzip, zstd or none (optional)")
+	cmd.Flags().String(partSizeFlagName, "", "Specify the part size of multipart uploads, e.g. 128Mi (optional)")
+	cmd.Flags().Int(uploadConcurrencyFlagName, objectstore.DefaultUploadConcurrency, "Specify the number of parts uploaded in parallel")
+	cmd.Flags().Int(partRetriesFlagName, objectstore.DefaultUploadPartRetries, "Specify the number of times a failed part is retried")
+	cmd.Flags().Int64(limitUploadFlagName, 0, "Specify the maximum upload rate in KiB/s. Defaults to the limit of the profile (optional)")
 	return cmd
 
 }
 
 func runLocationPush(cmd *cobra.Command, args []string) error {
-	source, err := sourceReader(args[0])
+	source, size, err := sourceReader(args[0])
 	if err != nil {
 		return err
 	}
@@ -35,27 +50,71 @@ func runLocationPush(cmd *cobra.Command, args []string) error {
 	if err != nil {
 		return err
 	}
+	codec, err := location.ParseCodec(cmd.Flag(compressionFlagName).Value.String())
+	if err != nil {
+		return err
+	}
+	upload, err := uploadOptions(cmd)
+	if err != nil {
+		return err
+	}
+	limit, err := uploadLimit(cmd)
+	if err != nil {
+		return err
+	}
 	s := pathFlag(cmd)
 	ctx := context.Background()
-	return locationPush(ctx, p, s, source)
+	return locationPush(ctx, p, s, source, location.WriteOptions{Compression: codec, Size: size, Upload: upload, UploadLimit: limit})
+}
+
+func uploadOptions(cmd *cobra.Command) (objectstore.UploadOptions, error) {
+	var opts objectstore.UploadOptions
+	if ps := cmd.Flag(partSizeFlagName).Value.String(); ps != "" {
+		q, err := resource.ParseQuantity(ps)
+		if err != nil {
+			return opts, errors.Wrapf(err, "invalid part size '%s'", ps)
+		}
+		opts.PartSize = q.Value()
+	}
+	opts.Concurrency, _ = cmd.Flags().GetInt(uploadConcurrencyFlagName)
+	opts.PartRetries, _ = cmd.Flags().GetInt(partRetriesFlagName)
+	return opts, nil
+}
+
+func uploadLimit(cmd *cobra.Command) (int64, error) {
+	limit, _ := cmd.Flags().GetInt64(limitUploadFlagName)
+	if limit < 0 {
+		return 0, errors.Errorf("invalid upload limit %d", limit)
+	}
+	return limit, nil
 }
 
 const usePipeParam = `-`
 
-func sourceReader(source string) (io.Reader, error) {
+// sourceReader returns a reader of the source and its size. The size of
+// stdin streams is unknown and returned as 0.
+func sourceReader(source string) (io.Reader, int64, error) {
 	if source != usePipeParam {
-		return os.Open(source)
+		f, err := os.Open(source)
+		if err != nil {
+			return nil, 0, err
+		}
+		fi, err := f.Stat()
+		if err != nil {
+			return nil, 0, errors.Wrapf(err, "Failed to Stat %s", source)
+		}
+		return f, fi.Size(), nil
 	}
 	fi, err := os.Stdin.Stat()
 	if err != nil {
-		errors.Wrap(err, "Failed to Stat stdin")
+		return nil, 0, errors.Wrap(err, "Failed to Stat stdin")
 	}
 	if fi.Mode()&os.ModeNamedPipe == 0 {
-		return nil, errors.New("Stdin must be piped when the source parameter is \"-\"")
+		return nil, 0, errors.New("Stdin must be piped when the source parameter is \"-\"")
 	}
-	return os.Stdin, nil
+	return os.Stdin, 0, nil
 }
 
-func locationPush(ctx context.Context, p *param.Profile, path string, source io.Reader) error {
-	return location.Write(ctx, source, *p, path)
+func locationPush(ctx context.Context, p *param.Profile, path string, source io.Reader, opts location.WriteOptions) error {
+	return location.WriteWithOptions(ctx, source, *p, path, opts)
 }
@@ -3,12 +3,15 @@ package kando
 import (
 	"bytes"
 	"context"
+	"os"
 	"path/filepath"
 
 	. "gopkg.in/check.v1"
 
 	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/location"
 	"github.com/kanisterio/kanister/pkg/objectstore"
+	"github.com/kanisterio/kanister/pkg/param"
 	"github.com/kanisterio/kanister/pkg/testutil"
 )
 
@@ -19,40 +22,102 @@ var _ = Suite(&LocationSuite{})
 const testContent = "test-content"
 
 func (s *LocationSuite) TestLocationObjectStore(c *C) {
-	location := crv1alpha1.Location{
+	loc := crv1alpha1.Location{
 		Type:   crv1alpha1.LocationTypeS3Compliant,
 		Bucket: testutil.GetEnvOrSkip(c, testutil.TestS3BucketName),
 	}
-	p := testutil.ObjectStoreProfileOrSkip(c, objectstore.ProviderTypeS3, location)
+	p := testutil.ObjectStoreProfileOrSkip(c, objectstore.ProviderTypeS3, loc)
 	ctx := context.Background()
 	dir := c.MkDir()
 	path := filepath.Join(dir, "test-object1.txt")
 
 	source := bytes.NewBufferString(testContent)
-	err := locationPush(ctx, p, path, source)
+	err := locationPush(ctx, p, path, source, location.WriteOptions{})
 	c.Assert(err, IsNil)
 
 	target := bytes.NewBuffer(nil)
-	err = locationPull(ctx, p, path, target)
+	err = locationPull(ctx, p, path, target, location.ReadOptions{})
 	c.Assert(err, IsNil)
 	c.Assert(target.String(), Equals, testContent)
 
+	err = locationVerify(ctx, p, path, location.ReadOptions{})
+	c.Assert(err, IsNil)
+
 	// test deleting single artifact
 	err = locationDelete(ctx, p, path)
 	c.Assert(err, IsNil)
 
 	//test deleting dir with multiple artifacts
 	source = bytes.NewBufferString(testContent)
-	err = locationPush(ctx, p, path, source)
+	err = locationPush(ctx, p, path, source, location.WriteOptions{})
 	c.Assert(err, IsNil)
 
 	path = filepath.Join(dir, "test-object2.txt")
 
 	source = bytes.NewBufferString(testContent)
-	err = locationPush(ctx, p, path, source)
+	err = locationPush(ctx, p, path, source, location.WriteOptions{})
 	c.Assert(err, IsNil)
 
 	err = locationDelete(ctx, p, dir)
 	c.Assert(err, IsNil)
 
 }
+
+func (s *LocationSuite) TestLocationCopy(c *C) {
+	ctx := context.Background()
+	defer objectstore.ResetMemoryProvider(c.TestName())
+	profile := func(bucket string) *param.Profile {
+		return &param.Profile{
+			Location: crv1alpha1.Location{
+				Type:     location.TypeMemory,
+				Endpoint: c.TestName(),
+				Bucket:   bucket,
+			},
+		}
+	}
+	src, dst := profile("source"), profile("destination")
+	err := locationPush(ctx, src, "dir/object", bytes.NewBufferString(testContent), location.WriteOptions{})
+	c.Assert(err, IsNil)
+
+	out := bytes.NewBuffer(nil)
+	opts := location.CopyOptions{Verify: true, Progress: printCopyProgress(out)}
+	err = locationCopy(ctx, src, dst, "dir", "copy", opts)
+	c.Assert(err, IsNil)
+	c.Assert(out.String(), Equals, "Copied dir/object to copy/object (12 bytes)\n")
+
+	target := bytes.NewBuffer(nil)
+	err = locationPull(ctx, dst, "copy/object", target, location.ReadOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(target.String(), Equals, testContent)
+}
+
+func (s *LocationSuite) TestLocationPullChecksumMismatch(c *C) {
+	ctx := context.Background()
+	defer objectstore.ResetMemoryProvider(c.TestName())
+	p := &param.Profile{
+		Location: crv1alpha1.Location{
+			Type:     location.TypeMemory,
+			Endpoint: c.TestName(),
+			Bucket:   "bucket",
+		},
+	}
+	err := locationPush(ctx, p, "object", bytes.NewBufferString(testContent), location.WriteOptions{})
+	c.Assert(err, IsNil)
+	target := filepath.Join(c.MkDir(), "object")
+	err = locationPullTarget(ctx, p, "object", target, location.ReadOptions{})
+	c.Assert(err, IsNil)
+
+	// Replace the data but keep the checksum of the original data
+	sum := bytes.NewBuffer(nil)
+	err = locationPull(ctx, p, "object"+location.ChecksumSuffix, sum, location.ReadOptions{})
+	c.Assert(err, IsNil)
+	err = locationPush(ctx, p, "object", bytes.NewBufferString("corrupted"), location.WriteOptions{})
+	c.Assert(err, IsNil)
+	err = locationPush(ctx, p, "object"+location.ChecksumSuffix, sum, location.WriteOptions{})
+	c.Assert(err, IsNil)
+
+	err = locationPullTarget(ctx, p, "object", target, location.ReadOptions{})
+	c.Assert(location.IsChecksumMismatchError(err), Equals, true)
+	_, err = os.Stat(target)
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
@@ -0,0 +1,45 @@
+package kando
+
+import (
+	"context"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanisterio/kanister/pkg/location"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+func newLocationVerifyCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check an artifact in object storage against its checksum without saving it",
+		// TODO: Example invocations
+		RunE: func(c *cobra.Command, args []string) error {
+			return runLocationVerify(c)
+		},
+	}
+	cmd.Flags().Int(downloadConcurrencyFlagName, defaultDownloadConcurrency, "Specify the number of ranges downloaded in parallel")
+	cmd.Flags().String(chunkSizeFlagName, "", "Specify the size of the ranges downloaded in parallel, e.g. 32Mi (optional)")
+	cmd.Flags().Int64(limitDownloadFlagName, 0, "Specify the maximum download rate in KiB/s. Defaults to the limit of the profile (optional)")
+	cmd.Flags().Bool(allowUnencryptedFlagName, false, "Read artifacts that are not encrypted although the Profile has encryption keys (optional)")
+	return cmd
+}
+
+func runLocationVerify(cmd *cobra.Command) error {
+	opts, err := readOptions(cmd)
+	if err != nil {
+		return err
+	}
+	p, err := unmarshalProfileFlag(cmd)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	s := pathFlag(cmd)
+	ctx := context.Background()
+	return locationVerify(ctx, p, s, opts)
+}
+
+func locationVerify(ctx context.Context, p *param.Profile, path string, opts location.ReadOptions) error {
+	return location.Verify(ctx, *p, path, opts)
+}
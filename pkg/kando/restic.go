@@ -0,0 +1,47 @@
+package kando
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+const (
+	artifactPrefixFlagName = "artifact-prefix"
+	passwordFileFlagName   = "password-file"
+)
+
+func newResticCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restic <command>",
+		Short: "Inspect restic repositories in object storage",
+	}
+	cmd.AddCommand(newResticLsCommand())
+	cmd.PersistentFlags().StringP(profileFlagName, "p", "", "Pass a Profile as a JSON string (required)")
+	cmd.MarkPersistentFlagRequired(profileFlagName)
+	cmd.PersistentFlags().StringP(artifactPrefixFlagName, "a", "", "Specify the path of the repository on the object store (required)")
+	cmd.MarkPersistentFlagRequired(artifactPrefixFlagName)
+	cmd.PersistentFlags().String(passwordFileFlagName, "", "Specify a file with the password of the repository. Defaults to the "+restic.ResticPassword+" environment variable (optional)")
+	return cmd
+}
+
+// repositoryPassword reads the password from the file named by the flag or
+// from the environment, so that it is not visible on the command line
+func repositoryPassword(cmd *cobra.Command) (string, error) {
+	if f := cmd.Flag(passwordFileFlagName).Value.String(); f != "" {
+		pw, err := ioutil.ReadFile(f)
+		if err != nil {
+			return "", errors.Wrap(err, "failed to read repository password")
+		}
+		return strings.TrimSpace(string(pw)), nil
+	}
+	if pw := os.Getenv(restic.ResticPassword); pw != "" {
+		return pw, nil
+	}
+	return "", errors.Errorf("repository password not specified, set --%s or %s", passwordFileFlagName, restic.ResticPassword)
+}
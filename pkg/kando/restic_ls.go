@@ -0,0 +1,99 @@
+package kando
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+const (
+	backupIDFlagName  = "backup-id"
+	backupTagFlagName = "backup-tag"
+	jsonFlagName      = "json"
+	maxNodesFlagName  = "max-nodes"
+	// defaultMaxNodes bounds the memory taken to list a large backup
+	defaultMaxNodes = 100000
+)
+
+func newResticLsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "ls [path...]",
+		Short: "List the files in a backup, or only the files under the paths",
+		// TODO: Example invocations
+		RunE: func(c *cobra.Command, args []string) error {
+			return runResticLs(c, args)
+		},
+	}
+	cmd.Flags().String(backupIDFlagName, "", "Specify the ID of the backup")
+	cmd.Flags().String(backupTagFlagName, "", "Specify the tag of the backup. The latest backup with the tag is listed")
+	cmd.Flags().Bool(jsonFlagName, false, "Print the listing as JSON")
+	cmd.Flags().Int(maxNodesFlagName, defaultMaxNodes, "Specify the maximum number of files to list, or 0 to list all of them")
+	return cmd
+}
+
+func runResticLs(cmd *cobra.Command, args []string) error {
+	id := cmd.Flag(backupIDFlagName).Value.String()
+	tag := cmd.Flag(backupTagFlagName).Value.String()
+	if (id == "") == (tag == "") {
+		return errors.Errorf("require one of --%s or --%s", backupIDFlagName, backupTagFlagName)
+	}
+	maxNodes, _ := cmd.Flags().GetInt(maxNodesFlagName)
+	if maxNodes < 0 {
+		return errors.Errorf("--%s must not be negative", maxNodesFlagName)
+	}
+	p, err := profileFlag(cmd)
+	if err != nil {
+		return err
+	}
+	password, err := repositoryPassword(cmd)
+	if err != nil {
+		return err
+	}
+	cmd.SilenceUsage = true
+	repository := cmd.Flag(artifactPrefixFlagName).Value.String()
+	var c []string
+	if id != "" {
+		c = restic.LsCommandByID(p, repository, id, args)
+	} else {
+		c = restic.LsCommandByTag(p, repository, tag, args)
+	}
+	ctx := context.Background()
+	lw := restic.NewLsWriter(maxNodes)
+	if _, err := restic.ExecLocalStream(ctx, c, p, password, lw); err != nil {
+		return err
+	}
+	listing, err := lw.Listing()
+	if err != nil {
+		return err
+	}
+	asJSON, _ := cmd.Flags().GetBool(jsonFlagName)
+	return printListing(os.Stdout, listing, asJSON)
+}
+
+// printListing prints the listing as JSON or as a table for interactive use
+func printListing(w io.Writer, l *restic.Listing, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(w).Encode(l)
+	}
+	fmt.Fprintf(w, "snapshot %s of %v at %s\n", l.SnapshotID, l.Paths, l.Time.Format(time.RFC3339))
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, n := range l.Nodes {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", n.Mode, n.Size, n.ModTime.Format(time.RFC3339), n.Path)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if l.Truncated {
+		fmt.Fprintf(w, "listing truncated after %d files, use --%s to list more\n", len(l.Nodes), maxNodesFlagName)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package kando
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/kanisterio/kanister/pkg/restic"
+)
+
+type ResticSuite struct{}
+
+var _ = Suite(&ResticSuite{})
+
+func (s *ResticSuite) TestPrintListing(c *C) {
+	l := &restic.Listing{
+		SnapshotID: "1a2b3c4d",
+		Time:       time.Date(2019, 6, 4, 10, 0, 0, 0, time.UTC),
+		Paths:      []string{"/mnt/data"},
+		Nodes: []restic.Node{
+			{Name: "data", Type: "dir", Path: "/mnt/data", Mode: os.ModeDir | 0755, ModTime: time.Date(2019, 6, 4, 9, 0, 0, 0, time.UTC)},
+			{Name: "a.txt", Type: "file", Path: "/mnt/data/a.txt", Size: 42, Mode: 0644, ModTime: time.Date(2019, 6, 4, 9, 30, 0, 0, time.UTC)},
+		},
+	}
+	b := &bytes.Buffer{}
+	err := printListing(b, l, false)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Split(b.String(), "\n"), DeepEquals, []string{
+		"snapshot 1a2b3c4d of [/mnt/data] at 2019-06-04T10:00:00Z",
+		"drwxr-xr-x  0   2019-06-04T09:00:00Z  /mnt/data",
+		"-rw-r--r--  42  2019-06-04T09:30:00Z  /mnt/data/a.txt",
+		"",
+	})
+
+	b.Reset()
+	err = printListing(b, l, true)
+	c.Assert(err, IsNil)
+	var out restic.Listing
+	err = json.Unmarshal(b.Bytes(), &out)
+	c.Assert(err, IsNil)
+	c.Assert(out.SnapshotID, Equals, l.SnapshotID)
+	c.Assert(out.Nodes, HasLen, 2)
+	c.Assert(out.Nodes[1].Path, Equals, "/mnt/data/a.txt")
+
+	b.Reset()
+	l.Truncated = true
+	err = printListing(b, l, false)
+	c.Assert(err, IsNil)
+	c.Assert(b.String(), Matches, "(?s).*listing truncated after 2 files, use --max-nodes to list more\n")
+}
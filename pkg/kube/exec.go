@@ -24,6 +24,9 @@ type ExecOptions struct {
 	Stdin         io.Reader
 	CaptureStdout bool
 	CaptureStderr bool
+	// Stdout, if set, receives the output of the command as it is produced.
+	// The returned stdout is empty in that case.
+	Stdout io.Writer
 }
 
 // Exec is our version of the call to `kubectl exec` that does not depend on
@@ -59,7 +62,7 @@ func ExecWithOptions(kubeCli kubernetes.Interface, options ExecOptions) (string,
 		Container: options.ContainerName,
 		Command:   options.Command,
 		Stdin:     options.Stdin != nil,
-		Stdout:    options.CaptureStdout,
+		Stdout:    options.CaptureStdout || options.Stdout != nil,
 		Stderr:    options.CaptureStderr,
 		TTY:       tty,
 	}, scheme.ParameterCodec)
@@ -70,7 +73,11 @@ func ExecWithOptions(kubeCli kubernetes.Interface, options ExecOptions) (string,
 	}
 
 	var stdout, stderr bytes.Buffer
-	err = execute("POST", req.URL(), config, options.Stdin, &stdout, &stderr, tty)
+	var out io.Writer = &stdout
+	if options.Stdout != nil {
+		out = options.Stdout
+	}
+	err = execute("POST", req.URL(), config, options.Stdin, out, &stderr, tty)
 	return strings.TrimSpace(stdout.String()), strings.TrimSpace(stderr.String()), err
 }
 
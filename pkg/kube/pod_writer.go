@@ -27,9 +27,10 @@ func NewPodWriter(cli kubernetes.Interface, path string, content io.Reader) *Pod
 	}
 }
 
-// Write will create a new file(if not present) and write the provided content to the file
+// Write will create a new file(if not present) and write the provided content to the file.
+// The file is only readable by its owner since it usually holds credentials.
 func (p *PodWriter) Write(ctx context.Context, namespace, podName, containerName string) error {
-	cmd := []string{"sh", "-c", "cat - > " + p.path}
+	cmd := []string{"sh", "-c", "umask 077 && cat - > " + p.path}
 	stdout, stderr, err := Exec(p.cli, namespace, podName, containerName, cmd, p.content)
 	format.Log(podName, containerName, stdout)
 	format.Log(podName, containerName, stderr)
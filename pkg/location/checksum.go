@@ -0,0 +1,104 @@
+package location
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"io"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+
+	"github.com/kanisterio/kanister/pkg/objectstore"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+// ChecksumSuffix is appended to the name of an artifact to name the object
+// holding the hex encoded SHA-256 checksum of its data. The checksum covers
+// the data as stored, after it is compressed and encrypted, so that it
+// reveals nothing about the data of encrypted artifacts.
+const ChecksumSuffix = ".sha256"
+
+func newChecksum() hash.Hash {
+	return sha256.New()
+}
+
+func writeChecksum(ctx context.Context, dir objectstore.Directory, path string, h hash.Hash) error {
+	sum := hex.EncodeToString(h.Sum(nil))
+	if err := dir.PutBytes(ctx, path+ChecksumSuffix, []byte(sum), nil); err != nil {
+		return errors.Wrapf(err, "failed to write checksum of %s", path)
+	}
+	return nil
+}
+
+// readChecksum returns the checksum stored for the artifact. It returns false
+// if the artifact was written without a checksum.
+func readChecksum(ctx context.Context, dir objectstore.Directory, path string) (string, bool, error) {
+	data, _, err := dir.GetBytes(ctx, path+ChecksumSuffix)
+	switch {
+	case objectstore.IsObjectNotFoundError(err):
+		return "", false, nil
+	case err != nil:
+		return "", false, errors.Wrapf(err, "failed to read checksum of %s", path)
+	}
+	return string(bytes.TrimSpace(data)), true, nil
+}
+
+type checksumMismatchError struct {
+	path, want, got string
+}
+
+func (e *checksumMismatchError) Error() string {
+	return "checksum mismatch for " + e.path + ": expected " + e.want + ", got " + e.got
+}
+
+// IsChecksumMismatchError returns true if the error is returned by Read or
+// Verify for an artifact whose data does not match its stored checksum
+func IsChecksumMismatchError(err error) bool {
+	_, ok := errors.Cause(err).(*checksumMismatchError)
+	return ok
+}
+
+func verifyChecksum(path, want string, h hash.Hash) error {
+	if got := hex.EncodeToString(h.Sum(nil)); got != want {
+		return &checksumMismatchError{path: path, want: want, got: got}
+	}
+	return nil
+}
+
+// Verify reads the artifact at the location specified by `profile` and
+// `suffix` and checks it against its stored checksum without keeping the
+// data. The data is not decrypted. It fails if the artifact has no checksum.
+func Verify(ctx context.Context, profile param.Profile, suffix string, opts ReadOptions) error {
+	osType, err := getProviderType(profile.Location.Type)
+	if err != nil {
+		return err
+	}
+	bucket, err := getBucket(ctx, osType, profile)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(
+		profile.Location.Prefix,
+		suffix,
+	)
+	want, ok, err := readChecksum(ctx, bucket, path)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return errors.Errorf("no checksum stored for %s", path)
+	}
+	rc, _, err := openArtifact(ctx, bucket, path, 0, opts)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := newChecksum()
+	if _, err := io.Copy(h, throttleReader(ctx, rc, downloadLimit(profile, opts))); err != nil {
+		return err
+	}
+	return verifyChecksum(path, want, h)
+}
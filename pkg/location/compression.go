@@ -0,0 +1,85 @@
+package location
+
+import (
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+)
+
+// Codec is the compression format of an artifact
+type Codec string
+
+const (
+	CodecNone Codec = ""
+	CodecGzip Codec = "gzip"
+	CodecZstd Codec = "zstd"
+)
+
+// CompressionTag is the object tag that records the codec of compressed
+// artifacts. Azure only allows identifiers as metadata names.
+const CompressionTag = "kanister_compression"
+
+// ParseCodec returns the codec with the given name. "none" and "" disable
+// compression.
+func ParseCodec(name string) (Codec, error) {
+	switch c := Codec(name); c {
+	case CodecNone, CodecGzip, CodecZstd:
+		return c, nil
+	case "none":
+		return CodecNone, nil
+	default:
+		return "", errors.Errorf("unsupported compression codec '%s'", name)
+	}
+}
+
+// compressReader returns a reader of the contents of in compressed with
+// codec. Closing the reader stops the compression.
+func compressReader(in io.Reader, codec Codec) (io.ReadCloser, error) {
+	var newWriter func(io.Writer) (io.WriteCloser, error)
+	switch codec {
+	case CodecNone:
+		return ioutil.NopCloser(in), nil
+	case CodecGzip:
+		newWriter = func(w io.Writer) (io.WriteCloser, error) { return gzip.NewWriter(w), nil }
+	case CodecZstd:
+		newWriter = func(w io.Writer) (io.WriteCloser, error) { return zstd.NewWriter(w) }
+	default:
+		return nil, errors.Errorf("unsupported compression codec '%s'", codec)
+	}
+	pr, pw := io.Pipe()
+	cw, err := newWriter(pw)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to create %s compressor", codec)
+	}
+	go func() {
+		_, err := io.Copy(cw, in)
+		if cErr := cw.Close(); err == nil {
+			err = cErr
+		}
+		pw.CloseWithError(err)
+	}()
+	return pr, nil
+}
+
+// decompressReader returns a reader of the contents of in decompressed with
+// codec
+func decompressReader(in io.Reader, codec Codec) (io.ReadCloser, error) {
+	switch codec {
+	case CodecNone:
+		return ioutil.NopCloser(in), nil
+	case CodecGzip:
+		r, err := gzip.NewReader(in)
+		return r, errors.Wrap(err, "failed to read gzip artifact")
+	case CodecZstd:
+		d, err := zstd.NewReader(in)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read zstd artifact")
+		}
+		return d.IOReadCloser(), nil
+	default:
+		return nil, errors.Errorf("unsupported compression codec '%s'", codec)
+	}
+}
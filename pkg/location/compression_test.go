@@ -0,0 +1,52 @@
+package location
+
+import (
+	"bytes"
+	"io/ioutil"
+	"strings"
+
+	. "gopkg.in/check.v1"
+)
+
+type CompressionSuite struct{}
+
+var _ = Suite(&CompressionSuite{})
+
+func (s *CompressionSuite) TestRoundTrip(c *C) {
+	data := []byte(strings.Repeat("compressible ", 1000))
+	for _, codec := range []Codec{CodecNone, CodecGzip, CodecZstd} {
+		cr, err := compressReader(bytes.NewReader(data), codec)
+		c.Assert(err, IsNil)
+		compressed, err := ioutil.ReadAll(cr)
+		c.Assert(err, IsNil)
+		c.Assert(cr.Close(), IsNil)
+		if codec != CodecNone {
+			c.Assert(len(compressed) < len(data), Equals, true)
+		}
+
+		dr, err := decompressReader(bytes.NewReader(compressed), codec)
+		c.Assert(err, IsNil)
+		out, err := ioutil.ReadAll(dr)
+		c.Assert(err, IsNil)
+		c.Assert(dr.Close(), IsNil)
+		c.Assert(out, DeepEquals, data, Commentf("codec %s", codec))
+	}
+}
+
+func (s *CompressionSuite) TestParseCodec(c *C) {
+	for _, tc := range []struct {
+		name    string
+		codec   Codec
+		checker Checker
+	}{
+		{"", CodecNone, IsNil},
+		{"none", CodecNone, IsNil},
+		{"gzip", CodecGzip, IsNil},
+		{"zstd", CodecZstd, IsNil},
+		{"lz4", "", NotNil},
+	} {
+		codec, err := ParseCodec(tc.name)
+		c.Check(err, tc.checker)
+		c.Check(codec, Equals, tc.codec)
+	}
+}
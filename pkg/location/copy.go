@@ -0,0 +1,234 @@
+package location
+
+import (
+	"context"
+	"encoding/hex"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/kanisterio/kanister/pkg/objectstore"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+// CopyOptions are optional settings for copying artifacts between locations
+type CopyOptions struct {
+	// Read configures the downloads from the source
+	Read ReadOptions
+	// Upload configures the uploads to the destination
+	Upload objectstore.UploadOptions
+	// UploadLimit is the maximum upload rate to the destination in KiB/s.
+	// The limit of the destination profile is used if it is 0.
+	UploadLimit int64
+	// Verify reads each copied artifact back from the destination and
+	// checks it against its checksum
+	Verify bool
+	// Progress is called after each artifact is copied
+	Progress func(CopyProgress)
+}
+
+// CopyProgress describes an artifact that has been copied
+type CopyProgress struct {
+	// Source and Destination are the paths of the artifact relative to
+	// the location prefixes
+	Source      string
+	Destination string
+	// Size of the data of the artifact, or of the stored data of artifacts
+	// copied under a path
+	Size int64
+	// Copied is the number of artifacts copied so far
+	Copied int
+}
+
+// CopyResult summarizes a copy
+type CopyResult struct {
+	Artifacts int
+	Size      int64
+}
+
+// Copy copies the artifact or all the artifacts under the path `srcSuffix`
+// of the location of `src` to `dstSuffix` in the location of `dst`. The data
+// of a single artifact is decrypted and decompressed with the settings of
+// `src`, checked against its checksum and written with the encryption keys of
+// `dst`. It is compressed with the codec it was stored with. The objects under
+// a path are copied as stored, together with their tags and checksums, since
+// they may not have been written as artifacts, e.g. those of a restic
+// repository. They keep the encryption of `src`.
+func Copy(ctx context.Context, src, dst param.Profile, srcSuffix, dstSuffix string, opts CopyOptions) (CopyResult, error) {
+	srcType, err := getProviderType(src.Location.Type)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	dstType, err := getProviderType(dst.Location.Type)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	srcBucket, err := getBucket(ctx, srcType, src)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	dstBucket, err := getBucket(ctx, dstType, dst)
+	if err != nil {
+		return CopyResult{}, err
+	}
+	// Artifacts are copied whole and from their latest version
+	opts.Read.Offset = 0
+	opts.Read.VersionID = ""
+	c := &copier{
+		src:       src,
+		dst:       dst,
+		srcType:   srcType,
+		dstType:   dstType,
+		srcBucket: srcBucket,
+		dstBucket: dstBucket,
+		opts:      opts,
+	}
+	// A path names either a single artifact or a prefix of artifacts
+	copied, err := c.copyArtifact(ctx, srcSuffix, dstSuffix)
+	switch {
+	case err != nil:
+		return c.res, err
+	case copied:
+		return c.res, nil
+	}
+	srcPath := filepath.Join(src.Location.Prefix, srcSuffix)
+	dir, err := objectstore.PrefixDirectory(ctx, srcBucket, srcPath)
+	if err != nil {
+		return c.res, errors.Wrapf(err, "failed to find artifacts under %s", srcPath)
+	}
+	it := objectstore.NewObjectIterator(dir, objectstore.ListOptions{Recursive: true})
+	for it.Next(ctx) {
+		name := it.Object().Name
+		// Checksums are copied along, but are not artifacts themselves
+		report := !strings.HasSuffix(name, ChecksumSuffix)
+		if err := c.copyObject(ctx, filepath.Join(srcSuffix, name), filepath.Join(dstSuffix, name), report); err != nil {
+			return c.res, err
+		}
+	}
+	if err := it.Err(); err != nil {
+		return c.res, errors.Wrapf(err, "failed to list artifacts under %s", srcPath)
+	}
+	if c.res.Artifacts == 0 {
+		return c.res, errors.Errorf("no artifacts found at %s", srcPath)
+	}
+	return c.res, nil
+}
+
+type copier struct {
+	src, dst         param.Profile
+	srcType, dstType objectstore.ProviderType
+	srcBucket        objectstore.Bucket
+	dstBucket        objectstore.Bucket
+	opts             CopyOptions
+	res              CopyResult
+}
+
+// copyArtifact copies a single artifact. It returns false if the artifact
+// does not exist.
+func (c *copier) copyArtifact(ctx context.Context, srcSuffix, dstSuffix string) (bool, error) {
+	srcPath := filepath.Join(c.src.Location.Prefix, srcSuffix)
+	dstPath := filepath.Join(c.dst.Location.Prefix, dstSuffix)
+	// The first byte is read to get the tags and to check that the
+	// artifact exists
+	rc, tags, err := c.srcBucket.GetRange(ctx, srcPath, 0, 1)
+	switch {
+	case objectstore.IsObjectNotFoundError(err):
+		return false, nil
+	case err != nil:
+		return false, errors.Wrapf(err, "failed to get artifact %s", srcPath)
+	}
+	rc.Close()
+
+	pr, pw := io.Pipe()
+	cw := &countingWriter{w: pw}
+	go func() {
+		// Read errors, including checksum mismatches, fail the upload
+		pw.CloseWithError(readData(ctx, c.srcType, c.src, cw, srcPath, c.opts.Read))
+	}()
+	wo := WriteOptions{
+		Compression: Codec(tags[CompressionTag]),
+		Upload:      c.opts.Upload,
+		UploadLimit: c.opts.UploadLimit,
+	}
+	_, err = writeData(ctx, c.dstType, c.dst, pr, dstPath, wo)
+	pr.CloseWithError(err)
+	if err != nil {
+		return true, errors.Wrapf(err, "failed to copy artifact %s to %s", srcPath, dstPath)
+	}
+	if c.opts.Verify {
+		if err := readData(ctx, c.dstType, c.dst, ioutil.Discard, dstPath, ReadOptions{}); err != nil {
+			return true, errors.Wrapf(err, "failed to verify copy %s of artifact %s", dstPath, srcPath)
+		}
+	}
+	c.copied(srcSuffix, dstSuffix, cw.n)
+	return true, nil
+}
+
+// copyObject copies an object as stored, without the codecs and checksums of
+// artifacts. If report is set, it is counted and reported as an artifact.
+func (c *copier) copyObject(ctx context.Context, srcSuffix, dstSuffix string, report bool) error {
+	srcPath := filepath.Join(c.src.Location.Prefix, srcSuffix)
+	dstPath := filepath.Join(c.dst.Location.Prefix, dstSuffix)
+	rc, tags, err := openArtifact(ctx, c.srcBucket, srcPath, 0, c.opts.Read)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get object %s", srcPath)
+	}
+	defer rc.Close()
+	h := newChecksum()
+	cw := &countingWriter{w: h}
+	in := io.TeeReader(throttleReader(ctx, rc, downloadLimit(c.src, c.opts.Read)), cw)
+	in = throttleReader(ctx, in, uploadLimit(c.dst, WriteOptions{UploadLimit: c.opts.UploadLimit}))
+	if _, err := objectstore.PutMultipart(ctx, c.dstBucket, dstPath, in, 0, tags, c.opts.Upload); err != nil {
+		return errors.Wrapf(err, "failed to copy object %s to %s", srcPath, dstPath)
+	}
+	if c.opts.Verify {
+		if err := verifyObject(ctx, c.dstBucket, dstPath, hex.EncodeToString(h.Sum(nil))); err != nil {
+			return errors.Wrapf(err, "failed to verify copy %s of object %s", dstPath, srcPath)
+		}
+	}
+	if report {
+		c.copied(srcSuffix, dstSuffix, cw.n)
+	}
+	return nil
+}
+
+// verifyObject checks that the stored data of the object has the checksum
+func verifyObject(ctx context.Context, dir objectstore.Directory, path, want string) error {
+	rc, _, err := dir.Get(ctx, path)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := newChecksum()
+	if _, err := io.Copy(h, rc); err != nil {
+		return err
+	}
+	return verifyChecksum(path, want, h)
+}
+
+func (c *copier) copied(srcSuffix, dstSuffix string, size int64) {
+	c.res.Artifacts++
+	c.res.Size += size
+	if c.opts.Progress != nil {
+		c.opts.Progress(CopyProgress{
+			Source:      srcSuffix,
+			Destination: dstSuffix,
+			Size:        size,
+			Copied:      c.res.Artifacts,
+		})
+	}
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.w.Write(p)
+	w.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,191 @@
+package location
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"time"
+
+	"github.com/jpillora/backoff"
+	"github.com/pkg/errors"
+
+	"github.com/kanisterio/kanister/pkg/objectstore"
+	"github.com/kanisterio/kanister/pkg/poll"
+)
+
+const (
+	// DefaultReadChunkSize is the size of the ranges read by parallel
+	// downloads
+	DefaultReadChunkSize = 16 << 20
+	readChunkRetries     = 3
+)
+
+// ReadOptions are optional settings for reading data from a location
+type ReadOptions struct {
+	// Offset is the number of bytes of data to skip. Downloads of
+	// artifacts that are neither compressed nor encrypted start at the
+	// offset. Other artifacts are read from the start and the data before
+	// the offset is discarded.
+	Offset int64
+	// Concurrency is the number of ranges downloaded in parallel. The
+	// artifact is read in a single stream if it is 0 or 1.
+	Concurrency int
+	// ChunkSize is the size of the ranges of parallel downloads
+	ChunkSize int64
+	// VersionID selects a version of the artifact returned by
+	// WriteVersion. Versions are read in a single stream and Concurrency
+	// is ignored.
+	VersionID string
+	// DownloadLimit is the maximum download rate in KiB/s. The limit of
+	// the profile is used if it is 0.
+	DownloadLimit int64
+	// AllowUnencrypted reads artifacts that are not encrypted even if the
+	// profile has encryption keys, e.g. artifacts written before the keys
+	// were added. Such artifacts are rejected by default.
+	AllowUnencrypted bool
+}
+
+// openArtifact returns a reader of the stored artifact from offset and the
+// artifact tags
+func openArtifact(ctx context.Context, dir objectstore.Directory, name string, offset int64, opts ReadOptions) (io.ReadCloser, map[string]string, error) {
+	if opts.Concurrency <= 1 {
+		if offset == 0 {
+			return dir.Get(ctx, name)
+		}
+		return dir.GetRange(ctx, name, offset, -1)
+	}
+	if opts.ChunkSize <= 0 {
+		opts.ChunkSize = DefaultReadChunkSize
+	}
+	return newParallelReader(ctx, dir, name, offset, opts)
+}
+
+// isRawArtifact returns true if the data of the artifact is stored as is,
+// so that it can be read from an offset
+func isRawArtifact(ctx context.Context, dir objectstore.Directory, name string) (bool, error) {
+	rc, tags, err := dir.GetRange(ctx, name, 0, int64(len(encryptionMagic)))
+	if err != nil {
+		return false, err
+	}
+	defer rc.Close()
+	head, err := ioutil.ReadAll(rc)
+	if err != nil {
+		return false, err
+	}
+	return Codec(tags[CompressionTag]) == CodecNone && !bytes.Equal(head, encryptionMagic), nil
+}
+
+type chunkResult struct {
+	data []byte
+	err  error
+}
+
+// parallelReader reads an artifact in chunks with up to Concurrency ranged
+// reads in flight and returns the data in order. The size of the artifact
+// is not known up front. The download ends with the first short chunk.
+type parallelReader struct {
+	cancel    context.CancelFunc
+	chunkSize int64
+	results   chan chan chunkResult
+	cur       []byte
+	done      bool
+	err       error
+}
+
+func newParallelReader(ctx context.Context, dir objectstore.Directory, name string, offset int64, opts ReadOptions) (io.ReadCloser, map[string]string, error) {
+	// The first chunk is read before the others to get the tags
+	first, tags, err := getChunk(ctx, dir, name, offset, opts.ChunkSize)
+	if err != nil {
+		return nil, nil, err
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	r := &parallelReader{
+		cancel:    cancel,
+		chunkSize: opts.ChunkSize,
+		results:   make(chan chan chunkResult, opts.Concurrency-1),
+		cur:       first,
+		done:      int64(len(first)) < opts.ChunkSize,
+	}
+	if r.done {
+		cancel()
+		return r, tags, nil
+	}
+	go func() {
+		defer close(r.results)
+		for off := offset + opts.ChunkSize; ; off += opts.ChunkSize {
+			ch := make(chan chunkResult, 1)
+			select {
+			case r.results <- ch:
+			case <-ctx.Done():
+				return
+			}
+			go func(off int64) {
+				data, _, err := getChunk(ctx, dir, name, off, opts.ChunkSize)
+				ch <- chunkResult{data: data, err: err}
+			}(off)
+		}
+	}()
+	return r, tags, nil
+}
+
+func (r *parallelReader) Read(p []byte) (int, error) {
+	for len(r.cur) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		if r.done {
+			return 0, io.EOF
+		}
+		ch, ok := <-r.results
+		if !ok {
+			r.err = errors.New("download canceled")
+			continue
+		}
+		res := <-ch
+		if res.err != nil {
+			r.err = res.err
+			r.cancel()
+			continue
+		}
+		r.cur = res.data
+		if int64(len(res.data)) < r.chunkSize {
+			r.done = true
+			r.cancel()
+		}
+	}
+	n := copy(p, r.cur)
+	r.cur = r.cur[n:]
+	return n, nil
+}
+
+func (r *parallelReader) Close() error {
+	r.cancel()
+	return nil
+}
+
+// getChunk reads up to size bytes of the artifact from offset. Failed reads
+// are retried.
+func getChunk(ctx context.Context, dir objectstore.Directory, name string, offset, size int64) ([]byte, map[string]string, error) {
+	data := make([]byte, size)
+	var n int
+	var tags map[string]string
+	b := backoff.Backoff{Min: 100 * time.Millisecond, Max: 10 * time.Second, Factor: 2, Jitter: true}
+	err := poll.WaitWithBackoffWithRetries(ctx, b, readChunkRetries, poll.IsAlwaysRetryable, func(ctx context.Context) (bool, error) {
+		rc, t, err := dir.GetRange(ctx, name, offset, size)
+		if err != nil {
+			return false, err
+		}
+		defer rc.Close()
+		n, err = io.ReadFull(rc, data)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return false, err
+		}
+		tags = t
+		return true, nil
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to read range %d-%d of %s", offset, offset+size-1, name)
+	}
+	return data[:n], tags, nil
+}
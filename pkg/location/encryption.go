@@ -0,0 +1,263 @@
+package location
+
+// Client-side envelope encryption of artifacts.
+//
+// Every artifact is encrypted with a random 256-bit data key. The data key is
+// wrapped with AES-GCM by a key encryption key from the Profile and stored in
+// the artifact header:
+//
+//   magic | key ID length (1 byte) | key ID | wrap nonce | wrapped data key | chunk size (4 bytes)
+//
+// The data follows as AES-GCM sealed chunks of chunk size bytes of plaintext.
+// The nonce of every chunk holds its sequence number and a flag marking the
+// final chunk, so reordered or truncated artifacts fail to decrypt. The
+// header is the additional data of every chunk.
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+const (
+	encryptionKeySize       = 32
+	encryptionChunkSize     = 64 << 10
+	encryptionMaxChunkSize  = 16 << 20
+	encryptionNonceSize     = 12
+	encryptionFinalChunkTag = 1
+)
+
+var encryptionMagic = []byte("KANENC\x00\x01")
+
+// isEncrypted returns true if the artifact read by r is encrypted. It does
+// not consume any data.
+func isEncrypted(r *bufio.Reader) bool {
+	b, err := r.Peek(len(encryptionMagic))
+	return err == nil && bytes.Equal(b, encryptionMagic)
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	if len(key) != encryptionKeySize {
+		return nil, errors.Errorf("encryption key must be %d bytes, got %d", encryptionKeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// encryptionKey returns the key encryption key that wraps new data keys
+func encryptionKey(enc *param.Encryption) (string, []byte, error) {
+	key, ok := enc.Keys[enc.KeyID]
+	if !ok {
+		return "", nil, errors.Errorf("encryption key '%s' not found", enc.KeyID)
+	}
+	if len(enc.KeyID) > 255 {
+		return "", nil, errors.Errorf("encryption key ID '%s' is too long", enc.KeyID)
+	}
+	return enc.KeyID, key, nil
+}
+
+// encryptReader returns a reader of the encrypted contents of in
+func encryptReader(in io.Reader, enc *param.Encryption) (io.Reader, error) {
+	keyID, kek, err := encryptionKey(enc)
+	if err != nil {
+		return nil, err
+	}
+	wrapper, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	dek := make([]byte, encryptionKeySize)
+	if _, err := rand.Read(dek); err != nil {
+		return nil, errors.Wrap(err, "failed to generate data key")
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	wrapNonce := make([]byte, encryptionNonceSize)
+	if _, err := rand.Read(wrapNonce); err != nil {
+		return nil, errors.Wrap(err, "failed to generate nonce")
+	}
+	header := bytes.NewBuffer(nil)
+	header.Write(encryptionMagic)
+	header.WriteByte(byte(len(keyID)))
+	header.WriteString(keyID)
+	header.Write(wrapNonce)
+	header.Write(wrapper.Seal(nil, wrapNonce, dek, header.Bytes()[:len(encryptionMagic)+1+len(keyID)]))
+	binary.Write(header, binary.BigEndian, uint32(encryptionChunkSize))
+	return &encryptingReader{
+		in:     bufio.NewReader(in),
+		aead:   aead,
+		header: header.Bytes(),
+		out:    header.Bytes(),
+		plain:  make([]byte, encryptionChunkSize),
+		sealed: make([]byte, 0, encryptionChunkSize+aead.Overhead()),
+	}, nil
+}
+
+type encryptingReader struct {
+	in     *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	seq    uint64
+	done   bool
+	out    []byte // sealed data not yet read
+	plain  []byte
+	sealed []byte
+}
+
+func (r *encryptingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.sealChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *encryptingReader) sealChunk() error {
+	n, err := io.ReadFull(r.in, r.plain)
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		r.done = true
+	case err != nil:
+		return err
+	default:
+		// A full chunk is the final one if nothing follows it
+		if _, err := r.in.Peek(1); err == io.EOF {
+			r.done = true
+		} else if err != nil {
+			return err
+		}
+	}
+	r.out = r.aead.Seal(r.sealed[:0], chunkNonce(r.seq, r.done), r.plain[:n], r.header)
+	r.seq++
+	return nil
+}
+
+func chunkNonce(seq uint64, final bool) []byte {
+	nonce := make([]byte, encryptionNonceSize)
+	binary.BigEndian.PutUint64(nonce, seq)
+	if final {
+		nonce[encryptionNonceSize-1] = encryptionFinalChunkTag
+	}
+	return nonce
+}
+
+// decryptReader returns a reader of the plaintext of the encrypted artifact
+// read by in
+func decryptReader(in *bufio.Reader, enc *param.Encryption) (io.Reader, error) {
+	if enc == nil || len(enc.Keys) == 0 {
+		return nil, errors.New("artifact is encrypted but no encryption keys were provided")
+	}
+	header := bytes.NewBuffer(nil)
+	prefix := make([]byte, len(encryptionMagic)+1)
+	if _, err := io.ReadFull(in, prefix); err != nil {
+		return nil, errors.Wrap(err, "failed to read encryption header")
+	}
+	header.Write(prefix)
+	keyID := make([]byte, prefix[len(encryptionMagic)])
+	if _, err := io.ReadFull(in, keyID); err != nil {
+		return nil, errors.Wrap(err, "failed to read encryption header")
+	}
+	header.Write(keyID)
+	kek, ok := enc.Keys[string(keyID)]
+	if !ok {
+		return nil, errors.Errorf("encryption key '%s' of the artifact not found", keyID)
+	}
+	wrapper, err := newGCM(kek)
+	if err != nil {
+		return nil, err
+	}
+	wrapped := make([]byte, encryptionNonceSize+encryptionKeySize+wrapper.Overhead()+4)
+	if _, err := io.ReadFull(in, wrapped); err != nil {
+		return nil, errors.Wrap(err, "failed to read encryption header")
+	}
+	wrapNonce := wrapped[:encryptionNonceSize]
+	dek, err := wrapper.Open(nil, wrapNonce, wrapped[encryptionNonceSize:len(wrapped)-4], header.Bytes())
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to unwrap data key with encryption key '%s'", keyID)
+	}
+	header.Write(wrapped)
+	chunkSize := binary.BigEndian.Uint32(wrapped[len(wrapped)-4:])
+	if chunkSize == 0 || chunkSize > encryptionMaxChunkSize {
+		return nil, errors.Errorf("invalid encryption chunk size %d", chunkSize)
+	}
+	aead, err := newGCM(dek)
+	if err != nil {
+		return nil, err
+	}
+	return &decryptingReader{
+		in:     in,
+		aead:   aead,
+		header: header.Bytes(),
+		sealed: make([]byte, int(chunkSize)+aead.Overhead()),
+		plain:  make([]byte, 0, chunkSize),
+	}, nil
+}
+
+type decryptingReader struct {
+	in     *bufio.Reader
+	aead   cipher.AEAD
+	header []byte
+	seq    uint64
+	done   bool
+	out    []byte // plaintext not yet read
+	sealed []byte
+	plain  []byte
+}
+
+func (r *decryptingReader) Read(p []byte) (int, error) {
+	for len(r.out) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(p, r.out)
+	r.out = r.out[n:]
+	return n, nil
+}
+
+func (r *decryptingReader) openChunk() error {
+	n, err := io.ReadFull(r.in, r.sealed)
+	final := false
+	switch {
+	case err == io.EOF || err == io.ErrUnexpectedEOF:
+		final = true
+	case err != nil:
+		return err
+	default:
+		if _, err := r.in.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return err
+		}
+	}
+	out, err := r.aead.Open(r.plain[:0], chunkNonce(r.seq, final), r.sealed[:n], r.header)
+	if err != nil {
+		return errors.Wrap(err, "failed to decrypt artifact")
+	}
+	r.out = out
+	r.seq++
+	r.done = final
+	return nil
+}
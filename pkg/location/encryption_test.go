@@ -0,0 +1,115 @@
+package location
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+
+	. "gopkg.in/check.v1"
+
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+type EncryptionSuite struct {
+	enc *param.Encryption
+}
+
+var _ = Suite(&EncryptionSuite{})
+
+func (s *EncryptionSuite) SetUpTest(c *C) {
+	s.enc = &param.Encryption{
+		KeyID: "new",
+		Keys: map[string][]byte{
+			"old": bytes.Repeat([]byte{1}, encryptionKeySize),
+			"new": bytes.Repeat([]byte{2}, encryptionKeySize),
+		},
+	}
+}
+
+func (s *EncryptionSuite) encrypt(c *C, data []byte) []byte {
+	r, err := encryptReader(bytes.NewReader(data), s.enc)
+	c.Assert(err, IsNil)
+	enc, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	return enc
+}
+
+func (s *EncryptionSuite) decrypt(enc []byte) ([]byte, error) {
+	br := bufio.NewReader(bytes.NewReader(enc))
+	if !isEncrypted(br) {
+		return ioutil.ReadAll(br)
+	}
+	r, err := decryptReader(br, s.enc)
+	if err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(r)
+}
+
+func (s *EncryptionSuite) TestRoundTrip(c *C) {
+	for _, size := range []int{0, 1, encryptionChunkSize - 1, encryptionChunkSize, encryptionChunkSize + 1, 3 * encryptionChunkSize} {
+		data := make([]byte, size)
+		rand.Read(data)
+		enc := s.encrypt(c, data)
+		c.Assert(len(enc) > size, Equals, true)
+		dec, err := s.decrypt(enc)
+		c.Assert(err, IsNil, Commentf("size %d", size))
+		c.Assert(bytes.Equal(dec, data), Equals, true, Commentf("size %d", size))
+	}
+}
+
+func (s *EncryptionSuite) TestPlaintext(c *C) {
+	data := []byte("plain artifact")
+	dec, err := s.decrypt(data)
+	c.Assert(err, IsNil)
+	c.Assert(dec, DeepEquals, data)
+}
+
+func (s *EncryptionSuite) TestKeyRotation(c *C) {
+	s.enc.KeyID = "old"
+	data := []byte("written with the old key")
+	enc := s.encrypt(c, data)
+
+	s.enc.KeyID = "new"
+	dec, err := s.decrypt(enc)
+	c.Assert(err, IsNil)
+	c.Assert(dec, DeepEquals, data)
+
+	delete(s.enc.Keys, "old")
+	_, err = s.decrypt(enc)
+	c.Assert(err, NotNil)
+}
+
+func (s *EncryptionSuite) TestTampering(c *C) {
+	data := make([]byte, 2*encryptionChunkSize+10)
+	rand.Read(data)
+	enc := s.encrypt(c, data)
+
+	// Wrong key
+	s.enc.Keys["new"] = bytes.Repeat([]byte{3}, encryptionKeySize)
+	_, err := s.decrypt(enc)
+	c.Assert(err, NotNil)
+	s.SetUpTest(c)
+
+	// Modified data
+	mod := append([]byte(nil), enc...)
+	mod[len(mod)-1] ^= 1
+	_, err = s.decrypt(mod)
+	c.Assert(err, NotNil)
+
+	// Truncated at a chunk boundary
+	headerSize := len(enc) - len(data) - 3*16
+	_, err = s.decrypt(enc[:headerSize+encryptionChunkSize+16])
+	c.Assert(err, NotNil)
+}
+
+func (s *EncryptionSuite) TestInvalidKey(c *C) {
+	s.enc.Keys["new"] = []byte("short")
+	_, err := encryptReader(bytes.NewReader(nil), s.enc)
+	c.Assert(err, NotNil)
+
+	s.enc.KeyID = "missing"
+	_, err = encryptReader(bytes.NewReader(nil), s.enc)
+	c.Assert(err, NotNil)
+}
@@ -1,8 +1,10 @@
 package location
 
 import (
+	"bufio"
 	"context"
 	"io"
+	"io/ioutil"
 	"path/filepath"
 
 	"github.com/pkg/errors"
@@ -21,30 +23,116 @@ const (
 	AzureStorageKey     = "AZURE_ACCOUNT_KEY"
 )
 
+// TypeMemory is the type of locations whose artifacts are kept in the memory
+// of the process that accesses them. The Endpoint names the store. It lets
+// tests read and write artifacts without object store credentials and is
+// not accepted in Profiles.
+const TypeMemory crv1alpha1.LocationType = "memory"
+
+// WriteOptions are optional settings for writing data to a location
+type WriteOptions struct {
+	// Compression is the codec used to compress the data before it is
+	// encrypted and stored
+	Compression Codec
+	// Size of the data, if known. It is used to pick the part size of
+	// multipart uploads.
+	Size int64
+	// Upload configures the multipart upload of the data
+	Upload objectstore.UploadOptions
+	// UploadLimit is the maximum upload rate in KiB/s. The limit of the
+	// profile is used if it is 0.
+	UploadLimit int64
+}
+
 // Write pipes data from `in` into the location specified by `profile` and `suffix`.
+// The data is encrypted if the profile has encryption keys. The SHA-256
+// checksum of the stored data is kept next to it.
 func Write(ctx context.Context, in io.Reader, profile param.Profile, suffix string) error {
+	return WriteWithOptions(ctx, in, profile, suffix, WriteOptions{})
+}
+
+// WriteWithOptions is like Write with the given options.
+func WriteWithOptions(ctx context.Context, in io.Reader, profile param.Profile, suffix string, opts WriteOptions) error {
+	_, err := WriteVersion(ctx, in, profile, suffix, opts)
+	return err
+}
+
+// WriteVersion is like WriteWithOptions and returns the version ID of the
+// written artifact. The ID is empty if the location does not keep object
+// versions.
+func WriteVersion(ctx context.Context, in io.Reader, profile param.Profile, suffix string, opts WriteOptions) (string, error) {
 	osType, err := getProviderType(profile.Location.Type)
 	if err != nil {
-		return err
+		return "", err
 	}
 	path := filepath.Join(
 		profile.Location.Prefix,
 		suffix,
 	)
-	return writeData(ctx, osType, profile, in, path)
+	return writeData(ctx, osType, profile, in, path, opts)
 }
 
 // Read pipes data from `in` into the location specified by `profile` and `suffix`.
+// Encrypted data is decrypted with the encryption keys of the profile and
+// compressed data is decompressed. If the profile has encryption keys,
+// artifacts that are not encrypted are rejected unless the options allow
+// them. The stored data is checked against the checksum stored by Write once
+// it has been copied to `out`, so `out` has received all of the data when a
+// mismatch is reported. Callers must discard it if IsChecksumMismatchError
+// returns true for the error.
 func Read(ctx context.Context, out io.Writer, profile param.Profile, suffix string) error {
+	return ReadWithOptions(ctx, out, profile, suffix, ReadOptions{})
+}
+
+// ReadWithOptions is like Read with the given options.
+func ReadWithOptions(ctx context.Context, out io.Writer, profile param.Profile, suffix string, opts ReadOptions) error {
+	osType, err := getProviderType(profile.Location.Type)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(
+		profile.Location.Prefix,
+		suffix,
+	)
+	return readData(ctx, osType, profile, out, path, opts)
+}
+
+// DeleteVersion deletes a version of the artifact at the location specified
+// by `profile` and `suffix`. Locked versions cannot be deleted until their
+// retention expires.
+func DeleteVersion(ctx context.Context, profile param.Profile, suffix, versionID string) error {
 	osType, err := getProviderType(profile.Location.Type)
 	if err != nil {
 		return err
 	}
+	bucket, err := getBucket(ctx, osType, profile)
+	if err != nil {
+		return err
+	}
 	path := filepath.Join(
 		profile.Location.Prefix,
 		suffix,
 	)
-	return readData(ctx, osType, profile, out, path)
+	return bucket.DeleteVersion(ctx, path, versionID)
+}
+
+// CheckBucketEncryption returns an error if the default encryption of the
+// bucket of `profile` does not match the server-side encryption of its
+// location. Tools that write to the bucket directly, such as restic, rely on
+// the bucket default.
+func CheckBucketEncryption(ctx context.Context, profile param.Profile) error {
+	if profile.Location.ServerSideEncryption == (crv1alpha1.ServerSideEncryption{}) {
+		return nil
+	}
+	osType, err := getProviderType(profile.Location.Type)
+	if err != nil {
+		return err
+	}
+	bucket, err := getBucket(ctx, osType, profile)
+	if err != nil {
+		return err
+	}
+	return objectstore.CheckBucketEncryption(ctx, bucket)
 }
 
 //Delete data from location specified by `profile` and `suffix`.
@@ -60,31 +148,115 @@ func Delete(ctx context.Context, profile param.Profile, suffix string) error {
 	return deleteData(ctx, osType, profile, path)
 }
 
-func readData(ctx context.Context, pType objectstore.ProviderType, profile param.Profile, out io.Writer, path string) error {
+func readData(ctx context.Context, pType objectstore.ProviderType, profile param.Profile, out io.Writer, path string, opts ReadOptions) error {
 	bucket, err := getBucket(ctx, pType, profile)
 	if err != nil {
 		return err
 	}
-
-	r, _, err := bucket.Get(ctx, path)
+	start := opts.Offset
+	if opts.VersionID != "" {
+		// Versions are read in a single stream from the start
+		start = 0
+	}
+	if start > 0 {
+		raw, err := isRawArtifact(ctx, bucket, path)
+		if err != nil {
+			return err
+		}
+		if !raw {
+			// Offsets into the stored data do not map to offsets into
+			// compressed or encrypted data
+			start = 0
+		}
+	}
+	// Data read from an offset into the stored artifact or from an older
+	// version cannot be checked against the stored checksum
+	var want string
+	var verify bool
+	if start == 0 && opts.VersionID == "" {
+		if want, verify, err = readChecksum(ctx, bucket, path); err != nil {
+			return err
+		}
+	}
+	var rc io.ReadCloser
+	var tags map[string]string
+	if opts.VersionID != "" {
+		rc, tags, err = bucket.GetVersion(ctx, path, opts.VersionID)
+	} else {
+		rc, tags, err = openArtifact(ctx, bucket, path, start, opts)
+	}
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	h := newChecksum()
+	br := bufio.NewReader(io.TeeReader(throttleReader(ctx, rc, downloadLimit(profile, opts)), h))
+	var r io.Reader = br
+	switch {
+	case isEncrypted(br):
+		if r, err = decryptReader(br, profile.Encryption); err != nil {
+			return err
+		}
+	case profile.Encryption != nil && !opts.AllowUnencrypted:
+		return errors.Errorf("artifact %s is not encrypted but the profile has encryption keys", path)
+	}
+	dr, err := decompressReader(r, Codec(tags[CompressionTag]))
 	if err != nil {
 		return err
 	}
-	if _, err := io.Copy(out, r); err != nil {
+	defer dr.Close()
+	if skip := opts.Offset - start; skip > 0 {
+		if _, err := io.CopyN(ioutil.Discard, dr, skip); err != nil && err != io.EOF {
+			return err
+		}
+	}
+	if _, err := io.Copy(out, dr); err != nil {
 		return err
 	}
-	return nil
+	if !verify {
+		return nil
+	}
+	// The checksum covers all stored bytes, including any the decoders
+	// did not consume
+	if _, err := io.Copy(ioutil.Discard, br); err != nil {
+		return err
+	}
+	return verifyChecksum(path, want, h)
 }
 
-func writeData(ctx context.Context, pType objectstore.ProviderType, profile param.Profile, in io.Reader, path string) error {
+func writeData(ctx context.Context, pType objectstore.ProviderType, profile param.Profile, in io.Reader, path string, opts WriteOptions) (string, error) {
 	bucket, err := getBucket(ctx, pType, profile)
 	if err != nil {
-		return err
+		return "", err
+	}
+	var tags map[string]string
+	size := opts.Size
+	if opts.Compression != CodecNone {
+		tags = map[string]string{CompressionTag: string(opts.Compression)}
+		size = 0
+	}
+	cr, err := compressReader(in, opts.Compression)
+	if err != nil {
+		return "", err
+	}
+	defer cr.Close()
+	in = cr
+	if profile.Encryption != nil {
+		if in, err = encryptReader(in, profile.Encryption); err != nil {
+			return "", err
+		}
+		size = 0
+	}
+	h := newChecksum()
+	in = throttleReader(ctx, io.TeeReader(in, h), uploadLimit(profile, opts))
+	versionID, err := objectstore.PutMultipart(ctx, bucket, path, in, size, tags, opts.Upload)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to write contents to bucket '%s'", profile.Location.Bucket)
 	}
-	if err := bucket.Put(ctx, path, in, 0, nil); err != nil {
-		return errors.Errorf("failed to write contents to bucket '%s'", profile.Location.Bucket)
+	if err := writeChecksum(ctx, bucket, path, h); err != nil {
+		return "", err
 	}
-	return nil
+	return versionID, nil
 }
 
 func deleteData(ctx context.Context, pType objectstore.ProviderType, profile param.Profile, path string) error {
@@ -103,6 +275,12 @@ func getProviderType(lType crv1alpha1.LocationType) (objectstore.ProviderType, e
 		return objectstore.ProviderTypeGCS, nil
 	case crv1alpha1.LocationTypeAzure:
 		return objectstore.ProviderTypeAzure, nil
+	case crv1alpha1.LocationTypeFileSystem:
+		return objectstore.ProviderTypeFileSystem, nil
+	case crv1alpha1.LocationTypeSFTP:
+		return objectstore.ProviderTypeSFTP, nil
+	case TypeMemory:
+		return objectstore.ProviderTypeMemory, nil
 	default:
 		return "", errors.Errorf("Unsupported Location type: %s", lType)
 	}
@@ -113,6 +291,17 @@ func getBucket(ctx context.Context, pType objectstore.ProviderType, profile para
 		Type:          pType,
 		Endpoint:      profile.Location.Endpoint,
 		SkipSSLVerify: profile.SkipSSLVerify,
+		Encryption:    ServerSideEncryption(profile.Location),
+		Retention:     Retention(profile.Location),
+		CACert:        profile.CACert,
+		Proxy:         profile.Proxy,
+	}
+	switch pType {
+	case objectstore.ProviderTypeFileSystem:
+		pc.Endpoint = profile.Location.Path
+	case objectstore.ProviderTypeSFTP:
+		pc.Endpoint = objectstore.SFTPEndpoint(profile.Location.Host, profile.Location.Port, profile.Location.Path)
+		pc.HostKey = profile.Location.HostKey
 	}
 	secret, err := getOSSecret(pType, profile.Credential)
 	if err != nil {
@@ -122,10 +311,46 @@ func getBucket(ctx context.Context, pType objectstore.ProviderType, profile para
 	if err != nil {
 		return nil, err
 	}
+	if pType == objectstore.ProviderTypeMemory {
+		// Memory stores start out empty in every process
+		return objectstore.GetOrCreateBucket(ctx, provider, profile.Location.Bucket, profile.Location.Region)
+	}
 	return provider.GetBucket(ctx, profile.Location.Bucket)
 }
 
+// ServerSideEncryption returns the object store encryption settings of the
+// location.
+func ServerSideEncryption(l crv1alpha1.Location) objectstore.ServerSideEncryption {
+	return objectstore.ServerSideEncryption{
+		Type:            objectstore.SSEType(l.ServerSideEncryption.Type),
+		KMSKeyID:        l.ServerSideEncryption.KMSKeyID,
+		EncryptionScope: l.ServerSideEncryption.EncryptionScope,
+	}
+}
+
+// Retention returns the object store retention settings of the location.
+func Retention(l crv1alpha1.Location) objectstore.Retention {
+	var mode objectstore.RetentionMode
+	switch l.Retention.Mode {
+	case crv1alpha1.RetentionModeGovernance:
+		mode = objectstore.RetentionModeGovernance
+	case crv1alpha1.RetentionModeCompliance:
+		mode = objectstore.RetentionModeCompliance
+	}
+	return objectstore.Retention{
+		Mode:   mode,
+		Period: l.Retention.Duration.Duration,
+	}
+}
+
 func getOSSecret(pType objectstore.ProviderType, cred param.Credential) (*objectstore.Secret, error) {
+	switch pType {
+	case objectstore.ProviderTypeFileSystem:
+		// Access is governed by the permissions of the mounted volume
+		return nil, nil
+	case objectstore.ProviderTypeMemory:
+		return nil, nil
+	}
 	secret := &objectstore.Secret{}
 	switch pType {
 	case objectstore.ProviderTypeS3:
@@ -146,6 +371,12 @@ func getOSSecret(pType objectstore.ProviderType, cred param.Credential) (*object
 			StorageAccount: cred.KeyPair.ID,
 			StorageKey:     cred.KeyPair.Secret,
 		}
+	case objectstore.ProviderTypeSFTP:
+		secret.Type = objectstore.SecretTypeSSHKey
+		secret.SSH = &objectstore.SecretSSH{
+			Username:   cred.KeyPair.ID,
+			PrivateKey: cred.KeyPair.Secret,
+		}
 	default:
 		return nil, errors.Errorf("unknown or unsupported provider type '%s'", pType)
 	}
@@ -3,7 +3,12 @@ package location
 import (
 	"bytes"
 	"context"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
 	"math/rand"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -38,6 +43,8 @@ const (
 var _ = Suite(&LocationSuite{osType: objectstore.ProviderTypeS3, region: testRegionS3})
 var _ = Suite(&LocationSuite{osType: objectstore.ProviderTypeGCS, region: ""})
 var _ = Suite(&LocationSuite{osType: objectstore.ProviderTypeAzure, region: ""})
+var _ = Suite(&LocationSuite{osType: objectstore.ProviderTypeFileSystem, region: ""})
+var _ = Suite(&LocationSuite{osType: objectstore.ProviderTypeMemory, region: ""})
 
 func (s *LocationSuite) SetUpSuite(c *C) {
 	var location crv1alpha1.Location
@@ -60,6 +67,16 @@ func (s *LocationSuite) SetUpSuite(c *C) {
 		location = crv1alpha1.Location{
 			Type: crv1alpha1.LocationTypeAzure,
 		}
+	case objectstore.ProviderTypeFileSystem:
+		location = crv1alpha1.Location{
+			Type: crv1alpha1.LocationTypeFileSystem,
+			Path: c.MkDir(),
+		}
+	case objectstore.ProviderTypeMemory:
+		location = crv1alpha1.Location{
+			Type:     TypeMemory,
+			Endpoint: "location-test",
+		}
 	default:
 		c.Fatalf("Unrecognized objectstore '%s'", s.osType)
 	}
@@ -69,7 +86,10 @@ func (s *LocationSuite) SetUpSuite(c *C) {
 	ctx := context.Background()
 
 	s.rand = rand.New(rand.NewSource(time.Now().UnixNano()))
-	pc := objectstore.ProviderConfig{Type: s.osType}
+	pc := objectstore.ProviderConfig{Type: s.osType, Endpoint: location.Path}
+	if s.osType == objectstore.ProviderTypeMemory {
+		pc.Endpoint = location.Endpoint
+	}
 	secret, err := getOSSecret(s.osType, s.profile.Credential)
 	c.Check(err, IsNil)
 	s.provider, err = objectstore.NewProvider(ctx, pc, secret)
@@ -87,6 +107,7 @@ func (s *LocationSuite) TearDownTest(c *C) {
 	if s.testpath != "" {
 		c.Assert(s.root, NotNil)
 		ctx := context.Background()
+		s.root.Delete(ctx, s.testpath+ChecksumSuffix)
 		err := s.root.Delete(ctx, s.testpath)
 		if err != nil {
 			c.Log("Cannot cleanup test directory: ", s.testpath)
@@ -98,11 +119,239 @@ func (s *LocationSuite) TearDownTest(c *C) {
 func (s *LocationSuite) TestWriteAndReadData(c *C) {
 	ctx := context.Background()
 	teststring := "test-content"
-	err := writeData(ctx, s.osType, s.profile, bytes.NewBufferString(teststring), s.testpath)
+	_, err := writeData(ctx, s.osType, s.profile, bytes.NewBufferString(teststring), s.testpath, WriteOptions{})
 	c.Check(err, IsNil)
 	buf := bytes.NewBuffer(nil)
-	err = readData(ctx, s.osType, s.profile, buf, s.testpath)
+	err = readData(ctx, s.osType, s.profile, buf, s.testpath, ReadOptions{})
 	c.Check(err, IsNil)
 	c.Check(buf.String(), Equals, teststring)
 
 }
+
+func (s *LocationSuite) TestWriteAndReadEncryptedData(c *C) {
+	ctx := context.Background()
+	teststring := "test-content"
+	profile := s.profile
+	profile.Encryption = &param.Encryption{
+		KeyID: "key1",
+		Keys:  map[string][]byte{"key1": bytes.Repeat([]byte{1}, 32)},
+	}
+	_, err := writeData(ctx, s.osType, profile, bytes.NewBufferString(teststring), s.testpath, WriteOptions{})
+	c.Check(err, IsNil)
+
+	// The stored data is not the plaintext
+	r, _, err := s.root.GetBytes(ctx, s.testpath)
+	c.Check(err, IsNil)
+	c.Check(bytes.Contains(r, []byte(teststring)), Equals, false)
+
+	// The checksum covers the stored data rather than the plaintext, and
+	// can be verified without the encryption keys
+	sum, ok, err := readChecksum(ctx, s.root, s.testpath)
+	c.Check(err, IsNil)
+	c.Check(ok, Equals, true)
+	c.Check(sum, Equals, fmt.Sprintf("%x", sha256.Sum256(r)))
+	c.Check(Verify(ctx, s.profile, s.testpath, ReadOptions{}), IsNil)
+
+	buf := bytes.NewBuffer(nil)
+	err = readData(ctx, s.osType, profile, buf, s.testpath, ReadOptions{})
+	c.Check(err, IsNil)
+	c.Check(buf.String(), Equals, teststring)
+
+	err = readData(ctx, s.osType, s.profile, bytes.NewBuffer(nil), s.testpath, ReadOptions{})
+	c.Check(err, NotNil)
+
+	// Unencrypted artifacts are only read if the caller allows them
+	_, err = writeData(ctx, s.osType, s.profile, bytes.NewBufferString(teststring), s.testpath, WriteOptions{})
+	c.Check(err, IsNil)
+	err = readData(ctx, s.osType, profile, bytes.NewBuffer(nil), s.testpath, ReadOptions{})
+	c.Check(err, ErrorMatches, ".* is not encrypted but the profile has encryption keys")
+	buf.Reset()
+	err = readData(ctx, s.osType, profile, buf, s.testpath, ReadOptions{AllowUnencrypted: true})
+	c.Check(err, IsNil)
+	c.Check(buf.String(), Equals, teststring)
+}
+
+func (s *LocationSuite) TestWriteAndReadCompressedData(c *C) {
+	ctx := context.Background()
+	teststring := strings.Repeat("test-content", 100)
+	for _, codec := range []Codec{CodecGzip, CodecZstd} {
+		_, err := writeData(ctx, s.osType, s.profile, bytes.NewBufferString(teststring), s.testpath, WriteOptions{Compression: codec})
+		c.Check(err, IsNil)
+
+		data, tags, err := s.root.GetBytes(ctx, s.testpath)
+		c.Check(err, IsNil)
+		c.Check(tags[CompressionTag], Equals, string(codec))
+		c.Check(len(data) < len(teststring), Equals, true)
+
+		buf := bytes.NewBuffer(nil)
+		err = readData(ctx, s.osType, s.profile, buf, s.testpath, ReadOptions{})
+		c.Check(err, IsNil)
+		c.Check(buf.String(), Equals, teststring)
+	}
+}
+
+func (s *LocationSuite) TestReadWithOptions(c *C) {
+	ctx := context.Background()
+	data := make([]byte, 1000)
+	_, err := s.rand.Read(data)
+	c.Assert(err, IsNil)
+	for _, wo := range []WriteOptions{{}, {Compression: CodecGzip}} {
+		_, err := writeData(ctx, s.osType, s.profile, bytes.NewReader(data), s.testpath, wo)
+		c.Assert(err, IsNil)
+		for _, ro := range []ReadOptions{
+			{},
+			{Offset: 10},
+			{Concurrency: 4, ChunkSize: 64},
+			{Concurrency: 4, ChunkSize: 100},
+			{Concurrency: 2, ChunkSize: 64, Offset: 130},
+			{Concurrency: 4, ChunkSize: 2000},
+			{Offset: 1000},
+			{Offset: 2000, Concurrency: 4, ChunkSize: 64},
+		} {
+			buf := bytes.NewBuffer(nil)
+			err = readData(ctx, s.osType, s.profile, buf, s.testpath, ro)
+			c.Assert(err, IsNil)
+			want := data[:0]
+			if ro.Offset < int64(len(data)) {
+				want = data[ro.Offset:]
+			}
+			c.Assert(buf.String(), Equals, string(want), Commentf("write: %#v, read: %#v", wo, ro))
+		}
+	}
+}
+
+func (s *LocationSuite) TestChecksum(c *C) {
+	ctx := context.Background()
+	data := make([]byte, 1000)
+	_, err := s.rand.Read(data)
+	c.Assert(err, IsNil)
+	for _, wo := range []WriteOptions{{}, {Compression: CodecGzip}} {
+		_, err := writeData(ctx, s.osType, s.profile, bytes.NewReader(data), s.testpath, wo)
+		c.Assert(err, IsNil)
+		stored, _, err := s.root.GetBytes(ctx, s.testpath)
+		c.Assert(err, IsNil)
+		sum, ok, err := readChecksum(ctx, s.root, s.testpath)
+		c.Assert(err, IsNil)
+		c.Assert(ok, Equals, true)
+		c.Assert(sum, Equals, fmt.Sprintf("%x", sha256.Sum256(stored)))
+		c.Assert(Verify(ctx, s.profile, s.testpath, ReadOptions{}), IsNil)
+		c.Assert(Verify(ctx, s.profile, s.testpath, ReadOptions{Concurrency: 4, ChunkSize: 64}), IsNil)
+	}
+
+	// Artifacts that do not match their checksum fail to read
+	err = s.root.PutBytes(ctx, s.testpath, []byte("corrupted"), nil)
+	c.Assert(err, IsNil)
+	err = readData(ctx, s.osType, s.profile, ioutil.Discard, s.testpath, ReadOptions{})
+	c.Assert(err, ErrorMatches, "checksum mismatch.*")
+	c.Assert(IsChecksumMismatchError(err), Equals, true)
+	err = Verify(ctx, s.profile, s.testpath, ReadOptions{})
+	c.Assert(IsChecksumMismatchError(err), Equals, true)
+
+	// Artifacts written without a checksum can be read but not verified
+	err = s.root.Delete(ctx, s.testpath+ChecksumSuffix)
+	c.Assert(err, IsNil)
+	buf := bytes.NewBuffer(nil)
+	err = readData(ctx, s.osType, s.profile, buf, s.testpath, ReadOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(buf.String(), Equals, "corrupted")
+	c.Assert(Verify(ctx, s.profile, s.testpath, ReadOptions{}), ErrorMatches, "no checksum stored.*")
+}
+
+func (s *LocationSuite) TestCopy(c *C) {
+	ctx := context.Background()
+	dst := param.Profile{
+		Location: crv1alpha1.Location{
+			Type:     TypeMemory,
+			Endpoint: c.TestName(),
+			Bucket:   testBucketName,
+			Prefix:   "replica",
+		},
+	}
+	defer objectstore.ResetMemoryProvider(c.TestName())
+	dir := filepath.Join(s.suiteDirPrefix, "copy")
+	defer deleteData(ctx, s.osType, s.profile, dir)
+	artifacts := map[string]WriteOptions{
+		"a.txt":     {},
+		"sub/b.txt": {Compression: CodecGzip},
+	}
+	for name, wo := range artifacts {
+		_, err := writeData(ctx, s.osType, s.profile, bytes.NewBufferString(name), filepath.Join(dir, name), wo)
+		c.Assert(err, IsNil)
+	}
+
+	var progress []CopyProgress
+	opts := CopyOptions{
+		Verify:   true,
+		Progress: func(p CopyProgress) { progress = append(progress, p) },
+	}
+	res, err := Copy(ctx, s.profile, dst, filepath.Join(dir, "a.txt"), "single.txt", opts)
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, CopyResult{Artifacts: 1, Size: 5})
+	c.Assert(progress, DeepEquals, []CopyProgress{{Source: filepath.Join(dir, "a.txt"), Destination: "single.txt", Size: 5, Copied: 1}})
+
+	res, err = Copy(ctx, s.profile, dst, dir, "all", opts)
+	c.Assert(err, IsNil)
+	c.Assert(res.Artifacts, Equals, 2)
+	for name, wo := range artifacts {
+		buf := bytes.NewBuffer(nil)
+		err := ReadWithOptions(ctx, buf, dst, filepath.Join("all", name), ReadOptions{})
+		c.Assert(err, IsNil)
+		c.Assert(buf.String(), Equals, name)
+		_, tags, err := mustGetBucket(c, dst).GetVersion(ctx, filepath.Join("replica", "all", name), "")
+		c.Assert(err, IsNil)
+		c.Assert(Codec(tags[CompressionTag]), Equals, wo.Compression)
+	}
+
+	_, err = Copy(ctx, s.profile, dst, filepath.Join(dir, "missing"), "missing", opts)
+	c.Assert(err, NotNil)
+}
+
+func (s *LocationSuite) TestCopyPrefixAsStored(c *C) {
+	ctx := context.Background()
+	dst := param.Profile{
+		Location: crv1alpha1.Location{
+			Type:     TypeMemory,
+			Endpoint: c.TestName(),
+			Bucket:   testBucketName,
+		},
+		// Objects under a prefix are not encrypted for the destination
+		Encryption: &param.Encryption{
+			KeyID: "key1",
+			Keys:  map[string][]byte{"key1": bytes.Repeat([]byte{1}, 32)},
+		},
+	}
+	defer objectstore.ResetMemoryProvider(c.TestName())
+	dir := filepath.Join(s.suiteDirPrefix, "repo")
+	defer deleteData(ctx, s.osType, s.profile, dir)
+	// Objects written by restic rather than as artifacts
+	objects := map[string][]byte{}
+	for _, name := range []string{"config", "index/0a1b", "data/2c/2c3d"} {
+		data := make([]byte, 1000)
+		_, err := s.rand.Read(data)
+		c.Assert(err, IsNil)
+		objects[name] = data
+		err = s.root.PutBytes(ctx, filepath.Join(dir, name), data, nil)
+		c.Assert(err, IsNil)
+	}
+
+	res, err := Copy(ctx, s.profile, dst, dir, "replica", CopyOptions{Verify: true})
+	c.Assert(err, IsNil)
+	c.Assert(res, Equals, CopyResult{Artifacts: 3, Size: 3000})
+	bucket := mustGetBucket(c, dst)
+	for name, data := range objects {
+		got, _, err := bucket.GetBytes(ctx, filepath.Join("replica", name))
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(got, data), Equals, true, Commentf("object %s", name))
+		// No checksums are added
+		_, _, err = bucket.GetBytes(ctx, filepath.Join("replica", name+ChecksumSuffix))
+		c.Assert(objectstore.IsObjectNotFoundError(err), Equals, true)
+	}
+}
+
+func mustGetBucket(c *C, p param.Profile) objectstore.Bucket {
+	pType, err := getProviderType(p.Location.Type)
+	c.Assert(err, IsNil)
+	b, err := getBucket(context.Background(), pType, p)
+	c.Assert(err, IsNil)
+	return b
+}
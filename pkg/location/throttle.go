@@ -0,0 +1,63 @@
+package location
+
+import (
+	"context"
+	"io"
+
+	"golang.org/x/time/rate"
+
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+// throttledReader limits the rate at which data is read from r
+type throttledReader struct {
+	ctx     context.Context
+	r       io.Reader
+	limiter *rate.Limiter
+}
+
+// throttleReader returns a reader of `r` that reads at most `limit` KiB/s.
+// `r` is returned as is if the limit is not positive.
+func throttleReader(ctx context.Context, r io.Reader, limit int64) io.Reader {
+	if limit <= 0 {
+		return r
+	}
+	bytesPerSec := int(limit * 1024)
+	return &throttledReader{
+		ctx:     ctx,
+		r:       r,
+		limiter: rate.NewLimiter(rate.Limit(bytesPerSec), bytesPerSec),
+	}
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	// Reads larger than the burst of the limiter could never be allowed
+	if b := t.limiter.Burst(); len(p) > b {
+		p = p[:b]
+	}
+	n, err := t.r.Read(p)
+	if n > 0 {
+		if werr := t.limiter.WaitN(t.ctx, n); werr != nil {
+			return n, werr
+		}
+	}
+	return n, err
+}
+
+// uploadLimit returns the upload limit of the options or the default of the
+// profile
+func uploadLimit(profile param.Profile, opts WriteOptions) int64 {
+	if opts.UploadLimit > 0 {
+		return opts.UploadLimit
+	}
+	return profile.BandwidthLimit.Upload
+}
+
+// downloadLimit returns the download limit of the options or the default of
+// the profile
+func downloadLimit(profile param.Profile, opts ReadOptions) int64 {
+	if opts.DownloadLimit > 0 {
+		return opts.DownloadLimit
+	}
+	return profile.BandwidthLimit.Download
+}
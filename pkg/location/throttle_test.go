@@ -0,0 +1,47 @@
+package location
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"time"
+
+	. "gopkg.in/check.v1"
+
+	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/param"
+)
+
+type ThrottleSuite struct{}
+
+var _ = Suite(&ThrottleSuite{})
+
+func (s *ThrottleSuite) TestThrottleReader(c *C) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte{'x'}, 3*1024)
+
+	r := throttleReader(ctx, bytes.NewReader(data), 0)
+	_, ok := r.(*throttledReader)
+	c.Assert(ok, Equals, false)
+
+	// The first KiB is within the burst. The other two take about a second
+	// each.
+	start := time.Now()
+	out, err := ioutil.ReadAll(throttleReader(ctx, bytes.NewReader(data), 1))
+	c.Assert(err, IsNil)
+	c.Assert(out, DeepEquals, data)
+	c.Assert(time.Since(start) > 1500*time.Millisecond, Equals, true)
+
+	cctx, cancel := context.WithCancel(ctx)
+	cancel()
+	_, err = ioutil.ReadAll(throttleReader(cctx, bytes.NewReader(data), 1))
+	c.Assert(err, NotNil)
+}
+
+func (s *ThrottleSuite) TestLimits(c *C) {
+	profile := param.Profile{BandwidthLimit: crv1alpha1.BandwidthLimit{Upload: 10, Download: 20}}
+	c.Assert(uploadLimit(profile, WriteOptions{}), Equals, int64(10))
+	c.Assert(uploadLimit(profile, WriteOptions{UploadLimit: 5}), Equals, int64(5))
+	c.Assert(downloadLimit(profile, ReadOptions{}), Equals, int64(20))
+	c.Assert(downloadLimit(profile, ReadOptions{DownloadLimit: 5}), Equals, int64(5))
+}
@@ -2,6 +2,7 @@ package objectstore
 
 import (
 	"context"
+	"net/http"
 	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -38,8 +39,26 @@ func IsBucketNotFoundError(err error) bool {
 }
 
 func GetS3BucketRegion(ctx context.Context, bucketName, regionHint string) (string, error) {
+	return getS3BucketRegion(ctx, bucketName, regionHint, nil)
+}
+
+// GetS3BucketRegionForConfig is like GetS3BucketRegion and sends the request
+// with the CA bundle, proxy and TLS settings of the object store
+func GetS3BucketRegionForConfig(ctx context.Context, pc ProviderConfig, bucketName, regionHint string) (string, error) {
+	hc, err := httpClient(pc)
+	if err != nil {
+		return "", err
+	}
+	return getS3BucketRegion(ctx, bucketName, regionHint, hc)
+}
+
+func getS3BucketRegion(ctx context.Context, bucketName, regionHint string, hc *http.Client) (string, error) {
 	r := s3.NormalizeBucketLocation(regionHint)
-	s, err := session.NewSession(config(r))
+	c := config(r)
+	if hc != nil {
+		c.WithHTTPClient(hc)
+	}
+	s, err := session.NewSession(c)
 	if err != nil {
 		return "", errors.Wrapf(err, "failed to create session, region = %s", r)
 	}
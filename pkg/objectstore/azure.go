@@ -0,0 +1,316 @@
+package objectstore
+
+// Azure locations are implemented here rather than by stow, which sends
+// requests with http.DefaultClient before the client can be configured.
+// The implementation follows the azure package of stow.
+
+import (
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	azstorage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/graymeta/stow"
+	stowaz "github.com/graymeta/stow/azure"
+	"github.com/pkg/errors"
+)
+
+// azureTimeFormat is the format of the times of Azure containers
+const azureTimeFormat = "Mon, 2 Jan 2006 15:04:05 MST"
+
+// newAzureBlobClient returns a blob service client that sends its requests
+// with hc, or with the default client if hc is nil
+func newAzureBlobClient(stowConfig stow.Config, hc *http.Client) (*azstorage.BlobStorageClient, error) {
+	account, _ := stowConfig.Config(stowaz.ConfigAccount)
+	key, _ := stowConfig.Config(stowaz.ConfigKey)
+	cli, err := azstorage.NewBasicClient(account, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create Azure storage client")
+	}
+	if hc != nil {
+		cli.HTTPClient = hc
+	}
+	bsc := cli.GetBlobService()
+	return &bsc, nil
+}
+
+func newAzureLocation(stowConfig stow.Config, hc *http.Client) (stow.Location, error) {
+	client, err := newAzureBlobClient(stowConfig, hc)
+	if err != nil {
+		return nil, err
+	}
+	account, _ := stowConfig.Config(stowaz.ConfigAccount)
+	l := &azureLocation{account: account, client: client}
+	// Test the connection
+	if _, _, err := l.Containers("", stow.CursorStart, 1); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
+
+var _ stow.Location = (*azureLocation)(nil)
+
+type azureLocation struct {
+	account string
+	client  *azstorage.BlobStorageClient
+}
+
+func (l *azureLocation) Close() error {
+	return nil
+}
+
+func (l *azureLocation) CreateContainer(name string) (stow.Container, error) {
+	err := l.client.GetContainerReference(name).Create(&azstorage.CreateContainerOptions{Access: azstorage.ContainerAccessTypeBlob})
+	if err != nil {
+		if strings.Contains(err.Error(), "ErrorCode=ContainerAlreadyExists") {
+			return l.Container(name)
+		}
+		return nil, err
+	}
+	c := &azureStowContainer{
+		id: name,
+		properties: azstorage.ContainerProperties{
+			LastModified: time.Now().Format(azureTimeFormat),
+		},
+		client: l.client,
+	}
+	// Containers are not usable right after they are created
+	time.Sleep(3 * time.Second)
+	return c, nil
+}
+
+func (l *azureLocation) Containers(prefix, cursor string, count int) ([]stow.Container, string, error) {
+	params := azstorage.ListContainersParameters{
+		MaxResults: uint(count),
+		Prefix:     prefix,
+	}
+	if cursor != stow.CursorStart {
+		params.Marker = cursor
+	}
+	resp, err := l.client.ListContainers(params)
+	if err != nil {
+		return nil, "", err
+	}
+	containers := make([]stow.Container, 0, len(resp.Containers))
+	for _, c := range resp.Containers {
+		containers = append(containers, &azureStowContainer{
+			id:         c.Name,
+			properties: c.Properties,
+			client:     l.client,
+		})
+	}
+	return containers, resp.NextMarker, nil
+}
+
+func (l *azureLocation) Container(id string) (stow.Container, error) {
+	// Container names are at least 3 characters long
+	prefix := id
+	if len(prefix) > 3 {
+		prefix = prefix[:3]
+	}
+	cursor := stow.CursorStart
+	for {
+		containers, next, err := l.Containers(prefix, cursor, 100)
+		if err != nil {
+			return nil, stow.ErrNotFound
+		}
+		for _, c := range containers {
+			if c.ID() == id {
+				return c, nil
+			}
+		}
+		if next == "" {
+			return nil, stow.ErrNotFound
+		}
+		cursor = next
+	}
+}
+
+func (l *azureLocation) ItemByURL(u *url.URL) (stow.Item, error) {
+	if u.Scheme != stowaz.Kind {
+		return nil, errors.New("not valid azure URL")
+	}
+	if strings.Split(u.Host, ".")[0] != l.account {
+		return nil, errors.New("wrong azure URL")
+	}
+	params := strings.SplitN(strings.TrimLeft(u.Path, "/"), "/", 2)
+	if len(params) != 2 {
+		return nil, errors.New("wrong path")
+	}
+	c, err := l.Container(params[0])
+	if err != nil {
+		return nil, err
+	}
+	return c.Item(params[1])
+}
+
+func (l *azureLocation) RemoveContainer(id string) error {
+	return l.client.GetContainerReference(id).Delete(nil)
+}
+
+var _ stow.Container = (*azureStowContainer)(nil)
+
+type azureStowContainer struct {
+	id         string
+	properties azstorage.ContainerProperties
+	client     *azstorage.BlobStorageClient
+}
+
+func (c *azureStowContainer) ID() string {
+	return c.id
+}
+
+func (c *azureStowContainer) Name() string {
+	return c.id
+}
+
+func (c *azureStowContainer) Item(id string) (stow.Item, error) {
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(id)
+	if err := blob.GetProperties(nil); err != nil {
+		if strings.Contains(err.Error(), "404") {
+			return nil, stow.ErrNotFound
+		}
+		return nil, err
+	}
+	blob.Properties.Etag = cleanEtag(blob.Properties.Etag)
+	return &azureStowItem{
+		id:         id,
+		container:  c,
+		properties: blob.Properties,
+	}, nil
+}
+
+func (c *azureStowContainer) Items(prefix, cursor string, count int) ([]stow.Item, string, error) {
+	params := azstorage.ListBlobsParameters{
+		Prefix:     prefix,
+		MaxResults: uint(count),
+	}
+	if cursor != "" {
+		params.Marker = cursor
+	}
+	resp, err := c.client.GetContainerReference(c.id).ListBlobs(params)
+	if err != nil {
+		return nil, "", err
+	}
+	items := make([]stow.Item, 0, len(resp.Blobs))
+	for _, blob := range resp.Blobs {
+		blob.Properties.Etag = cleanEtag(blob.Properties.Etag)
+		items = append(items, &azureStowItem{
+			id:         blob.Name,
+			container:  c,
+			properties: blob.Properties,
+		})
+	}
+	return items, resp.NextMarker, nil
+}
+
+func (c *azureStowContainer) Put(name string, r io.Reader, size int64, metadata map[string]interface{}) (stow.Item, error) {
+	md := make(map[string]string, len(metadata))
+	for k, v := range metadata {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("value of key '%s' in metadata must be of type string", k)
+		}
+		md[k] = s
+	}
+	name = strings.Replace(name, " ", "+", -1)
+	blob := c.client.GetContainerReference(c.id).GetBlobReference(name)
+	if err := blob.CreateBlockBlobFromReader(r, nil); err != nil {
+		return nil, errors.Wrap(err, "unable to create or update Item")
+	}
+	blob.Metadata = md
+	if err := blob.SetMetadata(nil); err != nil {
+		return nil, errors.Wrap(err, "unable to create or update item, setting Item metadata")
+	}
+	return &azureStowItem{
+		id:        name,
+		container: c,
+		properties: azstorage.BlobProperties{
+			LastModified:  azstorage.TimeRFC1123(time.Now()),
+			ContentLength: size,
+		},
+	}, nil
+}
+
+func (c *azureStowContainer) RemoveItem(id string) error {
+	return c.client.GetContainerReference(c.id).GetBlobReference(id).Delete(nil)
+}
+
+var _ stow.Item = (*azureStowItem)(nil)
+
+type azureStowItem struct {
+	id         string
+	container  *azureStowContainer
+	properties azstorage.BlobProperties
+	infoOnce   sync.Once
+	metadata   map[string]interface{}
+	infoErr    error
+}
+
+func (i *azureStowItem) blob() *azstorage.Blob {
+	return i.container.client.GetContainerReference(i.container.id).GetBlobReference(i.id)
+}
+
+func (i *azureStowItem) ID() string {
+	return i.id
+}
+
+func (i *azureStowItem) Name() string {
+	return i.id
+}
+
+func (i *azureStowItem) URL() *url.URL {
+	u, _ := url.Parse(i.blob().GetURL())
+	u.Scheme = stowaz.Kind
+	return u
+}
+
+func (i *azureStowItem) Size() (int64, error) {
+	return i.properties.ContentLength, nil
+}
+
+func (i *azureStowItem) Open() (io.ReadCloser, error) {
+	return i.blob().Get(nil)
+}
+
+func (i *azureStowItem) ETag() (string, error) {
+	return i.properties.Etag, nil
+}
+
+func (i *azureStowItem) LastMod() (time.Time, error) {
+	return time.Time(i.properties.LastModified), nil
+}
+
+func (i *azureStowItem) Metadata() (map[string]interface{}, error) {
+	i.infoOnce.Do(func() {
+		blob := i.blob()
+		if err := blob.GetMetadata(nil); err != nil {
+			i.infoErr = err
+			return
+		}
+		i.metadata = make(map[string]interface{}, len(blob.Metadata))
+		for k, v := range blob.Metadata {
+			i.metadata[k] = v
+		}
+	})
+	return i.metadata, errors.Wrap(i.infoErr, "retrieving metadata")
+}
+
+// cleanEtag removes the quotes around an ETag and the prefix of weak ETags
+func cleanEtag(etag string) string {
+	for {
+		switch {
+		case strings.HasPrefix(etag, `\"`):
+			etag = strings.Trim(etag, `\"`)
+		case strings.HasPrefix(etag, `"`):
+			etag = strings.Trim(etag, `"`)
+		case strings.HasPrefix(etag, `W/`):
+			etag = strings.Replace(etag, `W/`, "", 1)
+		default:
+			return etag
+		}
+	}
+}
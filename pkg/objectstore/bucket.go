@@ -4,6 +4,7 @@ package objectstore
 
 import (
 	"context"
+	"net/http"
 	"path"
 
 	"github.com/graymeta/stow"
@@ -20,6 +21,8 @@ type provider struct {
 	config ProviderConfig
 	// Secret
 	secret *Secret
+	// Client of the object store, nil if the default client is used
+	hc *http.Client
 }
 
 var _ Bucket = (*bucket)(nil)
@@ -30,11 +33,14 @@ type bucket struct {
 	container    stow.Container // stow bucket
 	location     stow.Location  // Authenticated stow handle
 	hostEndPoint string         // E.g., https://s3-us-west-2.amazonaws.com/bucket1
+	config       ProviderConfig // Object store information
+	secret       *Secret        // Credentials of the object store
+	hc           *http.Client   // Client of the object store, nil for the default client
 }
 
 // CreateBucket creates the bucket. Bucket naming rules are provider dependent.
 func (p *provider) CreateBucket(ctx context.Context, bucketName, region string) (Bucket, error) {
-	location, err := getStowLocation(ctx, p.config, p.secret, region)
+	location, err := getStowLocation(ctx, p.config, p.secret, region, p.hc)
 	if err != nil {
 		return nil, err
 	}
@@ -49,6 +55,9 @@ func (p *provider) CreateBucket(ctx context.Context, bucketName, region string)
 		directory:    dir,
 		container:    c,
 		location:     location,
+		config:       p.config,
+		secret:       p.secret,
+		hc:           p.hc,
 		hostEndPoint: path.Join(p.hostEndPoint, c.ID()),
 	}
 	dir.bucket = bucket
@@ -58,7 +67,7 @@ func (p *provider) CreateBucket(ctx context.Context, bucketName, region string)
 // GetBucket gets the handle for the specified bucket. Buckets are searched using prefix search;
 // if multiple buckets matched the name, then returns an error
 func (p *provider) GetBucket(ctx context.Context, bucketName string) (Bucket, error) {
-	location, err := getStowLocation(ctx, p.config, p.secret, "")
+	location, err := getStowLocation(ctx, p.config, p.secret, "", p.hc)
 	if err != nil {
 		return nil, err
 	}
@@ -73,6 +82,9 @@ func (p *provider) GetBucket(ctx context.Context, bucketName string) (Bucket, er
 		directory:    dir,
 		container:    c,
 		location:     location,
+		config:       p.config,
+		secret:       p.secret,
+		hc:           p.hc,
 		hostEndPoint: path.Join(p.hostEndPoint, c.ID()),
 	}
 	dir.bucket = bucket
@@ -84,7 +96,7 @@ func (p *provider) ListBuckets(ctx context.Context) (map[string]Bucket, error) {
 	// Walk all the buckets
 	buckets := make(map[string]Bucket)
 
-	location, err := getStowLocation(ctx, p.config, p.secret, "")
+	location, err := getStowLocation(ctx, p.config, p.secret, "", p.hc)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +113,9 @@ func (p *provider) ListBuckets(ctx context.Context) (map[string]Bucket, error) {
 				directory:    dir,
 				container:    c,
 				location:     location,
+				config:       p.config,
+				secret:       p.secret,
+				hc:           p.hc,
 				hostEndPoint: path.Join(p.hostEndPoint, c.ID()),
 			}
 			dir.bucket = bucket
@@ -117,7 +132,7 @@ func (p *provider) ListBuckets(ctx context.Context) (map[string]Bucket, error) {
 // For safety, does not delete buckets with contents. Caller should ensure
 // that bucket is empty.
 func (p *provider) DeleteBucket(ctx context.Context, bucketName string) error {
-	location, err := getStowLocation(ctx, p.config, p.secret, "")
+	location, err := getStowLocation(ctx, p.config, p.secret, "", p.hc)
 	if err != nil {
 		return err
 	}
@@ -148,7 +163,7 @@ func (p *s3Provider) GetBucket(ctx context.Context, bucketName string) (Bucket,
 		}
 		hostEndPoint = awsS3Endpoint(region)
 	}
-	location, err := getStowLocation(ctx, p.config, p.secret, region)
+	location, err := getStowLocation(ctx, p.config, p.secret, region, p.hc)
 	if err != nil {
 		return nil, err
 	}
@@ -163,6 +178,9 @@ func (p *s3Provider) GetBucket(ctx context.Context, bucketName string) (Bucket,
 		directory:    dir,
 		container:    c,
 		location:     location,
+		config:       p.config,
+		secret:       p.secret,
+		hc:           p.hc,
 		hostEndPoint: path.Join(hostEndPoint, c.ID()),
 	}
 	dir.bucket = bucket
@@ -171,7 +189,7 @@ func (p *s3Provider) GetBucket(ctx context.Context, bucketName string) (Bucket,
 
 func (p *s3Provider) DeleteBucket(ctx context.Context, bucketName string) error {
 	region, _ := p.getRegionForBucket(ctx, bucketName)
-	location, err := getStowLocation(ctx, p.config, p.secret, region)
+	location, err := getStowLocation(ctx, p.config, p.secret, region, p.hc)
 	if err != nil {
 		return errors.Wrapf(err, "Failed to get location for bucket deletion. bucket: %s", bucketName)
 	}
@@ -180,7 +198,7 @@ func (p *s3Provider) DeleteBucket(ctx context.Context, bucketName string) error
 
 // returns the region for a particular bucket
 func (p *s3Provider) getRegionForBucket(ctx context.Context, bucketName string) (string, error) {
-	return GetS3BucketRegion(ctx, bucketName, "")
+	return getS3BucketRegion(ctx, bucketName, "", p.hc)
 }
 
 func (p *s3Provider) getOrCreateBucket(ctx context.Context, bucketName, region string) (Bucket, error) {
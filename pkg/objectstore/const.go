@@ -15,6 +15,12 @@ const (
 	ProviderTypeS3 ProviderType = "S3"
 	// ProviderTypeAzure captures enum value "Azure"
 	ProviderTypeAzure ProviderType = "Azure"
+	// ProviderTypeFileSystem captures enum value "FileSystem"
+	ProviderTypeFileSystem ProviderType = "FileSystem"
+	// ProviderTypeSFTP captures enum value "SFTP"
+	ProviderTypeSFTP ProviderType = "SFTP"
+	// ProviderTypeMemory captures enum value "Memory"
+	ProviderTypeMemory ProviderType = "Memory"
 )
 
 // SecretType enum for different providers
@@ -27,4 +33,28 @@ const (
 	SecretTypeGcpServiceAccountKey SecretType = "GcpServiceAccountKey"
 	// SecretTypeAzStorageAccount captures enum value "AzStorageAccount"
 	SecretTypeAzStorageAccount SecretType = "AzStorageAccount"
+	// SecretTypeSSHKey captures enum value "SSHKey"
+	SecretTypeSSHKey SecretType = "SSHKey"
+)
+
+// SSEType enum for the server-side encryption of S3 objects
+type SSEType string
+
+const (
+	// SSETypeS3 captures enum value "AES256" (SSE-S3)
+	SSETypeS3 SSEType = "AES256"
+	// SSETypeKMS captures enum value "aws:kms" (SSE-KMS)
+	SSETypeKMS SSEType = "aws:kms"
+)
+
+// RetentionMode enum for the retention of locked objects
+type RetentionMode string
+
+const (
+	// RetentionModeGovernance captures enum value "GOVERNANCE". Users with
+	// special permissions can remove the lock.
+	RetentionModeGovernance RetentionMode = "GOVERNANCE"
+	// RetentionModeCompliance captures enum value "COMPLIANCE". The lock
+	// cannot be removed until it expires.
+	RetentionModeCompliance RetentionMode = "COMPLIANCE"
 )
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -119,6 +120,59 @@ func (d *directory) ListObjects(ctx context.Context) ([]string, error) {
 	return objects, nil
 }
 
+// ListObjectsPage returns a page of the objects that have d.path as the
+// prefix. Without opts.Recursive, pages can have fewer objects than
+// opts.PageSize, or none, before the end of the listing.
+func (d *directory) ListObjectsPage(ctx context.Context, opts ListOptions) ([]ObjectInfo, string, error) {
+	if d.path == "" {
+		return nil, "", errors.New("invalid entry")
+	}
+	prefix := cloudName(d.path)
+	items, cursor, err := d.bucket.container.Items(prefix, opts.Cursor, opts.pageSize())
+	if err != nil {
+		return nil, "", err
+	}
+	objects := make([]ObjectInfo, 0, len(items))
+	for _, item := range items {
+		objName := strings.TrimPrefix(item.Name(), prefix)
+		// Skip directory markers and, unless recursive, sub directories
+		if objName == "" || strings.HasSuffix(objName, "/") || (!opts.Recursive && strings.Contains(objName, "/")) {
+			continue
+		}
+		info, err := itemInfo(item, objName, opts.Tags)
+		if err != nil {
+			return nil, "", err
+		}
+		objects = append(objects, info)
+	}
+	if stow.IsCursorEnd(cursor) {
+		cursor = ""
+	}
+	return objects, cursor, nil
+}
+
+func itemInfo(item stow.Item, name string, withTags bool) (ObjectInfo, error) {
+	info := ObjectInfo{Name: name}
+	var err error
+	if info.Size, err = item.Size(); err != nil {
+		return info, err
+	}
+	if info.LastModified, err = item.LastMod(); err != nil {
+		return info, err
+	}
+	if info.ETag, err = item.ETag(); err != nil {
+		return info, err
+	}
+	// Some providers quote the ETag
+	info.ETag = strings.Trim(info.ETag, `"`)
+	if withTags {
+		if info.Tags, err = itemTags(item); err != nil {
+			return info, err
+		}
+	}
+	return info, nil
+}
+
 // DeleteDirectory deletes all objects that have d.path as the prefix
 // <bucket>/<d.path>/<everything> including <bucket>/<d.path>/<some dir>/<objects>
 func (d *directory) DeleteDirectory(ctx context.Context) error {
@@ -166,10 +220,58 @@ func (d *directory) Get(ctx context.Context, name string) (io.ReadCloser, map[st
 	if err != nil {
 		return nil, nil, err
 	}
-	rTags, err := item.Metadata()
+	tags, err := itemTags(item)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return r, tags, nil
+}
+
+// GetRange returns a reader for length bytes of the object
+// <bucket>/<d.path>/name from offset, and the object tags.
+func (d *directory) GetRange(ctx context.Context, name string, offset, length int64) (io.ReadCloser, map[string]string, error) {
+	if d.path == "" {
+		return nil, nil, errors.New("invalid entry")
+	}
+	if offset < 0 {
+		return nil, nil, errors.Errorf("invalid offset %d", offset)
+	}
+
+	objName := cloudName(d.absPathName(name))
+
+	item, err := d.bucket.container.Item(objName)
+	if err != nil {
+		return nil, nil, err
+	}
+	tags, err := itemTags(item)
+	if err != nil {
+		return nil, nil, err
+	}
+	size, err := item.Size()
+	if err != nil {
+		return nil, nil, err
+	}
+	end := size
+	if length >= 0 && offset+length < size {
+		end = offset + length
+	}
+	if offset >= end {
+		return ioutil.NopCloser(bytes.NewReader(nil)), tags, nil
+	}
+	r, err := d.bucket.getRange(ctx, item, offset, end-offset)
 	if err != nil {
 		return nil, nil, err
 	}
+	return r, tags, nil
+}
+
+// itemTags converts the metadata of an item into tags
+func itemTags(item stow.Item) (map[string]string, error) {
+	rTags, err := item.Metadata()
+	if err != nil {
+		return nil, err
+	}
 
 	// Convert tags:map[string]interface{} into map[string]string
 	tags := make(map[string]string)
@@ -178,8 +280,7 @@ func (d *directory) Get(ctx context.Context, name string) (io.ReadCloser, map[st
 			tags[key] = sVal
 		}
 	}
-
-	return r, tags, nil
+	return tags, nil
 }
 
 // Get data and tags associated with an object <bucket>/<d.path>/name.
@@ -198,24 +299,26 @@ func (d *directory) GetBytes(ctx context.Context, name string) ([]byte, map[stri
 	return data, tags, nil
 }
 
-func (d *directory) Put(ctx context.Context, name string, r io.Reader, size int64, tags map[string]string) error {
+// Put stores the data read from r in d.path/<name> and returns the version
+// ID of the new object in versioned buckets
+func (d *directory) Put(ctx context.Context, name string, r io.Reader, size int64, tags map[string]string) (string, error) {
 	if d.path == "" {
-		return errors.New("invalid entry")
+		return "", errors.New("invalid entry")
 	}
 	// K10 tags include '/'. Remove them, at least for S3
 	sTags := sanitizeTags(tags)
 
 	objName := d.absPathName(name)
 
-	// For versioned buckets, Put can return the new version name
-	// TODO: Support versioned buckets
-	_, err := d.bucket.container.Put(cloudName(objName), r, size, sTags)
-	return err
+	// Stow can neither request server-side encryption or retention nor
+	// return the version ID
+	return d.bucket.putMultipart(ctx, cloudName(objName), r, size, stringTags(sTags), UploadOptions{})
 }
 
-// Put stores a blob in d.path/<name>
+// PutBytes stores a blob in d.path/<name>
 func (d *directory) PutBytes(ctx context.Context, name string, data []byte, tags map[string]string) error {
-	return d.Put(ctx, name, bytes.NewReader(data), int64(len(data)), tags)
+	_, err := d.Put(ctx, name, bytes.NewReader(data), int64(len(data)), tags)
+	return err
 }
 
 // Delete removes an object
@@ -231,7 +334,17 @@ func (d *directory) Delete(ctx context.Context, name string) error {
 
 // If name does not start with '/', prefix with d.path. Add '/' as suffix
 func (d *directory) absDirName(dir string) string {
-	dir = d.absPathName(dir)
+	return absDirName(d.path, dir)
+}
+
+// If name does not start with '/', prefix with d.path.
+func (d *directory) absPathName(name string) string {
+	return absPathName(d.path, name)
+}
+
+// If dir does not start with '/', prefix with base. Add '/' as suffix
+func absDirName(base, dir string) string {
+	dir = absPathName(base, dir)
 
 	// End with a '/'
 	if !strings.HasSuffix(dir, "/") {
@@ -250,13 +363,13 @@ func cloudName(dir string) string {
 	return strings.TrimPrefix(dir, "/")
 }
 
-// If name does not start with '/', prefix with d.path.
-func (d *directory) absPathName(name string) string {
+// If name does not start with '/', prefix with base.
+func absPathName(base, name string) string {
 	if name == "" {
 		return ""
 	}
 	if !filepath.IsAbs(name) {
-		name = d.path + name
+		name = base + name
 	}
 
 	return name
@@ -291,3 +404,10 @@ func isDirectoryObject(path string) (string, bool) {
 	// Not reached
 	return "", false
 }
+
+// IsObjectNotFoundError returns true if the error is returned by Get for an
+// object that does not exist
+func IsObjectNotFoundError(err error) bool {
+	cause := errors.Cause(err)
+	return cause == stow.ErrNotFound || os.IsNotExist(cause)
+}
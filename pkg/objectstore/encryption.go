@@ -0,0 +1,327 @@
+package objectstore
+
+// Server-side encryption of objects. Stow does not expose the encryption
+// options of the providers, so encrypted objects are written and inspected
+// with the provider SDKs directly.
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	azstorage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	stowgcs "github.com/graymeta/stow/google"
+	stows3 "github.com/graymeta/stow/s3"
+	"github.com/pkg/errors"
+)
+
+const (
+	azureEncryptionScopeHeader        = "x-ms-encryption-scope"
+	azureDefaultEncryptionScopeHeader = "x-ms-default-encryption-scope"
+	// Oldest version of the blob service API that supports encryption
+	// scopes, blob versions and version-level immutability
+	azureAPIVersion = "2020-10-02"
+	azureSASExpiry  = time.Hour
+	// azureErrorCodeHeader is set on failed requests, which have no body
+	// with the error details for HEAD requests
+	azureErrorCodeHeader = "x-ms-error-code"
+)
+
+// IsZero returns true if no server-side encryption is configured
+func (sse ServerSideEncryption) IsZero() bool {
+	return sse == ServerSideEncryption{}
+}
+
+// CheckServerSideEncryption returns an error if the named object in the
+// directory is not encrypted with the server-side encryption configured for
+// its provider.
+func CheckServerSideEncryption(ctx context.Context, dir Directory, name string) error {
+	d, ok := stowDirectory(dir)
+	if !ok {
+		return errors.New("server-side encryption is not supported by the object store")
+	}
+	sse := d.bucket.config.Encryption
+	if sse.IsZero() {
+		return nil
+	}
+	objName := cloudName(d.absPathName(name))
+	switch d.bucket.config.Type {
+	case ProviderTypeS3:
+		return d.bucket.checkS3Encryption(ctx, objName, sse)
+	case ProviderTypeGCS:
+		return d.bucket.checkGCSEncryption(ctx, objName, sse)
+	case ProviderTypeAzure:
+		return d.bucket.checkAzureEncryption(ctx, objName, sse)
+	default:
+		return errors.Errorf("server-side encryption is not supported by object store type %s", d.bucket.config.Type)
+	}
+}
+
+// CheckBucketEncryption returns an error if the default encryption of the
+// bucket does not match the server-side encryption configured for its
+// provider. Objects written by tools that cannot request encryption
+// themselves, such as restic, rely on the bucket default.
+func CheckBucketEncryption(ctx context.Context, bkt Bucket) error {
+	b, ok := bkt.(*bucket)
+	if !ok {
+		return errors.New("server-side encryption is not supported by the object store")
+	}
+	sse := b.config.Encryption
+	if sse.IsZero() {
+		return nil
+	}
+	switch b.config.Type {
+	case ProviderTypeS3:
+		return b.checkS3BucketEncryption(ctx, sse)
+	case ProviderTypeGCS:
+		return b.checkGCSBucketEncryption(ctx, sse)
+	case ProviderTypeAzure:
+		return b.checkAzureBucketEncryption(ctx, sse)
+	default:
+		return errors.Errorf("server-side encryption is not supported by object store type %s", b.config.Type)
+	}
+}
+
+func (b *bucket) s3Client() (*s3.S3, error) {
+	var region string
+	if rc, ok := b.container.(interface{ Region() string }); ok {
+		region = rc.Region()
+	}
+	_, cfg, err := s3Config(b.config, b.secret, region)
+	if err != nil {
+		return nil, err
+	}
+	accessKeyID, _ := cfg.Config(stows3.ConfigAccessKeyID)
+	secretKey, _ := cfg.Config(stows3.ConfigSecretKey)
+	awsConfig := aws.NewConfig().
+		WithCredentials(credentials.NewStaticCredentials(accessKeyID, secretKey, "")).
+		WithRegion("us-east-1")
+	if region != "" {
+		awsConfig.WithRegion(region)
+	}
+	if b.config.Endpoint != "" {
+		awsConfig.WithEndpoint(b.config.Endpoint).WithS3ForcePathStyle(true)
+	}
+	if b.hc != nil {
+		awsConfig.WithHTTPClient(b.hc)
+	}
+	s, err := session.NewSession(awsConfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create S3 session")
+	}
+	return s3.New(s), nil
+}
+
+func (b *bucket) checkS3Encryption(ctx context.Context, objName string, sse ServerSideEncryption) error {
+	cli, err := b.s3Client()
+	if err != nil {
+		return err
+	}
+	out, err := cli.HeadObjectWithContext(ctx, &s3.HeadObjectInput{
+		Bucket: aws.String(b.container.Name()),
+		Key:    aws.String(objName),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get object %s", objName)
+	}
+	got := aws.StringValue(out.ServerSideEncryption)
+	if got == "" {
+		return errors.Errorf("object %s is not encrypted", objName)
+	}
+	if sse.Type != "" && got != string(sse.Type) {
+		return errors.Errorf("object %s is encrypted with %s instead of %s", objName, got, sse.Type)
+	}
+	if sse.KMSKeyID != "" && !strings.HasSuffix(aws.StringValue(out.SSEKMSKeyId), sse.KMSKeyID) {
+		return errors.Errorf("object %s is not encrypted with KMS key %s", objName, sse.KMSKeyID)
+	}
+	return nil
+}
+
+func (b *bucket) checkS3BucketEncryption(ctx context.Context, sse ServerSideEncryption) error {
+	cli, err := b.s3Client()
+	if err != nil {
+		return err
+	}
+	out, err := cli.GetBucketEncryptionWithContext(ctx, &s3.GetBucketEncryptionInput{
+		Bucket: aws.String(b.container.Name()),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get default encryption of bucket %s", b.container.Name())
+	}
+	if out.ServerSideEncryptionConfiguration != nil {
+		for _, rule := range out.ServerSideEncryptionConfiguration.Rules {
+			def := rule.ApplyServerSideEncryptionByDefault
+			if def == nil {
+				continue
+			}
+			if sse.Type != "" && aws.StringValue(def.SSEAlgorithm) != string(sse.Type) {
+				continue
+			}
+			if sse.KMSKeyID != "" && !strings.HasSuffix(aws.StringValue(def.KMSMasterKeyID), sse.KMSKeyID) {
+				continue
+			}
+			return nil
+		}
+	}
+	return errors.Errorf("default encryption of bucket %s does not match the profile", b.container.Name())
+}
+
+func (b *bucket) gcsLocation() (*stowgcs.Location, error) {
+	l, ok := b.location.(*stowgcs.Location)
+	if !ok {
+		return nil, errors.New("unexpected GCS location")
+	}
+	return l, nil
+}
+
+func (b *bucket) checkGCSEncryption(ctx context.Context, objName string, sse ServerSideEncryption) error {
+	l, err := b.gcsLocation()
+	if err != nil {
+		return err
+	}
+	object, err := l.Service().Objects.Get(b.container.Name(), objName).Context(ctx).Do()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get object %s", objName)
+	}
+	// The key name of the object includes the key version
+	if object.KmsKeyName == "" || !strings.HasPrefix(object.KmsKeyName, sse.KMSKeyID) {
+		return errors.Errorf("object %s is not encrypted with KMS key %s", objName, sse.KMSKeyID)
+	}
+	return nil
+}
+
+func (b *bucket) checkGCSBucketEncryption(ctx context.Context, sse ServerSideEncryption) error {
+	l, err := b.gcsLocation()
+	if err != nil {
+		return err
+	}
+	bkt, err := l.Service().Buckets.Get(b.container.Name()).Context(ctx).Do()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get bucket %s", b.container.Name())
+	}
+	if bkt.Encryption == nil || bkt.Encryption.DefaultKmsKeyName != sse.KMSKeyID {
+		return errors.Errorf("default KMS key of bucket %s is not %s", b.container.Name(), sse.KMSKeyID)
+	}
+	return nil
+}
+
+// azureBlobURI returns a short lived SAS URI for the named blob. Requests
+// authenticated with SAS can carry headers that the storage client does
+// not support, such as the encryption scope.
+func (b *bucket) azureBlobURI(objName string, perms azstorage.BlobServiceSASPermissions) (string, error) {
+	c, err := b.azureContainer()
+	if err != nil {
+		return "", err
+	}
+	return c.GetBlobReference(objName).GetSASURI(azstorage.BlobSASOptions{
+		BlobServiceSASPermissions: perms,
+		SASOptions:                azureSASOptions(),
+	})
+}
+
+func (b *bucket) azureContainer() (*azstorage.Container, error) {
+	_, cfg, err := azureConfig(context.Background(), b.secret)
+	if err != nil {
+		return nil, err
+	}
+	bsc, err := newAzureBlobClient(cfg, b.hc)
+	if err != nil {
+		return nil, err
+	}
+	return bsc.GetContainerReference(b.container.Name()), nil
+}
+
+func azureSASOptions() azstorage.SASOptions {
+	return azstorage.SASOptions{
+		Expiry:   time.Now().Add(azureSASExpiry),
+		UseHTTPS: true,
+	}
+}
+
+// azureHead sends a request without a body, authenticated with a SAS URI,
+// and returns the response headers
+func (b *bucket) azureHead(ctx context.Context, method, uri string) (http.Header, error) {
+	req, err := http.NewRequest(method, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	resp, err := orDefaultClient(b.hc).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, azureResponseError(resp)
+	}
+	return resp.Header, nil
+}
+
+// azureResponseError returns the status of a failed request with the error
+// code and message that Azure sends in the response body, or only the code
+// from the headers if there is no body
+func azureResponseError(resp *http.Response) error {
+	var body struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	data, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err := xml.Unmarshal(data, &body); err != nil || body.Code == "" {
+		body.Code = resp.Header.Get(azureErrorCodeHeader)
+	}
+	msg := resp.Status
+	if body.Code != "" {
+		msg += ": " + body.Code
+	}
+	if m := strings.Join(strings.Fields(body.Message), " "); m != "" {
+		msg += ": " + m
+	}
+	return errors.New(msg)
+}
+
+func (b *bucket) checkAzureBucketEncryption(ctx context.Context, sse ServerSideEncryption) error {
+	c, err := b.azureContainer()
+	if err != nil {
+		return err
+	}
+	uri, err := c.GetSASURI(azstorage.ContainerSASOptions{
+		ContainerSASPermissions: azstorage.ContainerSASPermissions{
+			BlobServiceSASPermissions: azstorage.BlobServiceSASPermissions{Read: true},
+		},
+		SASOptions: azureSASOptions(),
+	})
+	if err != nil {
+		return err
+	}
+	h, err := b.azureHead(ctx, http.MethodGet, uri+"&restype=container")
+	if err != nil {
+		return errors.Wrapf(err, "failed to get properties of container %s", b.container.Name())
+	}
+	if got := h.Get(azureDefaultEncryptionScopeHeader); got != sse.EncryptionScope {
+		return errors.Errorf("default encryption scope of container %s is '%s' instead of '%s'", b.container.Name(), got, sse.EncryptionScope)
+	}
+	return nil
+}
+
+func (b *bucket) checkAzureEncryption(ctx context.Context, objName string, sse ServerSideEncryption) error {
+	uri, err := b.azureBlobURI(objName, azstorage.BlobServiceSASPermissions{Read: true})
+	if err != nil {
+		return err
+	}
+	h, err := b.azureHead(ctx, http.MethodHead, uri)
+	if err != nil {
+		return errors.Wrapf(err, "failed to get object %s", objName)
+	}
+	if got := h.Get(azureEncryptionScopeHeader); got != sse.EncryptionScope {
+		return errors.Errorf("object %s is encrypted with scope '%s' instead of '%s'", objName, got, sse.EncryptionScope)
+	}
+	return nil
+}
@@ -0,0 +1,682 @@
+package objectstore
+
+// Buckets and directories on a local, network or remote file system
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// Tags of an object are stored in a sidecar file next to it
+	fsTagsSuffix = ".kanister-tags"
+	// Objects are written to a temporary file and renamed when complete
+	fsTempSuffix = ".kanister-tmp"
+)
+
+// fileSystem is the set of file operations needed to store objects. Paths
+// are absolute and use '/' as the separator.
+type fileSystem interface {
+	Stat(name string) (os.FileInfo, error)
+	ReadDir(name string) ([]os.FileInfo, error)
+	Mkdir(name string) error
+	MkdirAll(name string) error
+	Open(name string) (io.ReadCloser, error)
+	Create(name string) (io.WriteCloser, error)
+	Rename(oldname, newname string) error
+	Remove(name string) error
+	RemoveAll(name string) error
+	// Close releases any connection held by the file system
+	Close() error
+}
+
+// fileSystemDialer returns a connected fileSystem. The caller must Close it.
+type fileSystemDialer func() (fileSystem, error)
+
+var _ Provider = (*fsProvider)(nil)
+
+// fsProvider implements the Provider functionality over a file system. Each
+// bucket is a directory under root.
+type fsProvider struct {
+	root         string
+	hostEndPoint string // Prepended to the bucket paths in String()
+	dial         fileSystemDialer
+}
+
+var _ Directory = (*fsDirectory)(nil)
+
+// fsDirectory implements the Directory functionality over a file system.
+// The bucket itself is the fsDirectory with path '/'.
+type fsDirectory struct {
+	dial         fileSystemDialer
+	hostEndPoint string // Prepended to the bucket path in String()
+	bucketPath   string // Absolute path of the bucket on the file system
+	path         string // Starts (and if needed, ends) with a '/'
+}
+
+func newFileSystemProvider(config ProviderConfig) (Provider, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("root directory for file system provider not specified")
+	}
+	return &fsProvider{
+		root: path.Clean(config.Endpoint),
+		dial: func() (fileSystem, error) { return localFileSystem{}, nil },
+	}, nil
+}
+
+// CreateBucket creates a new directory under the root. The region is ignored.
+func (p *fsProvider) CreateBucket(ctx context.Context, bucketName, region string) (Bucket, error) {
+	fs, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	bp, err := p.bucketPath(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.Mkdir(bp); err != nil {
+		return nil, errors.Wrapf(err, "failed to create bucket %s", bucketName)
+	}
+	return p.newBucket(bp), nil
+}
+
+// GetBucket gets the handle for an existing bucket directory
+func (p *fsProvider) GetBucket(ctx context.Context, bucketName string) (Bucket, error) {
+	fs, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	bp, err := p.bucketPath(bucketName)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fs.Stat(bp)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get bucket %s", bucketName)
+	}
+	if !fi.IsDir() {
+		return nil, errors.Errorf("failed to get bucket %s: not a directory", bucketName)
+	}
+	return p.newBucket(bp), nil
+}
+
+// ListBuckets gets the handles of all the directories under the root.
+func (p *fsProvider) ListBuckets(ctx context.Context) (map[string]Bucket, error) {
+	fs, err := p.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	fis, err := fs.ReadDir(p.root)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to list buckets in %s", p.root)
+	}
+	buckets := make(map[string]Bucket)
+	for _, fi := range fis {
+		if fi.IsDir() {
+			buckets[fi.Name()] = p.newBucket(path.Join(p.root, fi.Name()))
+		}
+	}
+	return buckets, nil
+}
+
+// DeleteBucket removes the bucket directory. For safety, it does not delete
+// buckets with contents.
+func (p *fsProvider) DeleteBucket(ctx context.Context, bucketName string) error {
+	fs, err := p.dial()
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+	bp, err := p.bucketPath(bucketName)
+	if err != nil {
+		return err
+	}
+	return fs.Remove(bp)
+}
+
+// bucketPath returns the directory of the bucket. Bucket names are single
+// path elements, so that buckets stay under the root.
+func (p *fsProvider) bucketPath(bucketName string) (string, error) {
+	if bucketName == "" || bucketName == "." || bucketName == ".." || strings.Contains(bucketName, "/") {
+		return "", errors.Errorf("invalid bucket name '%s'", bucketName)
+	}
+	return path.Join(p.root, bucketName), nil
+}
+
+func (p *fsProvider) getOrCreateBucket(ctx context.Context, bucketName, region string) (Bucket, error) {
+	d, err := p.GetBucket(ctx, bucketName)
+	if err == nil {
+		return d, nil
+	}
+	return p.CreateBucket(ctx, bucketName, region)
+}
+
+func (p *fsProvider) newBucket(bucketPath string) *fsDirectory {
+	return &fsDirectory{
+		dial:         p.dial,
+		hostEndPoint: p.hostEndPoint,
+		bucketPath:   bucketPath,
+		path:         "/",
+	}
+}
+
+// String creates a string representation of the directory
+func (d *fsDirectory) String() string {
+	return d.hostEndPoint + d.bucketPath + d.path
+}
+
+// CreateDirectory creates the d.path/dir/ directory and any missing parents.
+func (d *fsDirectory) CreateDirectory(ctx context.Context, dir string) (Directory, error) {
+	fs, err := d.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	dir = absDirName(d.path, dir)
+	dirPath, err := d.fsPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	if err := fs.MkdirAll(dirPath); err != nil {
+		return nil, errors.Wrapf(err, "could not create directory %s", dir)
+	}
+	return d.subDirectory(dir), nil
+}
+
+// GetDirectory gets the directory object
+func (d *fsDirectory) GetDirectory(ctx context.Context, dir string) (Directory, error) {
+	if dir == "" {
+		return d, nil
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	dir = absDirName(d.path, dir)
+	dirPath, err := d.fsPath(dir)
+	if err != nil {
+		return nil, err
+	}
+	fi, err := fs.Stat(dirPath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get directory %s", dir)
+	}
+	if !fi.IsDir() {
+		return nil, errors.Errorf("could not get directory %s: not a directory", dir)
+	}
+	return d.subDirectory(dir), nil
+}
+
+// ListDirectories lists the sub directories of d.path, indexed by their
+// relative name.
+func (d *fsDirectory) ListDirectories(ctx context.Context) (map[string]Directory, error) {
+	if d.path == "" {
+		return nil, errors.New("invalid entry")
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	dirPath, err := d.fsPath(d.path)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := fs.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	directories := make(map[string]Directory)
+	for _, fi := range fis {
+		if fi.IsDir() {
+			directories[fi.Name()] = d.subDirectory(absDirName(d.path, fi.Name()))
+		}
+	}
+	return directories, nil
+}
+
+// ListObjects lists the objects directly under d.path.
+func (d *fsDirectory) ListObjects(ctx context.Context) ([]string, error) {
+	if d.path == "" {
+		return nil, errors.New("invalid entry")
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return nil, err
+	}
+	defer fs.Close()
+	dirPath, err := d.fsPath(d.path)
+	if err != nil {
+		return nil, err
+	}
+	fis, err := fs.ReadDir(dirPath)
+	if err != nil {
+		return nil, err
+	}
+	objects := make([]string, 0, len(fis))
+	for _, fi := range fis {
+		if !fi.IsDir() && !isFSInternalFile(fi.Name()) {
+			objects = append(objects, fi.Name())
+		}
+	}
+	return objects, nil
+}
+
+// ListObjectsPage returns a page of the objects under d.path. Objects are
+// listed in the order of their path elements and the cursor is the name
+// of the last object of the page.
+func (d *fsDirectory) ListObjectsPage(ctx context.Context, opts ListOptions) ([]ObjectInfo, string, error) {
+	if d.path == "" {
+		return nil, "", errors.New("invalid entry")
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return nil, "", err
+	}
+	defer fs.Close()
+	l := &fsLister{d: d, fs: fs, opts: opts}
+	if err := l.walk(""); err != nil && err != errPageFull {
+		return nil, "", err
+	}
+	var cursor string
+	if len(l.objects) == opts.pageSize() {
+		cursor = l.objects[len(l.objects)-1].Name
+	}
+	return l.objects, cursor, nil
+}
+
+var errPageFull = errors.New("page full")
+
+type fsLister struct {
+	d       *fsDirectory
+	fs      fileSystem
+	opts    ListOptions
+	objects []ObjectInfo
+}
+
+// walk adds the objects after the cursor in dir, relative to d.path, until
+// the page is full
+func (l *fsLister) walk(dir string) error {
+	dirPath, err := l.d.fsPath(l.d.path + dir)
+	if err != nil {
+		return err
+	}
+	fis, err := l.fs.ReadDir(dirPath)
+	if err != nil {
+		return err
+	}
+	for _, fi := range fis {
+		name := path.Join(dir, fi.Name())
+		if fi.IsDir() {
+			if !l.opts.Recursive || l.beforeCursor(name) {
+				continue
+			}
+			if err := l.walk(name); err != nil {
+				return err
+			}
+			continue
+		}
+		if isFSInternalFile(fi.Name()) || (l.opts.Cursor != "" && compareObjectNames(name, l.opts.Cursor) <= 0) {
+			continue
+		}
+		info := ObjectInfo{Name: name, Size: fi.Size(), LastModified: fi.ModTime()}
+		if l.opts.Tags {
+			objPath, err := l.d.fsPath(absPathName(l.d.path, name))
+			if err != nil {
+				return err
+			}
+			if info.Tags, err = readFSTags(l.fs, objPath); err != nil {
+				return err
+			}
+		}
+		l.objects = append(l.objects, info)
+		if len(l.objects) == l.opts.pageSize() {
+			return errPageFull
+		}
+	}
+	return nil
+}
+
+// beforeCursor returns true if all the objects in dir were listed before
+// the cursor
+func (l *fsLister) beforeCursor(dir string) bool {
+	c := l.opts.Cursor
+	return c != "" && !strings.HasPrefix(c, dir+"/") && compareObjectNames(dir, c) < 0
+}
+
+// DeleteDirectory deletes d.path and everything under it. Deleting the bucket
+// root only removes its contents.
+func (d *fsDirectory) DeleteDirectory(ctx context.Context) error {
+	if d.path == "" {
+		return errors.New("invalid entry")
+	}
+	return d.deleteWithPrefix(cloudName(d.path))
+}
+
+// DeleteAllWithPrefix deletes all directories and objects that have d.path/prefix
+// as the prefix of their name.
+func (d *fsDirectory) DeleteAllWithPrefix(ctx context.Context, prefix string) error {
+	return d.deleteWithPrefix(cloudName(path.Join(d.path, prefix)))
+}
+
+// deleteWithPrefix mirrors object store prefix semantics: a prefix of
+// "a/b" matches "a/b", "a/b/c" and "a/bc".
+func (d *fsDirectory) deleteWithPrefix(prefix string) error {
+	fs, err := d.dial()
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+	dir, base := path.Split(prefix)
+	p, err := d.fsPath(dir)
+	if err != nil {
+		return err
+	}
+	fis, err := fs.ReadDir(p)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "Failed to delete item %s", prefix)
+	}
+	for _, fi := range fis {
+		if strings.HasPrefix(fi.Name(), base) {
+			if err := fs.RemoveAll(path.Join(p, fi.Name())); err != nil {
+				return errors.Wrapf(err, "Failed to delete item %s", prefix)
+			}
+		}
+	}
+	if base == "" && dir != "" {
+		// The prefix is a directory, remove it as well
+		if err := fs.Remove(p); err != nil && !os.IsNotExist(err) {
+			return errors.Wrapf(err, "Failed to delete item %s", prefix)
+		}
+	}
+	return nil
+}
+
+// Get returns a reader for the object <bucket>/<d.path>/name and its tags.
+func (d *fsDirectory) Get(ctx context.Context, name string) (io.ReadCloser, map[string]string, error) {
+	if d.path == "" {
+		return nil, nil, errors.New("invalid entry")
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return nil, nil, err
+	}
+	objPath, err := d.fsPath(absPathName(d.path, name))
+	if err != nil {
+		fs.Close()
+		return nil, nil, err
+	}
+	// Directories are not objects
+	if fi, err := fs.Stat(objPath); err == nil && fi.IsDir() {
+		fs.Close()
+		return nil, nil, &os.PathError{Op: "open", Path: objPath, Err: os.ErrNotExist}
+	}
+	tags, err := readFSTags(fs, objPath)
+	if err != nil {
+		fs.Close()
+		return nil, nil, err
+	}
+	r, err := fs.Open(objPath)
+	if err != nil {
+		fs.Close()
+		return nil, nil, err
+	}
+	return &fsReadCloser{ReadCloser: r, fs: fs}, tags, nil
+}
+
+// GetRange returns a reader for length bytes of the object
+// <bucket>/<d.path>/name from offset, and the object tags.
+func (d *fsDirectory) GetRange(ctx context.Context, name string, offset, length int64) (io.ReadCloser, map[string]string, error) {
+	if offset < 0 {
+		return nil, nil, errors.Errorf("invalid offset %d", offset)
+	}
+	r, tags, err := d.Get(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if s, ok := r.(*fsReadCloser).ReadCloser.(io.Seeker); ok {
+		_, err = s.Seek(offset, io.SeekStart)
+	} else {
+		_, err = io.CopyN(ioutil.Discard, r, offset)
+		if err == io.EOF {
+			err = nil
+		}
+	}
+	if err != nil {
+		r.Close()
+		return nil, nil, err
+	}
+	if length < 0 {
+		return r, tags, nil
+	}
+	return readCloser{Reader: io.LimitReader(r, length), Closer: r}, tags, nil
+}
+
+// GetBytes returns data and tags associated with an object <bucket>/<d.path>/name.
+func (d *fsDirectory) GetBytes(ctx context.Context, name string) ([]byte, map[string]string, error) {
+	r, tags, err := d.Get(ctx, name)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer r.Close()
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return data, tags, nil
+}
+
+// Put writes the object to a temporary file and moves it into place once all
+// of the data has been written, so readers never see a partial object. Files
+// have no versions, so the version ID is always empty.
+func (d *fsDirectory) Put(ctx context.Context, name string, r io.Reader, size int64, tags map[string]string) (string, error) {
+	return "", d.put(ctx, name, r, tags)
+}
+
+func (d *fsDirectory) put(ctx context.Context, name string, r io.Reader, tags map[string]string) error {
+	if d.path == "" {
+		return errors.New("invalid entry")
+	}
+	if isFSInternalFile(name) {
+		return errors.Errorf("invalid object name %s", name)
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+	objPath, err := d.fsPath(absPathName(d.path, name))
+	if err != nil {
+		return err
+	}
+	if err := fs.MkdirAll(path.Dir(objPath)); err != nil {
+		return err
+	}
+	tmpPath, err := tempName(objPath)
+	if err != nil {
+		return err
+	}
+	w, err := fs.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	defer fs.Remove(tmpPath)
+	if _, err := io.Copy(w, r); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	if err := writeFSTags(fs, objPath, tags); err != nil {
+		return err
+	}
+	return fs.Rename(tmpPath, objPath)
+}
+
+// PutBytes stores a blob in d.path/<name>
+func (d *fsDirectory) PutBytes(ctx context.Context, name string, data []byte, tags map[string]string) error {
+	return d.put(ctx, name, bytes.NewReader(data), tags)
+}
+
+// Delete removes an object and its tags
+func (d *fsDirectory) Delete(ctx context.Context, name string) error {
+	if d.path == "" {
+		return errors.New("invalid entry")
+	}
+	fs, err := d.dial()
+	if err != nil {
+		return err
+	}
+	defer fs.Close()
+	objPath, err := d.fsPath(absPathName(d.path, name))
+	if err != nil {
+		return err
+	}
+	if err := fs.Remove(objPath); err != nil {
+		return err
+	}
+	if err := fs.Remove(objPath + fsTagsSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// GetVersion is like Get. Files have no versions other than the latest,
+// which is read with an empty version ID.
+func (d *fsDirectory) GetVersion(ctx context.Context, name, versionID string) (io.ReadCloser, map[string]string, error) {
+	if versionID != "" {
+		return nil, nil, errors.New("object versions are not supported by the filesystem object store")
+	}
+	return d.Get(ctx, name)
+}
+
+// DeleteVersion is like Delete. Files have no versions other than the
+// latest, which is removed with an empty version ID.
+func (d *fsDirectory) DeleteVersion(ctx context.Context, name, versionID string) error {
+	if versionID != "" {
+		return errors.New("object versions are not supported by the filesystem object store")
+	}
+	return d.Delete(ctx, name)
+}
+
+func (d *fsDirectory) subDirectory(dir string) *fsDirectory {
+	return &fsDirectory{
+		dial:         d.dial,
+		hostEndPoint: d.hostEndPoint,
+		bucketPath:   d.bucketPath,
+		path:         dir,
+	}
+}
+
+// fsPath returns the file system path of the object or directory named p.
+// Names with '..' elements that resolve outside of the bucket are rejected.
+func (d *fsDirectory) fsPath(p string) (string, error) {
+	fp := path.Join(d.bucketPath, cloudName(p))
+	if fp != d.bucketPath && !strings.HasPrefix(fp, d.bucketPath+"/") {
+		return "", errors.Errorf("invalid name '%s': outside of the bucket", p)
+	}
+	return fp, nil
+}
+
+// fsReadCloser closes the file system once the object has been read
+type fsReadCloser struct {
+	io.ReadCloser
+	fs fileSystem
+}
+
+func (r *fsReadCloser) Close() error {
+	err := r.ReadCloser.Close()
+	if cErr := r.fs.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
+
+func readFSTags(fs fileSystem, objPath string) (map[string]string, error) {
+	r, err := fs.Open(objPath + fsTagsSuffix)
+	switch {
+	case os.IsNotExist(err):
+		return map[string]string{}, nil
+	case err != nil:
+		return nil, err
+	}
+	defer r.Close()
+	tags := make(map[string]string)
+	if err := json.NewDecoder(r).Decode(&tags); err != nil {
+		return nil, errors.Wrapf(err, "failed to read tags for %s", objPath)
+	}
+	return tags, nil
+}
+
+func writeFSTags(fs fileSystem, objPath string, tags map[string]string) error {
+	tp := objPath + fsTagsSuffix
+	if len(tags) == 0 {
+		if err := fs.Remove(tp); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+	buf, err := json.Marshal(tags)
+	if err != nil {
+		return errors.Wrapf(err, "failed to write tags for %s", objPath)
+	}
+	w, err := fs.Create(tp)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(buf); err != nil {
+		w.Close()
+		return err
+	}
+	return w.Close()
+}
+
+// tempName returns a unique temporary name next to objPath
+func tempName(objPath string) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return objPath + "." + hex.EncodeToString(b) + fsTempSuffix, nil
+}
+
+// isFSInternalFile returns true for files used to implement objects that are
+// not objects themselves
+func isFSInternalFile(name string) bool {
+	return strings.HasSuffix(name, fsTagsSuffix) || strings.HasSuffix(name, fsTempSuffix)
+}
+
+var _ fileSystem = localFileSystem{}
+
+// localFileSystem is a fileSystem mounted in the current process
+type localFileSystem struct{}
+
+func (localFileSystem) Stat(name string) (os.FileInfo, error)      { return os.Stat(name) }
+func (localFileSystem) ReadDir(name string) ([]os.FileInfo, error) { return ioutil.ReadDir(name) }
+func (localFileSystem) Mkdir(name string) error                    { return os.Mkdir(name, 0755) }
+func (localFileSystem) MkdirAll(name string) error                 { return os.MkdirAll(name, 0755) }
+func (localFileSystem) Open(name string) (io.ReadCloser, error)    { return os.Open(name) }
+func (localFileSystem) Create(name string) (io.WriteCloser, error) { return os.Create(name) }
+func (localFileSystem) Rename(oldname, newname string) error       { return os.Rename(oldname, newname) }
+func (localFileSystem) Remove(name string) error                   { return os.Remove(name) }
+func (localFileSystem) RemoveAll(name string) error                { return os.RemoveAll(name) }
+func (localFileSystem) Close() error                               { return nil }
@@ -0,0 +1,212 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type FileSystemSuite struct {
+	root     string
+	provider Provider
+	bucket   Bucket
+}
+
+var _ = Suite(&FileSystemSuite{})
+
+func (s *FileSystemSuite) SetUpTest(c *C) {
+	ctx := context.Background()
+	s.root = c.MkDir()
+	var err error
+	s.provider, err = NewProvider(ctx, ProviderConfig{Type: ProviderTypeFileSystem, Endpoint: s.root}, nil)
+	c.Assert(err, IsNil)
+	s.bucket, err = GetOrCreateBucket(ctx, s.provider, testBucketName, "")
+	c.Assert(err, IsNil)
+}
+
+func (s *FileSystemSuite) TestBuckets(c *C) {
+	ctx := context.Background()
+	_, err := s.provider.CreateBucket(ctx, testBucketName, "")
+	c.Assert(err, NotNil)
+
+	b, err := s.provider.CreateBucket(ctx, "other-bucket", "")
+	c.Assert(err, IsNil)
+	buckets, err := s.provider.ListBuckets(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(buckets, HasLen, 2)
+
+	// Buckets with contents are not deleted
+	err = b.PutBytes(ctx, "object", []byte("content"), nil)
+	c.Assert(err, IsNil)
+	err = s.provider.DeleteBucket(ctx, "other-bucket")
+	c.Assert(err, NotNil)
+
+	err = b.DeleteDirectory(ctx)
+	c.Assert(err, IsNil)
+	err = s.provider.DeleteBucket(ctx, "other-bucket")
+	c.Assert(err, IsNil)
+	_, err = s.provider.GetBucket(ctx, "other-bucket")
+	c.Assert(err, NotNil)
+}
+
+func (s *FileSystemSuite) TestDirectories(c *C) {
+	ctx := context.Background()
+	d1, err := s.bucket.CreateDirectory(ctx, "dir1")
+	c.Assert(err, IsNil)
+	d2, err := d1.CreateDirectory(ctx, "dir2")
+	c.Assert(err, IsNil)
+	err = d1.PutBytes(ctx, "obj1", []byte("data"), nil)
+	c.Assert(err, IsNil)
+
+	ds, err := s.bucket.ListDirectories(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(ds, HasLen, 1)
+	_, ok := ds["dir1"]
+	c.Assert(ok, Equals, true)
+
+	d, err := s.bucket.GetDirectory(ctx, "dir1/dir2")
+	c.Assert(err, IsNil)
+	c.Assert(d.String(), Equals, d2.String())
+
+	_, err = s.bucket.GetDirectory(ctx, "dir1/obj1")
+	c.Assert(err, NotNil)
+
+	err = d2.DeleteDirectory(ctx)
+	c.Assert(err, IsNil)
+	_, err = d1.GetDirectory(ctx, "dir2")
+	c.Assert(err, NotNil)
+	obs, err := d1.ListObjects(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(obs, DeepEquals, []string{"obj1"})
+}
+
+func (s *FileSystemSuite) TestDeleteAllWithPrefix(c *C) {
+	ctx := context.Background()
+	for _, o := range []string{"dir1/obj", "dir1x/obj", "dir2/obj", "dir1-obj"} {
+		err := s.bucket.PutBytes(ctx, o, []byte("data"), nil)
+		c.Assert(err, IsNil)
+	}
+	err := s.bucket.DeleteAllWithPrefix(ctx, "dir1")
+	c.Assert(err, IsNil)
+	ds, err := s.bucket.ListDirectories(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(ds, HasLen, 1)
+	_, ok := ds["dir2"]
+	c.Assert(ok, Equals, true)
+	obs, err := s.bucket.ListObjects(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(obs, HasLen, 0)
+
+	// Non-existent prefixes are not an error
+	err = s.bucket.DeleteAllWithPrefix(ctx, "missing/prefix")
+	c.Assert(err, IsNil)
+}
+
+func (s *FileSystemSuite) TestEscapingNames(c *C) {
+	ctx := context.Background()
+	for _, name := range []string{"../outside", "dir/../../outside", "/../outside"} {
+		err := s.bucket.PutBytes(ctx, name, []byte("data"), nil)
+		c.Check(err, ErrorMatches, "invalid name .*: outside of the bucket")
+		_, _, err = s.bucket.GetBytes(ctx, name)
+		c.Check(err, NotNil)
+		c.Check(s.bucket.Delete(ctx, name), NotNil)
+		// Directory names are cleaned within the bucket
+		d, err := s.bucket.CreateDirectory(ctx, name)
+		c.Check(err, IsNil)
+		c.Check(d.String(), Matches, ".*/"+testBucketName+"/outside/")
+	}
+	_, err := os.Stat(filepath.Join(s.root, "outside"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// Names that resolve inside of the bucket are allowed
+	err = s.bucket.PutBytes(ctx, "dir/../inside", []byte("data"), nil)
+	c.Assert(err, IsNil)
+	data, _, err := s.bucket.GetBytes(ctx, "inside")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "data")
+
+	for _, name := range []string{"..", "a/b", ""} {
+		_, err = s.provider.CreateBucket(ctx, name, "")
+		c.Check(err, ErrorMatches, "invalid bucket name.*")
+		_, err = s.provider.GetBucket(ctx, name)
+		c.Check(err, NotNil)
+	}
+}
+
+func (s *FileSystemSuite) TestObjects(c *C) {
+	ctx := context.Background()
+	tags := map[string]string{
+		"key":  "value",
+		"key2": "value2",
+	}
+	const data = "Some other text"
+	_, err := s.bucket.Put(ctx, "/some/deep/object", bytes.NewBufferString(data), 0, tags)
+	c.Assert(err, IsNil)
+
+	d, err := s.bucket.GetDirectory(ctx, "some/deep")
+	c.Assert(err, IsNil)
+	obs, err := d.ListObjects(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(obs, DeepEquals, []string{"object"})
+
+	r, ntags, err := d.Get(ctx, "object")
+	c.Assert(err, IsNil)
+	buf, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	r.Close()
+	c.Assert(string(buf), Equals, data)
+	c.Assert(ntags, DeepEquals, tags)
+
+	// Overwriting an object without tags removes the old tags
+	err = d.PutBytes(ctx, "object", []byte(data), nil)
+	c.Assert(err, IsNil)
+	_, ntags, err = d.GetBytes(ctx, "object")
+	c.Assert(err, IsNil)
+	c.Assert(ntags, HasLen, 0)
+
+	err = d.Delete(ctx, "object")
+	c.Assert(err, IsNil)
+	_, _, err = d.Get(ctx, "object")
+	c.Assert(err, NotNil)
+	fis, err := ioutil.ReadDir(filepath.Join(s.root, testBucketName, "some", "deep"))
+	c.Assert(err, IsNil)
+	c.Assert(fis, HasLen, 0)
+
+	err = d.PutBytes(ctx, "object"+fsTagsSuffix, []byte(data), nil)
+	c.Assert(err, NotNil)
+	_, err = os.Stat(filepath.Join(s.root, testBucketName, "some", "deep", "object"+fsTagsSuffix))
+	c.Assert(os.IsNotExist(err), Equals, true)
+}
+
+func (s *FileSystemSuite) TestGetRange(c *C) {
+	ctx := context.Background()
+	tags := map[string]string{"key": "value"}
+	err := s.bucket.PutBytes(ctx, "dir/object", []byte("0123456789"), tags)
+	c.Assert(err, IsNil)
+	for _, tc := range []struct {
+		offset, length int64
+		want           string
+	}{
+		{0, -1, "0123456789"},
+		{3, 4, "3456"},
+		{8, 10, "89"},
+		{10, 5, ""},
+		{20, -1, ""},
+	} {
+		r, ntags, err := s.bucket.GetRange(ctx, "dir/object", tc.offset, tc.length)
+		c.Assert(err, IsNil)
+		data, err := ioutil.ReadAll(r)
+		c.Assert(err, IsNil)
+		c.Assert(r.Close(), IsNil)
+		c.Assert(string(data), Equals, tc.want)
+		c.Assert(ntags, DeepEquals, tags)
+	}
+	_, _, err = s.bucket.GetRange(ctx, "dir/object", -1, 1)
+	c.Assert(err, NotNil)
+	_, _, err = s.bucket.GetRange(ctx, "dir/missing", 0, 1)
+	c.Assert(err, NotNil)
+}
@@ -0,0 +1,163 @@
+package objectstore
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+	"net/url"
+	"reflect"
+	"time"
+	"unsafe"
+
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/graymeta/stow"
+	stowgcs "github.com/graymeta/stow/google"
+	"github.com/pkg/errors"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	storage "google.golang.org/api/storage/v1"
+)
+
+// Object stores with a CA bundle, a proxy or SkipSSLVerify are reached
+// through an http.Client of their own, so that the settings of one Profile
+// never apply to the requests of another. The clients that stow creates for
+// S3 and GCS cannot be configured, so their HTTP clients are replaced after
+// the location is created. Azure locations are created by newAzureLocation,
+// which accepts a client.
+
+// httpClient returns a client for the object store. It returns nil if the
+// default client can be used.
+func httpClient(config ProviderConfig) (*http.Client, error) {
+	if config.CACert == "" && config.Proxy == "" && !config.SkipSSLVerify {
+		return nil, nil
+	}
+	t, err := newTransport(config)
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: t}, nil
+}
+
+// orDefaultClient returns hc, or http.DefaultClient if hc is nil
+func orDefaultClient(hc *http.Client) *http.Client {
+	if hc == nil {
+		return http.DefaultClient
+	}
+	return hc
+}
+
+// newTransport returns a transport with the same settings as
+// http.DefaultTransport, the CA bundle and proxy of the object store
+func newTransport(config ProviderConfig) (*http.Transport, error) {
+	proxy := http.ProxyFromEnvironment
+	if config.Proxy != "" {
+		u, err := url.Parse(config.Proxy)
+		if err != nil {
+			// The URL and the parse error may contain the proxy password
+			return nil, errors.New("invalid proxy URL")
+		}
+		proxy = http.ProxyURL(u)
+	}
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.SkipSSLVerify}
+	if config.CACert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(config.CACert)) {
+			return nil, errors.New("failed to parse CA certificates")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	return &http.Transport{
+		Proxy: proxy,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig:       tlsConfig,
+	}, nil
+}
+
+// setLocationHTTPClient makes the SDK client of the S3 or GCS stow location
+// send its requests with hc
+func setLocationHTTPClient(ctx context.Context, location stow.Location, kind string, stowConfig stow.Config, hc *http.Client) error {
+	if hc == nil {
+		return nil
+	}
+	switch l := location.(type) {
+	case *stowgcs.Location:
+		svc, err := gcsService(ctx, stowConfig, hc)
+		if err != nil {
+			return err
+		}
+		return setField(l, "client", svc)
+	default:
+		f, err := field(location, "client")
+		if err != nil {
+			return err
+		}
+		cli, ok := f.Interface().(*s3.S3)
+		if !ok {
+			return errors.Errorf("cannot configure the HTTP client of %s locations", kind)
+		}
+		// The client is created for this location only
+		cli.Config.HTTPClient = hc
+		return nil
+	}
+}
+
+// gcsService returns a GCS client that sends its requests, including the
+// requests for tokens, with hc
+func gcsService(ctx context.Context, stowConfig stow.Config, hc *http.Client) (*storage.Service, error) {
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, hc)
+	var client *http.Client
+	if json, _ := stowConfig.Config(stowgcs.ConfigJSON); json != "" {
+		jwtConf, err := google.JWTConfigFromJSON([]byte(json), storage.DevstorageReadWriteScope)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse GCS credentials")
+		}
+		client = jwtConf.Client(ctx)
+	} else {
+		creds, err := google.FindDefaultCredentials(ctx, storage.DevstorageReadWriteScope)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to find GCS credentials")
+		}
+		client = oauth2.NewClient(ctx, creds.TokenSource)
+	}
+	svc, err := storage.New(client)
+	return svc, errors.Wrap(err, "failed to create GCS client")
+}
+
+// field returns the unexported field of the struct that ptr points to
+func field(ptr interface{}, name string) (reflect.Value, error) {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, errors.Errorf("%T is not a pointer to a struct", ptr)
+	}
+	f := v.Elem().FieldByName(name)
+	if !f.IsValid() {
+		return reflect.Value{}, errors.Errorf("%T has no field %s", ptr, name)
+	}
+	return reflect.NewAt(f.Type(), unsafe.Pointer(f.UnsafeAddr())).Elem(), nil
+}
+
+// setField sets the unexported field of the struct that ptr points to
+func setField(ptr interface{}, name string, value interface{}) error {
+	f, err := field(ptr, name)
+	if err != nil {
+		return err
+	}
+	v := reflect.ValueOf(value)
+	if !v.Type().AssignableTo(f.Type()) {
+		return errors.Errorf("cannot assign %T to field %s of %T", value, name, ptr)
+	}
+	f.Set(v)
+	return nil
+}
@@ -0,0 +1,114 @@
+package objectstore
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// DefaultListPageSize is the number of objects in a page of a listing
+const DefaultListPageSize = 1000
+
+// ObjectInfo describes an object
+type ObjectInfo struct {
+	// Name of the object, relative to the listed directory
+	Name         string
+	Size         int64
+	LastModified time.Time
+	// ETag is empty for file system stores
+	ETag string
+	// Tags are only set if requested in the ListOptions
+	Tags map[string]string
+}
+
+// ListOptions configure a paginated listing of objects
+type ListOptions struct {
+	// Cursor returned with the previous page. It is empty for the first
+	// page.
+	Cursor string
+	// PageSize is the maximum number of objects in a page
+	PageSize int
+	// Recursive lists the objects in all the sub directories too
+	Recursive bool
+	// Tags requests the tags of the objects. Some stores need a request
+	// per object to get them.
+	Tags bool
+}
+
+func (o ListOptions) pageSize() int {
+	if o.PageSize <= 0 {
+		return DefaultListPageSize
+	}
+	return o.PageSize
+}
+
+// ObjectIterator iterates over the objects of a directory, one page at a
+// time:
+//
+//   it := NewObjectIterator(dir, ListOptions{Recursive: true})
+//   for it.Next(ctx) {
+//       obj := it.Object()
+//   }
+//   if err := it.Err(); err != nil {
+//   }
+type ObjectIterator struct {
+	dir  Directory
+	opts ListOptions
+	page []ObjectInfo
+	cur  ObjectInfo
+	done bool
+	err  error
+}
+
+// NewObjectIterator returns an iterator over the objects of dir. The
+// listing starts at opts.Cursor.
+func NewObjectIterator(dir Directory, opts ListOptions) *ObjectIterator {
+	return &ObjectIterator{dir: dir, opts: opts}
+}
+
+// Next advances to the next object. It returns false at the end of the
+// listing or on errors.
+func (it *ObjectIterator) Next(ctx context.Context) bool {
+	for len(it.page) == 0 {
+		if it.done || it.err != nil {
+			return false
+		}
+		it.page, it.opts.Cursor, it.err = it.dir.ListObjectsPage(ctx, it.opts)
+		it.done = it.opts.Cursor == ""
+	}
+	it.cur, it.page = it.page[0], it.page[1:]
+	return true
+}
+
+// Object returns the current object
+func (it *ObjectIterator) Object() ObjectInfo {
+	return it.cur
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *ObjectIterator) Err() error {
+	return it.err
+}
+
+// PrefixDirectory returns the directory of the objects of dir whose names
+// start with prefix and a '/'. Unlike GetDirectory, it does not need a
+// directory marker, so that objects written with a path in their name can
+// be listed.
+func PrefixDirectory(ctx context.Context, dir Directory, prefix string) (Directory, error) {
+	if d, ok := stowDirectory(dir); ok {
+		return &directory{bucket: d.bucket, path: d.absDirName(prefix)}, nil
+	}
+	return dir.GetDirectory(ctx, prefix)
+}
+
+// compareObjectNames orders object names by their path elements, the order
+// in which file systems are walked
+func compareObjectNames(a, b string) int {
+	as, bs := strings.Split(a, "/"), strings.Split(b, "/")
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		if c := strings.Compare(as[i], bs[i]); c != 0 {
+			return c
+		}
+	}
+	return len(as) - len(bs)
+}
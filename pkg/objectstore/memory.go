@@ -0,0 +1,310 @@
+package objectstore
+
+// Buckets and directories held in memory, for tests
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const memoryScheme = "mem"
+
+var (
+	memoryStoresMu sync.Mutex
+	// memoryStores holds the contents of the memory providers, indexed by
+	// endpoint, so that providers created with the same config share data.
+	memoryStores = map[string]*memFileSystem{}
+)
+
+// newMemoryProvider returns a provider whose buckets only exist in the memory
+// of the current process. Providers with the same endpoint share buckets.
+func newMemoryProvider(config ProviderConfig) (Provider, error) {
+	memoryStoresMu.Lock()
+	fs, ok := memoryStores[config.Endpoint]
+	if !ok {
+		fs = newMemFileSystem()
+		memoryStores[config.Endpoint] = fs
+	}
+	memoryStoresMu.Unlock()
+	return &fsProvider{
+		root:         "/",
+		hostEndPoint: memoryScheme + "://" + config.Endpoint,
+		dial:         func() (fileSystem, error) { return fs, nil },
+	}, nil
+}
+
+// ResetMemoryProvider discards the buckets of the memory provider with the
+// given endpoint
+func ResetMemoryProvider(endpoint string) {
+	memoryStoresMu.Lock()
+	defer memoryStoresMu.Unlock()
+	delete(memoryStores, endpoint)
+}
+
+var _ fileSystem = (*memFileSystem)(nil)
+
+// memFileSystem is a fileSystem held in memory. It is safe for concurrent use.
+type memFileSystem struct {
+	mu   sync.Mutex
+	root *memNode
+}
+
+type memNode struct {
+	name     string
+	dir      bool
+	data     []byte
+	modTime  time.Time
+	children map[string]*memNode
+}
+
+func newMemFileSystem() *memFileSystem {
+	return &memFileSystem{root: newMemDir("/")}
+}
+
+func newMemDir(name string) *memNode {
+	return &memNode{name: name, dir: true, modTime: time.Now(), children: map[string]*memNode{}}
+}
+
+func memPathError(op, name string, err error) error {
+	return &os.PathError{Op: op, Path: name, Err: err}
+}
+
+func splitMemPath(name string) []string {
+	name = strings.Trim(path.Clean("/"+name), "/")
+	if name == "" {
+		return nil
+	}
+	return strings.Split(name, "/")
+}
+
+// lookup returns the node at name. The caller must hold fs.mu.
+func (fs *memFileSystem) lookup(name string) (*memNode, bool) {
+	n := fs.root
+	for _, e := range splitMemPath(name) {
+		if !n.dir {
+			return nil, false
+		}
+		c, ok := n.children[e]
+		if !ok {
+			return nil, false
+		}
+		n = c
+	}
+	return n, true
+}
+
+// parent returns the directory containing name and the base name. The
+// caller must hold fs.mu.
+func (fs *memFileSystem) parent(op, name string) (*memNode, string, error) {
+	dir, base := path.Split(path.Clean("/" + name))
+	if base == "" {
+		return nil, "", memPathError(op, name, os.ErrExist)
+	}
+	p, ok := fs.lookup(dir)
+	if !ok || !p.dir {
+		return nil, "", memPathError(op, name, os.ErrNotExist)
+	}
+	return p, base, nil
+}
+
+func (fs *memFileSystem) Stat(name string) (os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, ok := fs.lookup(name)
+	if !ok {
+		return nil, memPathError("stat", name, os.ErrNotExist)
+	}
+	return n.info(), nil
+}
+
+func (fs *memFileSystem) ReadDir(name string) ([]os.FileInfo, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, ok := fs.lookup(name)
+	if !ok || !n.dir {
+		return nil, memPathError("readdir", name, os.ErrNotExist)
+	}
+	fis := make([]os.FileInfo, 0, len(n.children))
+	for _, c := range n.children {
+		fis = append(fis, c.info())
+	}
+	sort.Slice(fis, func(i, j int) bool { return fis[i].Name() < fis[j].Name() })
+	return fis, nil
+}
+
+func (fs *memFileSystem) Mkdir(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p, base, err := fs.parent("mkdir", name)
+	if err != nil {
+		return err
+	}
+	if _, ok := p.children[base]; ok {
+		return memPathError("mkdir", name, os.ErrExist)
+	}
+	p.children[base] = newMemDir(base)
+	return nil
+}
+
+func (fs *memFileSystem) MkdirAll(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n := fs.root
+	for _, e := range splitMemPath(name) {
+		c, ok := n.children[e]
+		switch {
+		case !ok:
+			c = newMemDir(e)
+			n.children[e] = c
+		case !c.dir:
+			return memPathError("mkdir", name, os.ErrExist)
+		}
+		n = c
+	}
+	return nil
+}
+
+func (fs *memFileSystem) Open(name string) (io.ReadCloser, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	n, ok := fs.lookup(name)
+	if !ok || n.dir {
+		return nil, memPathError("open", name, os.ErrNotExist)
+	}
+	// Writers replace the data of a node, so the slice is never modified
+	return memReader{bytes.NewReader(n.data)}, nil
+}
+
+func (fs *memFileSystem) Create(name string) (io.WriteCloser, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p, base, err := fs.parent("create", name)
+	if err != nil {
+		return nil, err
+	}
+	if c, ok := p.children[base]; ok && c.dir {
+		return nil, memPathError("create", name, os.ErrExist)
+	}
+	n := &memNode{name: base, modTime: time.Now()}
+	p.children[base] = n
+	return &memFile{fs: fs, node: n}, nil
+}
+
+func (fs *memFileSystem) Rename(oldname, newname string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	op, obase, err := fs.parent("rename", oldname)
+	if err != nil {
+		return err
+	}
+	n, ok := op.children[obase]
+	if !ok {
+		return memPathError("rename", oldname, os.ErrNotExist)
+	}
+	np, nbase, err := fs.parent("rename", newname)
+	if err != nil {
+		return err
+	}
+	if c, ok := np.children[nbase]; ok && c.dir {
+		return memPathError("rename", newname, os.ErrExist)
+	}
+	delete(op.children, obase)
+	n.name = nbase
+	np.children[nbase] = n
+	return nil
+}
+
+func (fs *memFileSystem) Remove(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p, base, err := fs.parent("remove", name)
+	if err != nil {
+		return err
+	}
+	n, ok := p.children[base]
+	if !ok {
+		return memPathError("remove", name, os.ErrNotExist)
+	}
+	if n.dir && len(n.children) > 0 {
+		return memPathError("remove", name, os.ErrExist)
+	}
+	delete(p.children, base)
+	return nil
+}
+
+func (fs *memFileSystem) RemoveAll(name string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	p, base, err := fs.parent("remove", name)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	delete(p.children, base)
+	return nil
+}
+
+// Close is a no-op. The contents remain available to other dialers.
+func (fs *memFileSystem) Close() error {
+	return nil
+}
+
+// memReader is a seekable reader of the data of a file
+type memReader struct {
+	*bytes.Reader
+}
+
+func (memReader) Close() error { return nil }
+
+// memFile buffers the data written to a file and stores it on Close
+type memFile struct {
+	fs   *memFileSystem
+	node *memNode
+	buf  bytes.Buffer
+}
+
+func (f *memFile) Write(p []byte) (int, error) {
+	return f.buf.Write(p)
+}
+
+func (f *memFile) Close() error {
+	f.fs.mu.Lock()
+	defer f.fs.mu.Unlock()
+	f.node.data = f.buf.Bytes()
+	f.node.modTime = time.Now()
+	return nil
+}
+
+func (n *memNode) info() os.FileInfo {
+	return memFileInfo{name: n.name, dir: n.dir, size: int64(len(n.data)), modTime: n.modTime}
+}
+
+var _ os.FileInfo = memFileInfo{}
+
+type memFileInfo struct {
+	name    string
+	dir     bool
+	size    int64
+	modTime time.Time
+}
+
+func (fi memFileInfo) Name() string       { return fi.name }
+func (fi memFileInfo) Size() int64        { return fi.size }
+func (fi memFileInfo) ModTime() time.Time { return fi.modTime }
+func (fi memFileInfo) IsDir() bool        { return fi.dir }
+func (fi memFileInfo) Sys() interface{}   { return nil }
+
+func (fi memFileInfo) Mode() os.FileMode {
+	if fi.dir {
+		return os.ModeDir | 0755
+	}
+	return 0644
+}
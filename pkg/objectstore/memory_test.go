@@ -0,0 +1,220 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"io/ioutil"
+
+	. "gopkg.in/check.v1"
+)
+
+type MemorySuite struct {
+	endpoint string
+	provider Provider
+	bucket   Bucket
+}
+
+var _ = Suite(&MemorySuite{})
+
+func (s *MemorySuite) SetUpTest(c *C) {
+	ctx := context.Background()
+	s.endpoint = c.TestName()
+	var err error
+	s.provider, err = NewProvider(ctx, ProviderConfig{Type: ProviderTypeMemory, Endpoint: s.endpoint}, nil)
+	c.Assert(err, IsNil)
+	s.bucket, err = GetOrCreateBucket(ctx, s.provider, testBucketName, "")
+	c.Assert(err, IsNil)
+}
+
+func (s *MemorySuite) TearDownTest(c *C) {
+	ResetMemoryProvider(s.endpoint)
+}
+
+func (s *MemorySuite) TestSharedStore(c *C) {
+	ctx := context.Background()
+	c.Assert(s.bucket.String(), Equals, "mem://"+s.endpoint+"/"+testBucketName+"/")
+	err := s.bucket.PutBytes(ctx, "dir/object", []byte("data"), map[string]string{"key": "value"})
+	c.Assert(err, IsNil)
+
+	// Providers with the same endpoint see the same buckets
+	p, err := NewProvider(ctx, ProviderConfig{Type: ProviderTypeMemory, Endpoint: s.endpoint}, nil)
+	c.Assert(err, IsNil)
+	b, err := p.GetBucket(ctx, testBucketName)
+	c.Assert(err, IsNil)
+	data, tags, err := b.GetBytes(ctx, "dir/object")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "data")
+	c.Assert(tags, DeepEquals, map[string]string{"key": "value"})
+
+	// Other endpoints are isolated
+	p, err = NewProvider(ctx, ProviderConfig{Type: ProviderTypeMemory, Endpoint: s.endpoint + "-other"}, nil)
+	c.Assert(err, IsNil)
+	_, err = p.GetBucket(ctx, testBucketName)
+	c.Assert(err, NotNil)
+
+	ResetMemoryProvider(s.endpoint)
+	_, err = s.provider.GetBucket(ctx, testBucketName)
+	c.Assert(err, IsNil)
+	p, err = NewProvider(ctx, ProviderConfig{Type: ProviderTypeMemory, Endpoint: s.endpoint}, nil)
+	c.Assert(err, IsNil)
+	_, err = p.GetBucket(ctx, testBucketName)
+	c.Assert(err, NotNil)
+}
+
+func (s *MemorySuite) TestBuckets(c *C) {
+	ctx := context.Background()
+	_, err := s.provider.CreateBucket(ctx, testBucketName, "")
+	c.Assert(err, NotNil)
+
+	b, err := s.provider.CreateBucket(ctx, "other-bucket", "")
+	c.Assert(err, IsNil)
+	buckets, err := s.provider.ListBuckets(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(buckets, HasLen, 2)
+
+	// Buckets with contents are not deleted
+	err = b.PutBytes(ctx, "object", []byte("content"), nil)
+	c.Assert(err, IsNil)
+	err = s.provider.DeleteBucket(ctx, "other-bucket")
+	c.Assert(err, NotNil)
+
+	err = b.DeleteDirectory(ctx)
+	c.Assert(err, IsNil)
+	err = s.provider.DeleteBucket(ctx, "other-bucket")
+	c.Assert(err, IsNil)
+	_, err = s.provider.GetBucket(ctx, "other-bucket")
+	c.Assert(err, NotNil)
+}
+
+func (s *MemorySuite) TestDirectoriesAndPrefixes(c *C) {
+	ctx := context.Background()
+	for _, o := range []string{"dir1/obj", "dir1/sub/obj", "dir1x/obj", "dir2/obj", "dir1-obj", "obj"} {
+		err := s.bucket.PutBytes(ctx, o, []byte("data"), nil)
+		c.Assert(err, IsNil)
+	}
+	ds, err := s.bucket.ListDirectories(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(ds, HasLen, 3)
+	d, ok := ds["dir1"]
+	c.Assert(ok, Equals, true)
+	sub, err := d.ListDirectories(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(sub, HasLen, 1)
+	obs, err := s.bucket.ListObjects(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(obs, DeepEquals, []string{"dir1-obj", "obj"})
+
+	_, err = s.bucket.GetDirectory(ctx, "obj")
+	c.Assert(err, NotNil)
+
+	err = s.bucket.DeleteAllWithPrefix(ctx, "dir1")
+	c.Assert(err, IsNil)
+	ds, err = s.bucket.ListDirectories(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(ds, HasLen, 1)
+	_, ok = ds["dir2"]
+	c.Assert(ok, Equals, true)
+	obs, err = s.bucket.ListObjects(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(obs, DeepEquals, []string{"obj"})
+
+	err = s.bucket.DeleteAllWithPrefix(ctx, "missing/prefix")
+	c.Assert(err, IsNil)
+}
+
+func (s *MemorySuite) TestObjects(c *C) {
+	ctx := context.Background()
+	tags := map[string]string{"key": "value"}
+	const data = "Some other text"
+	_, err := s.bucket.Put(ctx, "/some/deep/object", bytes.NewBufferString(data), 0, tags)
+	c.Assert(err, IsNil)
+
+	d, err := s.bucket.GetDirectory(ctx, "some/deep")
+	c.Assert(err, IsNil)
+	r, ntags, err := d.Get(ctx, "object")
+	c.Assert(err, IsNil)
+	buf, err := ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	r.Close()
+	c.Assert(string(buf), Equals, data)
+	c.Assert(ntags, DeepEquals, tags)
+
+	// Overwriting an object without tags removes the old tags
+	err = d.PutBytes(ctx, "object", []byte("new"), nil)
+	c.Assert(err, IsNil)
+	nbuf, ntags, err := d.GetBytes(ctx, "object")
+	c.Assert(err, IsNil)
+	c.Assert(string(nbuf), Equals, "new")
+	c.Assert(ntags, HasLen, 0)
+
+	r, _, err = d.GetRange(ctx, "object", 1, 1)
+	c.Assert(err, IsNil)
+	buf, err = ioutil.ReadAll(r)
+	c.Assert(err, IsNil)
+	c.Assert(string(buf), Equals, "e")
+
+	err = d.Delete(ctx, "object")
+	c.Assert(err, IsNil)
+	_, _, err = d.Get(ctx, "object")
+	c.Assert(err, NotNil)
+}
+
+func (s *MemorySuite) TestListObjectsPage(c *C) {
+	ctx := context.Background()
+	names := []string{"a", "a-b", "b/c", "b/d/e", "b/d/f", "b0", "c"}
+	for i, n := range names {
+		err := s.bucket.PutBytes(ctx, n, make([]byte, i), map[string]string{"n": n})
+		c.Assert(err, IsNil)
+	}
+
+	objs, cursor, err := s.bucket.ListObjectsPage(ctx, ListOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(cursor, Equals, "")
+	c.Assert(objectNames(objs), DeepEquals, []string{"a", "a-b", "b0", "c"})
+	c.Assert(objs[1].Size, Equals, int64(1))
+	c.Assert(objs[1].LastModified.IsZero(), Equals, false)
+	c.Assert(objs[1].Tags, IsNil)
+
+	// Pages of a recursive listing resume after the cursor
+	var all []ObjectInfo
+	opts := ListOptions{PageSize: 2, Recursive: true, Tags: true}
+	for {
+		objs, cursor, err = s.bucket.ListObjectsPage(ctx, opts)
+		c.Assert(err, IsNil)
+		c.Assert(len(objs) <= 2, Equals, true)
+		all = append(all, objs...)
+		if cursor == "" {
+			break
+		}
+		opts.Cursor = cursor
+	}
+	c.Assert(objectNames(all), DeepEquals, []string{"a", "a-b", "b/c", "b/d/e", "b/d/f", "b0", "c"})
+	for _, o := range all {
+		c.Assert(o.Tags, DeepEquals, map[string]string{"n": o.Name})
+	}
+
+	it := NewObjectIterator(s.bucket, ListOptions{PageSize: 3, Recursive: true})
+	var size int64
+	var n int
+	for it.Next(ctx) {
+		size += it.Object().Size
+		n++
+	}
+	c.Assert(it.Err(), IsNil)
+	c.Assert(n, Equals, len(names))
+	c.Assert(size, Equals, int64(21))
+
+	d, err := s.bucket.GetDirectory(ctx, "b")
+	c.Assert(err, IsNil)
+	objs, _, err = d.ListObjectsPage(ctx, ListOptions{Recursive: true})
+	c.Assert(err, IsNil)
+	c.Assert(objectNames(objs), DeepEquals, []string{"c", "d/e", "d/f"})
+}
+
+func objectNames(objs []ObjectInfo) []string {
+	names := make([]string, 0, len(objs))
+	for _, o := range objs {
+		names = append(names, o.Name)
+	}
+	return names
+}
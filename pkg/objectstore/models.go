@@ -1,16 +1,53 @@
 package objectstore
 
+import "time"
+
 // ProviderConfig describes the config for the object store (which provider to use)
 type ProviderConfig struct {
 	// object store type
 	Type ProviderType
 	// Endpoint used to access the object store. It can be implicit for
 	// stores from certain cloud providers such as AWS. In that case it can
-	// be empty
+	// be empty. For the file system provider, it is the root directory
+	// under which buckets are created. For the SFTP provider, it is a
+	// sftp://host:port/root URL (see SFTPEndpoint). For the memory
+	// provider, it names the store shared by providers in the process.
 	Endpoint string
 	// If true, disable SSL verification. If false (the default), SSL
-	// verification is enabled.
+	// verification is enabled. For the SFTP provider, it disables host key
+	// verification when HostKey is empty.
 	SkipSSLVerify bool
+	// HostKey is the public key of the SFTP server, in authorized_keys
+	// format
+	HostKey string
+	// Server-side encryption applied to the objects that are Put
+	Encryption ServerSideEncryption
+	// Retention applied to the objects that are Put
+	Retention Retention
+	// CACert is a PEM encoded bundle of the certificates of CAs that are
+	// trusted in addition to the system CAs
+	CACert string
+	// Proxy is the URL of the HTTP(S) proxy through which the object store
+	// is reached. The proxy from the environment is used if it is empty.
+	Proxy string
+}
+
+// Retention describes how long new objects are locked against deletion and
+// overwrites. The zero value does not lock objects.
+type Retention struct {
+	Mode   RetentionMode
+	Period time.Duration
+}
+
+// ServerSideEncryption describes how the object store encrypts new objects.
+// The zero value leaves the bucket defaults in place.
+type ServerSideEncryption struct {
+	// S3 encryption type
+	Type SSEType
+	// SSE-KMS key ID for S3 or Cloud KMS key name for GCS
+	KMSKeyID string
+	// Azure encryption scope
+	EncryptionScope string
 }
 
 // SecretAws AWS keys
@@ -37,6 +74,14 @@ type SecretGcp struct {
 	ServiceKey string
 }
 
+// SecretSSH SSH credentials
+type SecretSSH struct {
+	// user name
+	Username string
+	// PEM encoded private key
+	PrivateKey string
+}
+
 // Secret contains the credentials for different providers
 type Secret struct {
 	// aws
@@ -45,6 +90,8 @@ type Secret struct {
 	Azure *SecretAzure
 	// gcp
 	Gcp *SecretGcp
+	// ssh
+	SSH *SecretSSH
 	// type
 	Type SecretType
 }
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"io"
+	"net/http"
 	"os"
 
 	"github.com/graymeta/stow"
@@ -60,14 +61,30 @@ type Directory interface {
 	// ListObjects lists all the objects rooted in the current directory
 	ListObjects(context.Context) ([]string, error)
 
+	// ListObjectsPage returns a page of the objects in the current
+	// directory and the cursor of the next page. The cursor is empty
+	// after the last page.
+	ListObjectsPage(ctx context.Context, opts ListOptions) ([]ObjectInfo, string, error)
+
 	// Get returns the io interface to read object data
 	Get(context.Context, string) (io.ReadCloser, map[string]string, error)
 
+	// GetVersion returns the io interface to read the data of a version
+	// of the named object. The version ID is one returned by Put. The
+	// latest version is read if the ID is empty.
+	GetVersion(ctx context.Context, name, versionID string) (io.ReadCloser, map[string]string, error)
+
+	// GetRange returns the io interface to read length bytes of object
+	// data from offset. A negative length reads to the end of the object.
+	GetRange(ctx context.Context, name string, offset, length int64) (io.ReadCloser, map[string]string, error)
+
 	// Get returns bytes in the named object
 	GetBytes(context.Context, string) ([]byte, map[string]string, error)
 
-	// Put persists data from the Reader interface in the named object
-	Put(context.Context, string, io.Reader, int64, map[string]string) error
+	// Put persists data from the Reader interface in the named object. It
+	// returns the version ID of the new object in versioned buckets and an
+	// empty ID otherwise.
+	Put(context.Context, string, io.Reader, int64, map[string]string) (string, error)
 
 	// Put persists bytes in the named object
 	PutBytes(context.Context, string, []byte, map[string]string) error
@@ -75,16 +92,33 @@ type Directory interface {
 	// Delete removes the object
 	Delete(context.Context, string) error
 
+	// DeleteVersion removes a version of the named object. The latest
+	// version is removed if the ID is empty.
+	DeleteVersion(ctx context.Context, name, versionID string) error
+
 	// Serialize directory
 	String() string
 }
 
 // NewProvider creates a new Provider
 func NewProvider(ctx context.Context, config ProviderConfig, secret *Secret) (Provider, error) {
+	switch config.Type {
+	case ProviderTypeFileSystem:
+		return newFileSystemProvider(config)
+	case ProviderTypeSFTP:
+		return newSFTPProvider(config, secret)
+	case ProviderTypeMemory:
+		return newMemoryProvider(config)
+	}
+	hc, err := httpClient(config)
+	if err != nil {
+		return nil, err
+	}
 	p := &provider{
 		hostEndPoint: getHostURI(config),
 		config:       config,
 		secret:       secret,
+		hc:           hc,
 	}
 	if p.config.Type == ProviderTypeS3 {
 		return &s3Provider{provider: p}, nil
@@ -94,7 +128,7 @@ func NewProvider(ctx context.Context, config ProviderConfig, secret *Secret) (Pr
 
 // Supported returns true if the object store type is supported
 func Supported(t ProviderType) bool {
-	return t == ProviderTypeS3 || t == ProviderTypeGCS || t == ProviderTypeAzure
+	return t == ProviderTypeS3 || t == ProviderTypeGCS || t == ProviderTypeAzure || t == ProviderTypeFileSystem || t == ProviderTypeSFTP || t == ProviderTypeMemory
 }
 
 func s3Config(config ProviderConfig, secret *Secret, region string) (stowKind string, stowConfig stow.Config, err error) {
@@ -193,14 +227,23 @@ func getConfig(ctx context.Context, config ProviderConfig, secret *Secret, regio
 	}
 }
 
-func getStowLocation(ctx context.Context, config ProviderConfig, secret *Secret, region string) (stow.Location, error) {
+// getStowLocation returns a location that sends its requests with hc, or
+// with the default client if hc is nil
+func getStowLocation(ctx context.Context, config ProviderConfig, secret *Secret, region string, hc *http.Client) (stow.Location, error) {
 	kind, stowConfig, err := getConfig(ctx, config, secret, region)
 	if err != nil {
 		return nil, err
 	}
+	if kind == stowaz.Kind {
+		location, err := newAzureLocation(stowConfig, hc)
+		return location, errors.Wrapf(err, "could not create store provider %s", config.Type)
+	}
 	location, err := stow.Dial(kind, stowConfig)
 	if err != nil {
-		return nil, errors.Wrapf(err, "could not create store provider %+v", config)
+		return nil, errors.Wrapf(err, "could not create store provider %s", config.Type)
+	}
+	if err := setLocationHTTPClient(ctx, location, kind, stowConfig, hc); err != nil {
+		return nil, errors.Wrapf(err, "could not configure store provider %s", config.Type)
 	}
 	return location, nil
 }
@@ -352,7 +352,7 @@ func (s *ObjectStoreProviderSuite) TestObjectsStreaming(c *C) {
 	data1B := []byte(data1)
 	data2B := []byte(data2)
 
-	err = rootDirectory.Put(ctx, obj1, bytes.NewReader(data1B), int64(len(data1B)), nil)
+	_, err = rootDirectory.Put(ctx, obj1, bytes.NewReader(data1B), int64(len(data1B)), nil)
 	c.Check(err, IsNil)
 
 	objs, err := rootDirectory.ListObjects(ctx)
@@ -367,7 +367,7 @@ func (s *ObjectStoreProviderSuite) TestObjectsStreaming(c *C) {
 	r.Close()
 	c.Check(data, DeepEquals, data1B)
 
-	err = rootDirectory.Put(ctx, obj2, bytes.NewReader(data2B), int64(len(data2B)), tags)
+	_, err = rootDirectory.Put(ctx, obj2, bytes.NewReader(data2B), int64(len(data2B)), tags)
 	c.Check(err, IsNil)
 	r, ntags, err := rootDirectory.Get(ctx, obj2)
 	c.Check(err, IsNil)
@@ -384,6 +384,35 @@ func (s *ObjectStoreProviderSuite) TestObjectsStreaming(c *C) {
 	c.Check(err, IsNil)
 }
 
+func (s *ObjectStoreProviderSuite) TestListObjectsPage(c *C) {
+	ctx := context.Background()
+	rootDirectory, err := s.root.CreateDirectory(ctx, s.testDir)
+	c.Assert(err, IsNil)
+	for _, name := range []string{"obj1", "obj2", "dir/obj3"} {
+		err = rootDirectory.PutBytes(ctx, name, []byte(name), map[string]string{"key": name})
+		c.Assert(err, IsNil)
+	}
+
+	objs, cursor, err := rootDirectory.ListObjectsPage(ctx, ListOptions{Tags: true})
+	c.Assert(err, IsNil)
+	c.Check(cursor, Equals, "")
+	c.Assert(objs, HasLen, 2)
+	for _, o := range objs {
+		c.Check(o.Size, Equals, int64(len(o.Name)))
+		c.Check(o.ETag, Not(Equals), "")
+		c.Check(o.LastModified.IsZero(), Equals, false)
+		c.Check(o.Tags["key"], Equals, o.Name)
+	}
+
+	it := NewObjectIterator(rootDirectory, ListOptions{PageSize: 1, Recursive: true})
+	var names []string
+	for it.Next(ctx) {
+		names = append(names, it.Object().Name)
+	}
+	c.Assert(it.Err(), IsNil)
+	c.Check(names, DeepEquals, []string{"dir/obj3", "obj1", "obj2"})
+}
+
 func (s *ObjectStoreProviderSuite) createBucketName(c *C) string {
 	// Generate a bucket name
 	bucketName := fmt.Sprintf("kio-io-tests-%v-%d", strings.ToLower(c.TestName()), s.rand.Uint32())
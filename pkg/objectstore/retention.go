@@ -0,0 +1,284 @@
+package objectstore
+
+// Object lock (WORM) retention and object versions. Stow ignores both, so
+// they are handled with the provider SDKs directly.
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	azstorage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/pkg/errors"
+)
+
+const (
+	azureImmutabilityUntilHeader     = "x-ms-immutability-policy-until-date"
+	azureImmutabilityModeHeader      = "x-ms-immutability-policy-mode"
+	azureVersionIDHeader             = "x-ms-version-id"
+	azureVersionLevelImmutableHeader = "x-ms-immutable-storage-with-versioning-enabled"
+	azureMetadataHeaderPrefix        = "x-ms-meta-"
+	azureImmutabilityModeUnlocked    = "Unlocked"
+	azureImmutabilityModeLocked      = "Locked"
+	s3ObjectLockEnabled              = "Enabled"
+	gcsRetentionPeriodUnit           = time.Second
+)
+
+// IsZero returns true if no retention is configured
+func (r Retention) IsZero() bool {
+	return r == Retention{}
+}
+
+// until returns the end of the retention of an object written now
+func (r Retention) until() time.Time {
+	return time.Now().Add(r.Period)
+}
+
+// azureImmutabilityMode returns the mode of Azure immutability policies.
+// Unlocked policies can be removed by privileged users.
+func azureImmutabilityMode(m RetentionMode) string {
+	if m == RetentionModeCompliance {
+		return azureImmutabilityModeLocked
+	}
+	return azureImmutabilityModeUnlocked
+}
+
+// CheckBucketRetention returns an error if the bucket cannot lock objects
+// with the retention configured for its provider. S3 buckets need Object
+// Lock, GCS buckets a retention policy at least as long as the retention
+// period, locked for compliance mode, and Azure containers version-level
+// immutability.
+func CheckBucketRetention(ctx context.Context, bkt Bucket) error {
+	b, ok := bkt.(*bucket)
+	if !ok {
+		return errors.New("retention is not supported by the object store")
+	}
+	ret := b.config.Retention
+	if ret.IsZero() {
+		return nil
+	}
+	switch b.config.Type {
+	case ProviderTypeS3:
+		return b.checkS3BucketRetention(ctx)
+	case ProviderTypeGCS:
+		return b.checkGCSBucketRetention(ctx, ret)
+	case ProviderTypeAzure:
+		return b.checkAzureBucketRetention(ctx)
+	default:
+		return errors.Errorf("retention is not supported by object store type %s", b.config.Type)
+	}
+}
+
+func (b *bucket) checkS3BucketRetention(ctx context.Context) error {
+	cli, err := b.s3Client()
+	if err != nil {
+		return err
+	}
+	out, err := cli.GetObjectLockConfigurationWithContext(ctx, &s3.GetObjectLockConfigurationInput{
+		Bucket: aws.String(b.container.Name()),
+	})
+	if err != nil {
+		return errors.Wrapf(err, "failed to get object lock configuration of bucket %s", b.container.Name())
+	}
+	if out.ObjectLockConfiguration == nil || aws.StringValue(out.ObjectLockConfiguration.ObjectLockEnabled) != s3ObjectLockEnabled {
+		return errors.Errorf("object lock is not enabled for bucket %s", b.container.Name())
+	}
+	return nil
+}
+
+func (b *bucket) checkGCSBucketRetention(ctx context.Context, ret Retention) error {
+	l, err := b.gcsLocation()
+	if err != nil {
+		return err
+	}
+	bkt, err := l.Service().Buckets.Get(b.container.Name()).Context(ctx).Do()
+	if err != nil {
+		return errors.Wrapf(err, "failed to get bucket %s", b.container.Name())
+	}
+	rp := bkt.RetentionPolicy
+	if rp == nil {
+		return errors.Errorf("bucket %s has no retention policy", b.container.Name())
+	}
+	if period := time.Duration(rp.RetentionPeriod) * gcsRetentionPeriodUnit; period < ret.Period {
+		return errors.Errorf("retention period of bucket %s is %s, shorter than %s", b.container.Name(), period, ret.Period)
+	}
+	if ret.Mode == RetentionModeCompliance && !rp.IsLocked {
+		return errors.Errorf("retention policy of bucket %s is not locked", b.container.Name())
+	}
+	return nil
+}
+
+func (b *bucket) checkAzureBucketRetention(ctx context.Context) error {
+	c, err := b.azureContainer()
+	if err != nil {
+		return err
+	}
+	uri, err := c.GetSASURI(azstorage.ContainerSASOptions{
+		ContainerSASPermissions: azstorage.ContainerSASPermissions{
+			BlobServiceSASPermissions: azstorage.BlobServiceSASPermissions{Read: true},
+		},
+		SASOptions: azureSASOptions(),
+	})
+	if err != nil {
+		return err
+	}
+	h, err := b.azureHead(ctx, http.MethodGet, uri+"&restype=container")
+	if err != nil {
+		return errors.Wrapf(err, "failed to get properties of container %s", b.container.Name())
+	}
+	if h.Get(azureVersionLevelImmutableHeader) != "true" {
+		return errors.Errorf("version-level immutability is not enabled for container %s", b.container.Name())
+	}
+	return nil
+}
+
+// GetVersion returns the io interface to read the data of a version of the
+// named object and its tags. The version ID is one returned by Put. The
+// latest version is read if the ID is empty.
+func (d *directory) GetVersion(ctx context.Context, name, versionID string) (io.ReadCloser, map[string]string, error) {
+	if versionID == "" {
+		return d.Get(ctx, name)
+	}
+	if d.path == "" {
+		return nil, nil, errors.New("invalid entry")
+	}
+	objName := cloudName(d.absPathName(name))
+	switch d.bucket.config.Type {
+	case ProviderTypeS3:
+		return d.bucket.getS3Version(ctx, objName, versionID)
+	case ProviderTypeGCS:
+		return d.bucket.getGCSVersion(ctx, objName, versionID)
+	case ProviderTypeAzure:
+		return d.bucket.getAzureVersion(ctx, objName, versionID)
+	default:
+		return nil, nil, errors.Errorf("object versions are not supported by object store type %s", d.bucket.config.Type)
+	}
+}
+
+// DeleteVersion removes a version of the named object. The latest version
+// is removed if the ID is empty. Locked versions cannot be removed until
+// their retention expires. Versions of Azure blobs cannot be removed.
+func (d *directory) DeleteVersion(ctx context.Context, name, versionID string) error {
+	if versionID == "" {
+		return d.Delete(ctx, name)
+	}
+	if d.path == "" {
+		return errors.New("invalid entry")
+	}
+	objName := cloudName(d.absPathName(name))
+	switch d.bucket.config.Type {
+	case ProviderTypeS3:
+		return d.bucket.deleteS3Version(ctx, objName, versionID)
+	case ProviderTypeGCS:
+		return d.bucket.deleteGCSVersion(ctx, objName, versionID)
+	default:
+		// Deleting Azure blob versions needs SAS permissions that the
+		// storage client does not support
+		return errors.Errorf("deleting object versions is not supported by object store type %s", d.bucket.config.Type)
+	}
+}
+
+func (b *bucket) getS3Version(ctx context.Context, objName, versionID string) (io.ReadCloser, map[string]string, error) {
+	cli, err := b.s3Client()
+	if err != nil {
+		return nil, nil, err
+	}
+	out, err := cli.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket:    aws.String(b.container.Name()),
+		Key:       aws.String(objName),
+		VersionId: aws.String(versionID),
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get version %s of object %s", versionID, objName)
+	}
+	// Keys are lower case, like the tags returned by stow
+	tags := make(map[string]string, len(out.Metadata))
+	for k, v := range out.Metadata {
+		tags[strings.ToLower(k)] = aws.StringValue(v)
+	}
+	return out.Body, tags, nil
+}
+
+func (b *bucket) deleteS3Version(ctx context.Context, objName, versionID string) error {
+	cli, err := b.s3Client()
+	if err != nil {
+		return err
+	}
+	_, err = cli.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket:    aws.String(b.container.Name()),
+		Key:       aws.String(objName),
+		VersionId: aws.String(versionID),
+	})
+	return errors.Wrapf(err, "failed to delete version %s of object %s", versionID, objName)
+}
+
+func gcsGeneration(versionID string) (int64, error) {
+	gen, err := strconv.ParseInt(versionID, 10, 64)
+	return gen, errors.Wrapf(err, "invalid GCS object generation '%s'", versionID)
+}
+
+func (b *bucket) getGCSVersion(ctx context.Context, objName, versionID string) (io.ReadCloser, map[string]string, error) {
+	gen, err := gcsGeneration(versionID)
+	if err != nil {
+		return nil, nil, err
+	}
+	l, err := b.gcsLocation()
+	if err != nil {
+		return nil, nil, err
+	}
+	object, err := l.Service().Objects.Get(b.container.Name(), objName).Generation(gen).Context(ctx).Do()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get version %s of object %s", versionID, objName)
+	}
+	resp, err := l.Service().Objects.Get(b.container.Name(), objName).Generation(gen).Context(ctx).Download()
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get version %s of object %s", versionID, objName)
+	}
+	return resp.Body, object.Metadata, nil
+}
+
+func (b *bucket) deleteGCSVersion(ctx context.Context, objName, versionID string) error {
+	gen, err := gcsGeneration(versionID)
+	if err != nil {
+		return err
+	}
+	l, err := b.gcsLocation()
+	if err != nil {
+		return err
+	}
+	err = l.Service().Objects.Delete(b.container.Name(), objName).Generation(gen).Context(ctx).Do()
+	return errors.Wrapf(err, "failed to delete version %s of object %s", versionID, objName)
+}
+
+func (b *bucket) getAzureVersion(ctx context.Context, objName, versionID string) (io.ReadCloser, map[string]string, error) {
+	uri, err := b.azureBlobURI(objName, azstorage.BlobServiceSASPermissions{Read: true})
+	if err != nil {
+		return nil, nil, err
+	}
+	req, err := http.NewRequest(http.MethodGet, uri+"&versionid="+url.QueryEscape(versionID), nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	resp, err := orDefaultClient(b.hc).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "failed to get version %s of object %s", versionID, objName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		return nil, nil, errors.Wrapf(azureResponseError(resp), "failed to get version %s of object %s", versionID, objName)
+	}
+	tags := make(map[string]string)
+	for k := range resp.Header {
+		if lk := strings.ToLower(k); strings.HasPrefix(lk, azureMetadataHeaderPrefix) {
+			tags[strings.TrimPrefix(lk, azureMetadataHeaderPrefix)] = resp.Header.Get(k)
+		}
+	}
+	return resp.Body, tags, nil
+}
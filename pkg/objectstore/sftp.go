@@ -0,0 +1,156 @@
+package objectstore
+
+// Buckets and directories on a remote file system accessed over SFTP
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+const (
+	sftpScheme      = "sftp"
+	defaultSFTPPort = 22
+)
+
+// SFTPEndpoint returns the endpoint of the SFTP provider for the directory
+// root on the server host:port.
+func SFTPEndpoint(host string, port int, root string) string {
+	if port == 0 {
+		port = defaultSFTPPort
+	}
+	u := url.URL{
+		Scheme: sftpScheme,
+		Host:   net.JoinHostPort(host, strconv.Itoa(port)),
+		Path:   path.Join("/", root),
+	}
+	return u.String()
+}
+
+// newSFTPProvider returns a provider whose buckets are directories under the
+// path of the config endpoint. Every operation uses its own SSH connection.
+func newSFTPProvider(config ProviderConfig, secret *Secret) (Provider, error) {
+	u, err := url.Parse(config.Endpoint)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid SFTP endpoint %s", config.Endpoint)
+	}
+	if u.Scheme != sftpScheme || u.Hostname() == "" {
+		return nil, errors.Errorf("invalid SFTP endpoint %s", config.Endpoint)
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), strconv.Itoa(defaultSFTPPort))
+	}
+	sshConfig, err := sshClientConfig(config, secret)
+	if err != nil {
+		return nil, err
+	}
+	return &fsProvider{
+		root:         path.Join("/", u.Path),
+		hostEndPoint: fmt.Sprintf("%s://%s", sftpScheme, addr),
+		dial: func() (fileSystem, error) {
+			return dialSFTP(addr, sshConfig)
+		},
+	}, nil
+}
+
+func sshClientConfig(config ProviderConfig, secret *Secret) (*ssh.ClientConfig, error) {
+	if secret == nil || secret.Type != SecretTypeSSHKey || secret.SSH == nil {
+		return nil, errors.New("SSH key secret required for SFTP provider")
+	}
+	signer, err := ssh.ParsePrivateKey([]byte(secret.SSH.PrivateKey))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse SSH private key")
+	}
+	var hostKeyCallback ssh.HostKeyCallback
+	switch {
+	case config.HostKey != "":
+		hk, _, _, _, err := ssh.ParseAuthorizedKey([]byte(config.HostKey))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to parse SFTP server host key")
+		}
+		hostKeyCallback = ssh.FixedHostKey(hk)
+	case config.SkipSSLVerify:
+		hostKeyCallback = ssh.InsecureIgnoreHostKey()
+	default:
+		return nil, errors.New("SFTP server host key not specified")
+	}
+	return &ssh.ClientConfig{
+		User:            secret.SSH.Username,
+		Auth:            []ssh.AuthMethod{ssh.PublicKeys(signer)},
+		HostKeyCallback: hostKeyCallback,
+	}, nil
+}
+
+var _ fileSystem = (*sftpFileSystem)(nil)
+
+// sftpFileSystem is a fileSystem on an SFTP server
+type sftpFileSystem struct {
+	conn   io.Closer
+	client *sftp.Client
+}
+
+func dialSFTP(addr string, config *ssh.ClientConfig) (fileSystem, error) {
+	conn, err := ssh.Dial("tcp", addr, config)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to connect to SFTP server %s", addr)
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, errors.Wrapf(err, "failed to start SFTP session with %s", addr)
+	}
+	return &sftpFileSystem{conn: conn, client: client}, nil
+}
+
+func (s *sftpFileSystem) Stat(name string) (os.FileInfo, error)      { return s.client.Stat(name) }
+func (s *sftpFileSystem) ReadDir(name string) ([]os.FileInfo, error) { return s.client.ReadDir(name) }
+func (s *sftpFileSystem) Mkdir(name string) error                    { return s.client.Mkdir(name) }
+func (s *sftpFileSystem) MkdirAll(name string) error                 { return s.client.MkdirAll(name) }
+func (s *sftpFileSystem) Open(name string) (io.ReadCloser, error)    { return s.client.Open(name) }
+func (s *sftpFileSystem) Create(name string) (io.WriteCloser, error) { return s.client.Create(name) }
+func (s *sftpFileSystem) Remove(name string) error                   { return s.client.Remove(name) }
+
+// Rename replaces newname if it exists, which plain SFTP renames do not
+func (s *sftpFileSystem) Rename(oldname, newname string) error {
+	return s.client.PosixRename(oldname, newname)
+}
+
+// RemoveAll removes name and, if it is a directory, everything under it
+func (s *sftpFileSystem) RemoveAll(name string) error {
+	fi, err := s.client.Lstat(name)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return err
+	}
+	if fi.IsDir() {
+		fis, err := s.client.ReadDir(name)
+		if err != nil {
+			return err
+		}
+		for _, fi := range fis {
+			if err := s.RemoveAll(path.Join(name, fi.Name())); err != nil {
+				return err
+			}
+		}
+	}
+	return s.client.Remove(name)
+}
+
+func (s *sftpFileSystem) Close() error {
+	err := s.client.Close()
+	if cErr := s.conn.Close(); err == nil {
+		err = cErr
+	}
+	return err
+}
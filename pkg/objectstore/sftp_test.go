@@ -0,0 +1,101 @@
+package objectstore
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+
+	"github.com/pkg/sftp"
+	. "gopkg.in/check.v1"
+)
+
+type SFTPSuite struct{}
+
+var _ = Suite(&SFTPSuite{})
+
+// pipeFileSystem connects an SFTP client to an in-process server
+func pipeFileSystem(c *C) (fileSystem, error) {
+	cr, sw := io.Pipe()
+	sr, cw := io.Pipe()
+	server, err := sftp.NewServer(struct {
+		io.Reader
+		io.WriteCloser
+	}{sr, sw})
+	c.Assert(err, IsNil)
+	go func() {
+		// The server stops once the client closes its end of the pipe
+		server.Serve()
+		server.Close()
+	}()
+	client, err := sftp.NewClientPipe(cr, cw)
+	if err != nil {
+		return nil, err
+	}
+	return &sftpFileSystem{conn: ioutil.NopCloser(nil), client: client}, nil
+}
+
+func (s *SFTPSuite) TestObjects(c *C) {
+	ctx := context.Background()
+	p := &fsProvider{
+		root:         c.MkDir(),
+		hostEndPoint: "sftp://localhost:22",
+		dial:         func() (fileSystem, error) { return pipeFileSystem(c) },
+	}
+	b, err := GetOrCreateBucket(ctx, p, testBucketName, "")
+	c.Assert(err, IsNil)
+	c.Assert(b.String(), Equals, "sftp://localhost:22"+p.root+"/"+testBucketName+"/")
+
+	tags := map[string]string{"key": "value"}
+	err = b.PutBytes(ctx, "dir/object", []byte("data"), tags)
+	c.Assert(err, IsNil)
+	// Overwrite the existing object
+	err = b.PutBytes(ctx, "dir/object", []byte("new data"), tags)
+	c.Assert(err, IsNil)
+	data, ntags, err := b.GetBytes(ctx, "dir/object")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "new data")
+	c.Assert(ntags, DeepEquals, tags)
+
+	d, err := b.GetDirectory(ctx, "dir")
+	c.Assert(err, IsNil)
+	obs, err := d.ListObjects(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(obs, DeepEquals, []string{"object"})
+
+	err = b.DeleteAllWithPrefix(ctx, "dir")
+	c.Assert(err, IsNil)
+	ds, err := b.ListDirectories(ctx)
+	c.Assert(err, IsNil)
+	c.Assert(ds, HasLen, 0)
+}
+
+func (s *SFTPSuite) TestSFTPEndpoint(c *C) {
+	c.Assert(SFTPEndpoint("backup.example.com", 0, "srv/backups"), Equals, "sftp://backup.example.com:22/srv/backups")
+	c.Assert(SFTPEndpoint("10.0.0.1", 2222, "/backups"), Equals, "sftp://10.0.0.1:2222/backups")
+}
+
+func (s *SFTPSuite) TestNewSFTPProvider(c *C) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		config ProviderConfig
+		secret *Secret
+	}{
+		{
+			// No secret
+			config: ProviderConfig{Type: ProviderTypeSFTP, Endpoint: SFTPEndpoint("host", 22, "/")},
+		},
+		{
+			// Not an SFTP endpoint
+			config: ProviderConfig{Type: ProviderTypeSFTP, Endpoint: "https://host/path"},
+			secret: &Secret{Type: SecretTypeSSHKey, SSH: &SecretSSH{Username: "user", PrivateKey: "key"}},
+		},
+		{
+			// Invalid private key
+			config: ProviderConfig{Type: ProviderTypeSFTP, Endpoint: SFTPEndpoint("host", 22, "/"), SkipSSLVerify: true},
+			secret: &Secret{Type: SecretTypeSSHKey, SSH: &SecretSSH{Username: "user", PrivateKey: "key"}},
+		},
+	} {
+		_, err := NewProvider(ctx, tc.config, tc.secret)
+		c.Check(err, NotNil)
+	}
+}
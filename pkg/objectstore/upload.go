@@ -0,0 +1,373 @@
+package objectstore
+
+// Multipart uploads of large objects. Parts are read from the stream one at
+// a time and uploaded in parallel, so objects of unknown size can be stored
+// without buffering them whole.
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"time"
+
+	azstorage "github.com/Azure/azure-sdk-for-go/storage"
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/jpillora/backoff"
+	"github.com/pkg/errors"
+	"google.golang.org/api/googleapi"
+	gcsstorage "google.golang.org/api/storage/v1"
+
+	"github.com/kanisterio/kanister/pkg/poll"
+)
+
+const (
+	// DefaultUploadPartSize is the size of the parts of multipart uploads.
+	// S3 uploads have at most 10000 parts, so streams of unknown size up to
+	// 640GiB can be uploaded with the default.
+	DefaultUploadPartSize = 64 << 20
+	// DefaultUploadConcurrency is the number of parts uploaded in parallel
+	DefaultUploadConcurrency = 4
+	// DefaultUploadPartRetries is the number of times a failed part is
+	// uploaded again before the upload fails
+	DefaultUploadPartRetries = 3
+
+	s3MinPartSize  = 5 << 20
+	s3MaxParts     = 10000
+	azureMaxBlocks = 50000
+)
+
+// UploadOptions configure multipart uploads. Zero values select the
+// defaults.
+type UploadOptions struct {
+	// PartSize is the size in bytes of every part but the last. It is grown
+	// to fit objects of known size, but it caps streams of unknown size at
+	// 10000 parts on S3, i.e. about 640GiB with the default, and at 50000
+	// blocks on Azure. Larger streams need a larger part size.
+	PartSize int64
+	// Concurrency is the number of parts uploaded in parallel
+	Concurrency int
+	// PartRetries is the number of times a failed part is retried
+	PartRetries int
+}
+
+func (o UploadOptions) withDefaults() UploadOptions {
+	if o.PartSize <= 0 {
+		o.PartSize = DefaultUploadPartSize
+	}
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultUploadConcurrency
+	}
+	if o.PartRetries <= 0 {
+		o.PartRetries = DefaultUploadPartRetries
+	}
+	return o
+}
+
+// withSize grows the part size so that an object of the given size fits in
+// maxParts parts. A size of 0 means the size is unknown.
+func (o UploadOptions) withSize(size int64, maxParts int64) UploadOptions {
+	if min := (size + maxParts - 1) / maxParts; min > o.PartSize {
+		o.PartSize = min
+	}
+	return o
+}
+
+// PutMultipart persists the data read from r in the named object, like
+// Directory.Put. S3 and Azure objects are uploaded in parts of
+// opts.PartSize bytes, opts.Concurrency at a time, and failed parts are
+// retried without restarting the upload. GCS objects are uploaded with a
+// resumable upload in chunks of opts.PartSize bytes. Other stores stream
+// the data with Put. The size is used to pick a part size that fits the
+// object. It can be 0 if unknown, in which case the upload fails once the
+// stream exceeds the maximum number of parts of the store, e.g. after
+// 10000 × 64MiB, about 640GiB, on S3 with the default part size.
+// PutMultipart returns the version ID of the new object in versioned
+// buckets.
+func PutMultipart(ctx context.Context, dir Directory, name string, r io.Reader, size int64, tags map[string]string, opts UploadOptions) (string, error) {
+	d, ok := stowDirectory(dir)
+	if !ok {
+		return dir.Put(ctx, name, r, size, tags)
+	}
+	if d.path == "" {
+		return "", errors.New("invalid entry")
+	}
+	objName := cloudName(d.absPathName(name))
+	return d.bucket.putMultipart(ctx, objName, r, size, stringTags(sanitizeTags(tags)), opts)
+}
+
+// stowDirectory returns the stow directory implementing dir
+func stowDirectory(dir Directory) (*directory, bool) {
+	switch d := dir.(type) {
+	case *directory:
+		return d, true
+	case *bucket:
+		return d.directory, true
+	default:
+		return nil, false
+	}
+}
+
+func stringTags(tags map[string]interface{}) map[string]string {
+	sTags := make(map[string]string, len(tags))
+	for k, v := range tags {
+		sTags[k] = v.(string)
+	}
+	return sTags
+}
+
+func (b *bucket) putMultipart(ctx context.Context, objName string, r io.Reader, size int64, tags map[string]string, opts UploadOptions) (string, error) {
+	opts = opts.withDefaults()
+	switch b.config.Type {
+	case ProviderTypeS3:
+		return b.putS3(ctx, objName, r, tags, opts.withSize(size, s3MaxParts))
+	case ProviderTypeGCS:
+		return b.putGCS(ctx, objName, r, tags, opts)
+	case ProviderTypeAzure:
+		return b.putAzure(ctx, objName, r, tags, opts.withSize(size, azureMaxBlocks))
+	default:
+		_, err := b.container.Put(objName, r, size, tagsToInterface(tags))
+		return "", err
+	}
+}
+
+func tagsToInterface(tags map[string]string) map[string]interface{} {
+	iTags := make(map[string]interface{}, len(tags))
+	for k, v := range tags {
+		iTags[k] = v
+	}
+	return iTags
+}
+
+func (b *bucket) putS3(ctx context.Context, objName string, r io.Reader, tags map[string]string, opts UploadOptions) (string, error) {
+	cli, err := b.s3Client()
+	if err != nil {
+		return "", err
+	}
+	// Each part request is retried by the client
+	cli.Config.MaxRetries = aws.Int(opts.PartRetries)
+	input := &s3manager.UploadInput{
+		Bucket:   aws.String(b.container.Name()),
+		Key:      aws.String(objName),
+		Body:     r,
+		Metadata: aws.StringMap(tags),
+	}
+	if sse := b.config.Encryption; !sse.IsZero() {
+		sseType := sse.Type
+		if sseType == "" {
+			sseType = SSETypeS3
+			if sse.KMSKeyID != "" {
+				sseType = SSETypeKMS
+			}
+		}
+		input.ServerSideEncryption = aws.String(string(sseType))
+		if sseType == SSETypeKMS && sse.KMSKeyID != "" {
+			input.SSEKMSKeyId = aws.String(sse.KMSKeyID)
+		}
+	}
+	if ret := b.config.Retention; !ret.IsZero() {
+		input.ObjectLockMode = aws.String(string(ret.Mode))
+		input.ObjectLockRetainUntilDate = aws.Time(ret.until())
+	}
+	partSize := opts.PartSize
+	if partSize < s3MinPartSize {
+		partSize = s3MinPartSize
+	}
+	u := s3manager.NewUploaderWithClient(cli, func(u *s3manager.Uploader) {
+		u.PartSize = partSize
+		u.Concurrency = opts.Concurrency
+	})
+	out, err := u.UploadWithContext(ctx, input)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to upload object %s", objName)
+	}
+	return aws.StringValue(out.VersionID), nil
+}
+
+// putGCS uploads an object to GCS. The retention of GCS objects is set by
+// the retention policy of the bucket.
+func (b *bucket) putGCS(ctx context.Context, objName string, r io.Reader, tags map[string]string, opts UploadOptions) (string, error) {
+	l, err := b.gcsLocation()
+	if err != nil {
+		return "", err
+	}
+	sse := b.config.Encryption
+	if !sse.IsZero() && sse.KMSKeyID == "" {
+		return "", errors.New("KMS key required for GCS server-side encryption")
+	}
+	// Chunks of resumable uploads are retried by the client. They are
+	// uploaded in sequence.
+	call := l.Service().Objects.Insert(b.container.Name(), &gcsstorage.Object{Name: objName, Metadata: tags}).
+		Media(r, googleapi.ChunkSize(int(opts.PartSize))).
+		Context(ctx)
+	if sse.KMSKeyID != "" {
+		call = call.KmsKeyName(sse.KMSKeyID)
+	}
+	object, err := call.Do()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to upload object %s", objName)
+	}
+	if sse.KMSKeyID != "" && object.KmsKeyName == "" {
+		return "", errors.Errorf("object %s was not encrypted", objName)
+	}
+	return strconv.FormatInt(object.Generation, 10), nil
+}
+
+// putAzure uploads the blocks of a block blob in parallel and commits them.
+// Requests are authenticated with a SAS URI so that they can carry the
+// encryption scope and immutability policy.
+func (b *bucket) putAzure(ctx context.Context, objName string, r io.Reader, tags map[string]string, opts UploadOptions) (string, error) {
+	scope := b.config.Encryption.EncryptionScope
+	if !b.config.Encryption.IsZero() && scope == "" {
+		return "", errors.New("encryption scope required for Azure server-side encryption")
+	}
+	uri, err := b.azureBlobURI(objName, azstorage.BlobServiceSASPermissions{Create: true, Write: true})
+	if err != nil {
+		return "", err
+	}
+	n, err := uploadParts(ctx, r, opts, func(ctx context.Context, part int, data []byte) error {
+		q := url.Values{"comp": {"block"}, "blockid": {azureBlockID(part)}}
+		_, err := b.azurePut(ctx, uri+"&"+q.Encode(), data, scope, nil)
+		return err
+	})
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to upload object %s", objName)
+	}
+	list := azureBlockList{}
+	for i := 0; i < n; i++ {
+		list.Latest = append(list.Latest, azureBlockID(i))
+	}
+	body, err := xml.Marshal(list)
+	if err != nil {
+		return "", err
+	}
+	headers := map[string]string{}
+	for k, v := range tags {
+		headers["x-ms-meta-"+k] = v
+	}
+	if ret := b.config.Retention; !ret.IsZero() {
+		headers[azureImmutabilityUntilHeader] = ret.until().UTC().Format(http.TimeFormat)
+		headers[azureImmutabilityModeHeader] = azureImmutabilityMode(ret.Mode)
+	}
+	h, err := b.azurePut(ctx, uri+"&comp=blocklist", body, scope, headers)
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to commit object %s", objName)
+	}
+	return h.Get(azureVersionIDHeader), nil
+}
+
+type azureBlockList struct {
+	XMLName xml.Name `xml:"BlockList"`
+	Latest  []string `xml:"Latest"`
+}
+
+// azureBlockID returns the ID of the block of a part. All the IDs of a blob
+// must have the same length.
+func azureBlockID(part int) string {
+	return base64.StdEncoding.EncodeToString([]byte(fmt.Sprintf("%08d", part)))
+}
+
+// azurePut sends a PUT request and returns the response headers
+func (b *bucket) azurePut(ctx context.Context, uri string, data []byte, scope string, headers map[string]string) (http.Header, error) {
+	req, err := http.NewRequest(http.MethodPut, uri, bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	req.Header.Set("x-ms-version", azureAPIVersion)
+	if scope != "" {
+		req.Header.Set(azureEncryptionScopeHeader, scope)
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := orDefaultClient(b.hc).Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return nil, azureResponseError(resp)
+	}
+	return resp.Header, nil
+}
+
+// uploadParts reads r in parts of opts.PartSize bytes and calls put for
+// each of them, with up to opts.Concurrency calls in flight. A failed call
+// is retried opts.PartRetries times with the same data. uploadParts
+// returns the number of parts.
+func uploadParts(ctx context.Context, r io.Reader, opts UploadOptions, put func(ctx context.Context, part int, data []byte) error) (int, error) {
+	opts = opts.withDefaults()
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	var (
+		wg      sync.WaitGroup
+		errOnce sync.Once
+		partErr error
+	)
+	fail := func(err error) {
+		errOnce.Do(func() {
+			partErr = err
+			cancel()
+		})
+	}
+	// Each token is a part in flight and bounds the memory in use
+	tokens := make(chan struct{}, opts.Concurrency)
+	n := 0
+	for eof := false; !eof; {
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			if partErr != nil {
+				return n, partErr
+			}
+			return n, ctx.Err()
+		}
+		data := make([]byte, opts.PartSize)
+		m, err := io.ReadFull(r, data)
+		switch {
+		case err == io.EOF:
+			<-tokens
+			wg.Wait()
+			return n, partErr
+		case err == io.ErrUnexpectedEOF:
+			eof = true
+		case err != nil:
+			<-tokens
+			fail(errors.Wrap(err, "failed to read data"))
+			wg.Wait()
+			return n, partErr
+		}
+		wg.Add(1)
+		go func(part int, data []byte) {
+			defer wg.Done()
+			defer func() { <-tokens }()
+			if err := retryPart(ctx, opts.PartRetries, func(ctx context.Context) error {
+				return put(ctx, part, data)
+			}); err != nil {
+				fail(errors.Wrapf(err, "failed to upload part %d", part))
+			}
+		}(n, data[:m])
+		n++
+	}
+	wg.Wait()
+	return n, partErr
+}
+
+func retryPart(ctx context.Context, retries int, put func(context.Context) error) error {
+	b := backoff.Backoff{Min: 100 * time.Millisecond, Max: 10 * time.Second, Factor: 2, Jitter: true}
+	return poll.WaitWithBackoffWithRetries(ctx, b, retries, poll.IsAlwaysRetryable, func(ctx context.Context) (bool, error) {
+		if err := put(ctx); err != nil {
+			return false, err
+		}
+		return true, nil
+	})
+}
@@ -0,0 +1,145 @@
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	. "gopkg.in/check.v1"
+)
+
+type UploadSuite struct{}
+
+var _ = Suite(&UploadSuite{})
+
+func (s *UploadSuite) TestUploadParts(c *C) {
+	ctx := context.Background()
+	for _, tc := range []struct {
+		size  int
+		parts int
+	}{
+		{size: 0, parts: 0},
+		{size: 1, parts: 1},
+		{size: 10, parts: 1},
+		{size: 11, parts: 2},
+		{size: 95, parts: 10},
+	} {
+		data := bytes.Repeat([]byte("x"), tc.size)
+		var mu sync.Mutex
+		got := map[int][]byte{}
+		var inFlight, maxInFlight int32
+		n, err := uploadParts(ctx, bytes.NewReader(data), UploadOptions{PartSize: 10, Concurrency: 3}, func(ctx context.Context, part int, data []byte) error {
+			cur := atomic.AddInt32(&inFlight, 1)
+			defer atomic.AddInt32(&inFlight, -1)
+			mu.Lock()
+			defer mu.Unlock()
+			if cur > maxInFlight {
+				maxInFlight = cur
+			}
+			got[part] = data
+			return nil
+		})
+		c.Assert(err, IsNil)
+		c.Assert(n, Equals, tc.parts)
+		c.Assert(got, HasLen, tc.parts)
+		c.Assert(maxInFlight <= 3, Equals, true)
+		var out []byte
+		for i := 0; i < n; i++ {
+			out = append(out, got[i]...)
+		}
+		c.Assert(out, HasLen, tc.size)
+	}
+}
+
+func (s *UploadSuite) TestUploadPartsRetry(c *C) {
+	ctx := context.Background()
+	data := bytes.Repeat([]byte("x"), 30)
+	var mu sync.Mutex
+	attempts := map[int]int{}
+	put := func(failures int) func(context.Context, int, []byte) error {
+		return func(ctx context.Context, part int, data []byte) error {
+			mu.Lock()
+			defer mu.Unlock()
+			attempts[part]++
+			if part == 1 && attempts[part] <= failures {
+				return errors.New("transient failure")
+			}
+			return nil
+		}
+	}
+
+	// Only the failed part is uploaded again
+	n, err := uploadParts(ctx, bytes.NewReader(data), UploadOptions{PartSize: 10, PartRetries: 2}, put(2))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 3)
+	c.Assert(attempts, DeepEquals, map[int]int{0: 1, 1: 3, 2: 1})
+
+	attempts = map[int]int{}
+	_, err = uploadParts(ctx, bytes.NewReader(data), UploadOptions{PartSize: 10, PartRetries: 2}, put(3))
+	c.Assert(err, ErrorMatches, "failed to upload part 1: transient failure")
+}
+
+func (s *UploadSuite) TestPartSize(c *C) {
+	o := UploadOptions{}.withDefaults()
+	c.Assert(o.PartSize, Equals, int64(DefaultUploadPartSize))
+	c.Assert(o.withSize(0, s3MaxParts).PartSize, Equals, int64(DefaultUploadPartSize))
+	c.Assert(o.withSize(1<<40, s3MaxParts).PartSize, Equals, int64((1<<40+s3MaxParts-1)/s3MaxParts))
+}
+
+func (s *UploadSuite) TestPutMultipartFallback(c *C) {
+	ctx := context.Background()
+	p, err := NewProvider(ctx, ProviderConfig{Type: ProviderTypeMemory, Endpoint: c.TestName()}, nil)
+	c.Assert(err, IsNil)
+	defer ResetMemoryProvider(c.TestName())
+	b, err := GetOrCreateBucket(ctx, p, testBucketName, "")
+	c.Assert(err, IsNil)
+	tags := map[string]string{"key": "value"}
+	versionID, err := PutMultipart(ctx, b, "dir/object", bytes.NewBufferString("data"), 0, tags, UploadOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(versionID, Equals, "")
+	data, ntags, err := b.GetBytes(ctx, "dir/object")
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "data")
+	c.Assert(ntags, DeepEquals, tags)
+
+	// Stores without versions only serve the latest version
+	rc, ntags, err := b.GetVersion(ctx, "dir/object", "")
+	c.Assert(err, IsNil)
+	data, err = ioutil.ReadAll(rc)
+	c.Assert(err, IsNil)
+	c.Assert(rc.Close(), IsNil)
+	c.Assert(string(data), Equals, "data")
+	c.Assert(ntags, DeepEquals, tags)
+	_, _, err = b.GetVersion(ctx, "dir/object", "1")
+	c.Assert(err, NotNil)
+	c.Assert(b.DeleteVersion(ctx, "dir/object", "1"), NotNil)
+	c.Assert(b.DeleteVersion(ctx, "dir/object", ""), IsNil)
+	_, _, err = b.Get(ctx, "dir/object")
+	c.Assert(err, NotNil)
+	c.Assert(CheckBucketRetention(ctx, b), NotNil)
+}
+
+func (s *UploadSuite) TestAzureResponseError(c *C) {
+	ctx := context.Background()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(azureErrorCodeHeader, "AuthorizationPermissionMismatch")
+		w.WriteHeader(http.StatusForbidden)
+		if r.Method == http.MethodPut {
+			fmt.Fprint(w, `<?xml version="1.0" encoding="utf-8"?><Error><Code>AuthorizationPermissionMismatch</Code><Message>This request is not authorized to perform this operation using this permission.
+RequestId:1a2b
+Time:2021-06-04T10:00:00.0000000Z</Message></Error>`)
+		}
+	}))
+	defer srv.Close()
+	b := &bucket{}
+	_, err := b.azurePut(ctx, srv.URL, []byte("data"), "", nil)
+	c.Assert(err, ErrorMatches, "403 Forbidden: AuthorizationPermissionMismatch: This request is not authorized to perform this operation using this permission. RequestId:1a2b Time:.*")
+	_, err = b.azureHead(ctx, http.MethodHead, srv.URL)
+	c.Assert(err, ErrorMatches, "403 Forbidden: AuthorizationPermissionMismatch")
+}
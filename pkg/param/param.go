@@ -2,6 +2,7 @@ package param
 
 import (
 	"context"
+	"net/url"
 	"strings"
 	"time"
 
@@ -65,9 +66,41 @@ type NamespaceParams struct {
 
 // Profile contains where to store artifacts and how to access them.
 type Profile struct {
+	// Namespace of the Profile resource
+	Namespace     string `json:",omitempty"`
 	Location      crv1alpha1.Location
 	Credential    Credential
 	SkipSSLVerify bool
+	Encryption    *Encryption `json:",omitempty"`
+	// BandwidthLimit is the default limit of data transfers
+	BandwidthLimit crv1alpha1.BandwidthLimit `json:",omitempty"`
+	// CACert is the PEM encoded bundle of additionally trusted CAs
+	CACert string `json:",omitempty"`
+	// Proxy is the URL of the proxy through which the location is reached
+	Proxy string `json:",omitempty"`
+	// RepositoryPasswordSecret stores the passwords of restic repositories
+	RepositoryPasswordSecret *crv1alpha1.ObjectReference `json:",omitempty"`
+	// DataMover selects the backend of the data functions
+	DataMover string `json:",omitempty"`
+}
+
+// ProxyPassword returns the password in the userinfo of the proxy URL, which
+// must be kept out of logs and errors like the credential secret.
+func (p *Profile) ProxyPassword() string {
+	u, err := url.Parse(p.Proxy)
+	if err != nil || u.User == nil {
+		return ""
+	}
+	pw, _ := u.User.Password()
+	return pw
+}
+
+// Encryption contains the keys used for client-side encryption of artifacts.
+type Encryption struct {
+	// KeyID is the key that wraps the data keys of new artifacts
+	KeyID string
+	// Keys are all the keys that can unwrap data keys, by key ID
+	Keys map[string][]byte
 }
 
 // CredentialType
@@ -113,7 +146,7 @@ func New(ctx context.Context, cli kubernetes.Interface, crCli versioned.Interfac
 	if err != nil {
 		return nil, err
 	}
-	prof, err := fetchProfile(ctx, cli, crCli, as.Profile)
+	prof, err := FetchProfile(ctx, cli, crCli, as.Profile)
 	if err != nil {
 		return nil, err
 	}
@@ -163,7 +196,9 @@ func New(ctx context.Context, cli kubernetes.Interface, crCli versioned.Interfac
 	return &tp, nil
 }
 
-func fetchProfile(ctx context.Context, cli kubernetes.Interface, crCli versioned.Interface, ref *crv1alpha1.ObjectReference) (*Profile, error) {
+// FetchProfile returns the referenced Profile with its credentials and
+// encryption keys
+func FetchProfile(ctx context.Context, cli kubernetes.Interface, crCli versioned.Interface, ref *crv1alpha1.ObjectReference) (*Profile, error) {
 	if ref == nil {
 		return nil, errors.New("Cannot execute action without a profile. Specify a profile in the action set")
 	}
@@ -171,14 +206,88 @@ func fetchProfile(ctx context.Context, cli kubernetes.Interface, crCli versioned
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	cred, err := fetchCredential(ctx, cli, p.Credential)
+	cred := &Credential{}
+	// Filesystem locations are accessed through a mounted volume and do not
+	// require credentials.
+	if p.Location.Type != crv1alpha1.LocationTypeFileSystem || p.Credential.Type != "" {
+		cred, err = fetchCredential(ctx, cli, p.Credential)
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+	}
+	var enc *Encryption
+	if p.Encryption != nil {
+		enc, err = fetchEncryption(ctx, cli, p.Encryption)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var caCert string
+	if p.CABundle != nil {
+		caCert, err = FetchCABundle(ctx, cli, p.CABundle)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rps := p.RepositoryPasswordSecret
+	if rps != nil && rps.Namespace == "" {
+		// The Secret defaults to the namespace of the Profile
+		ref := *rps
+		ref.Namespace = p.GetNamespace()
+		rps = &ref
+	}
+	return &Profile{
+		Namespace:                p.GetNamespace(),
+		Location:                 p.Location,
+		Credential:               *cred,
+		SkipSSLVerify:            p.SkipSSLVerify,
+		Encryption:               enc,
+		BandwidthLimit:           p.BandwidthLimit,
+		CACert:                   caCert,
+		Proxy:                    p.Proxy,
+		RepositoryPasswordSecret: rps,
+		DataMover:                p.DataMover,
+	}, nil
+}
+
+// FetchCABundle returns the PEM encoded CA certificates referenced by the
+// bundle
+func FetchCABundle(ctx context.Context, cli kubernetes.Interface, b *crv1alpha1.CABundle) (string, error) {
+	switch {
+	case b.Secret != nil:
+		s, err := cli.CoreV1().Secrets(b.Secret.Namespace).Get(b.Secret.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if _, ok := s.Data[b.Key]; !ok {
+			return "", errors.Errorf("CA bundle '%s' not found in secret '%s:%s'", b.Key, s.GetNamespace(), s.GetName())
+		}
+		return string(s.Data[b.Key]), nil
+	case b.ConfigMap != nil:
+		cm, err := cli.CoreV1().ConfigMaps(b.ConfigMap.Namespace).Get(b.ConfigMap.Name, metav1.GetOptions{})
+		if err != nil {
+			return "", errors.WithStack(err)
+		}
+		if _, ok := cm.Data[b.Key]; !ok {
+			return "", errors.Errorf("CA bundle '%s' not found in config map '%s:%s'", b.Key, cm.GetNamespace(), cm.GetName())
+		}
+		return cm.Data[b.Key], nil
+	default:
+		return "", errors.New("CA bundle must reference a secret or a config map")
+	}
+}
+
+func fetchEncryption(ctx context.Context, cli kubernetes.Interface, e *crv1alpha1.ClientSideEncryption) (*Encryption, error) {
+	s, err := cli.CoreV1().Secrets(e.Secret.Namespace).Get(e.Secret.Name, metav1.GetOptions{})
 	if err != nil {
 		return nil, errors.WithStack(err)
 	}
-	return &Profile{
-		Location:      p.Location,
-		Credential:    *cred,
-		SkipSSLVerify: p.SkipSSLVerify,
+	if _, ok := s.Data[e.KeyID]; !ok {
+		return nil, errors.Errorf("Encryption key '%s' not found in secret '%s:%s'", e.KeyID, s.GetNamespace(), s.GetName())
+	}
+	return &Encryption{
+		KeyID: e.KeyID,
+		Keys:  s.Data,
 	}, nil
 }
 
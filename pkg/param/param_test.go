@@ -480,7 +480,8 @@ func (s *ParamsSuite) TestProfile(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(tp.Profile, NotNil)
 	c.Assert(tp.Profile, DeepEquals, &Profile{
-		Location: crv1alpha1.Location{},
+		Namespace: s.namespace,
+		Location:  crv1alpha1.Location{},
 		Credential: Credential{
 			Type: CredentialTypeKeyPair,
 			KeyPair: &KeyPair{
@@ -603,3 +604,50 @@ func (s *ParamsSuite) TestRenderingPhaseParams(c *C) {
 		c.Assert(buf.String(), Equals, tc.expected)
 	}
 }
+
+func (s *ParamsSuite) TestFetchCABundle(c *C) {
+	secret := &v1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca-secret",
+			Namespace: s.namespace,
+		},
+		Data: map[string][]byte{"ca.crt": []byte("secret-pem")},
+	}
+	cm := &v1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "ca-config",
+			Namespace: s.namespace,
+		},
+		Data: map[string]string{"ca.crt": "config-pem"},
+	}
+	cli := fake.NewSimpleClientset(secret, cm)
+	ctx := context.Background()
+	for _, tc := range []struct {
+		bundle  *crv1alpha1.CABundle
+		cert    string
+		checker Checker
+	}{
+		{
+			bundle:  &crv1alpha1.CABundle{Secret: &crv1alpha1.ObjectReference{Name: "ca-secret", Namespace: s.namespace}, Key: "ca.crt"},
+			cert:    "secret-pem",
+			checker: IsNil,
+		},
+		{
+			bundle:  &crv1alpha1.CABundle{ConfigMap: &crv1alpha1.ObjectReference{Name: "ca-config", Namespace: s.namespace}, Key: "ca.crt"},
+			cert:    "config-pem",
+			checker: IsNil,
+		},
+		{
+			bundle:  &crv1alpha1.CABundle{ConfigMap: &crv1alpha1.ObjectReference{Name: "ca-config", Namespace: s.namespace}, Key: "missing"},
+			checker: NotNil,
+		},
+		{
+			bundle:  &crv1alpha1.CABundle{Key: "ca.crt"},
+			checker: NotNil,
+		},
+	} {
+		cert, err := FetchCABundle(ctx, cli, tc.bundle)
+		c.Assert(err, tc.checker)
+		c.Assert(cert, Equals, tc.cert)
+	}
+}
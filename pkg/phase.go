@@ -6,6 +6,7 @@ import (
 	"github.com/pkg/errors"
 
 	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/format"
 	"github.com/kanisterio/kanister/pkg/param"
 )
 
@@ -51,8 +52,22 @@ func (p *Phase) Exec(ctx context.Context, bp crv1alpha1.Blueprint, action string
 			p.args = args
 		}
 	}
-	// Execute the function
-	return p.f.Exec(ctx, tp, p.args)
+	// Execute the function. Errors often quote the output of commands, which
+	// must not leak the credentials of the Profile.
+	out, err := p.f.Exec(ctx, tp, p.args)
+	return out, profileRedactor(tp.Profile).RedactError(err)
+}
+
+// profileRedactor returns a Redactor for the credentials of the Profile
+func profileRedactor(profile *param.Profile) *format.Redactor {
+	if profile == nil {
+		return format.NewRedactor()
+	}
+	values := []string{profile.ProxyPassword()}
+	if profile.Credential.KeyPair != nil {
+		values = append(values, profile.Credential.KeyPair.Secret)
+	}
+	return format.NewRedactor(values...)
 }
 
 // GetPhases renders the returns a list of Phases with pre-rendered arguments.
@@ -0,0 +1,261 @@
+package restic
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	messageTypeStatus  = "status"
+	messageTypeSummary = "summary"
+	structTypeSnapshot = "snapshot"
+	shortIDLength      = 8
+)
+
+// BackupStatus is the progress of a backup, reported periodically by
+// restic backup --json unless --quiet is set
+type BackupStatus struct {
+	SecondsElapsed   int64    `json:"seconds_elapsed"`
+	SecondsRemaining int64    `json:"seconds_remaining"`
+	PercentDone      float64  `json:"percent_done"`
+	TotalFiles       int64    `json:"total_files"`
+	FilesDone        int64    `json:"files_done"`
+	TotalBytes       int64    `json:"total_bytes"`
+	BytesDone        int64    `json:"bytes_done"`
+	ErrorCount       int64    `json:"error_count"`
+	CurrentFiles     []string `json:"current_files"`
+}
+
+// BackupSummary describes a completed backup, reported at its end by
+// restic backup --json
+type BackupSummary struct {
+	FilesNew            int64   `json:"files_new"`
+	FilesChanged        int64   `json:"files_changed"`
+	FilesUnmodified     int64   `json:"files_unmodified"`
+	DirsNew             int64   `json:"dirs_new"`
+	DirsChanged         int64   `json:"dirs_changed"`
+	DirsUnmodified      int64   `json:"dirs_unmodified"`
+	DataBlobs           int64   `json:"data_blobs"`
+	TreeBlobs           int64   `json:"tree_blobs"`
+	DataAdded           int64   `json:"data_added"`
+	TotalFilesProcessed int64   `json:"total_files_processed"`
+	TotalBytesProcessed int64   `json:"total_bytes_processed"`
+	TotalDuration       float64 `json:"total_duration"`
+	SnapshotID          string  `json:"snapshot_id"`
+}
+
+// ShortSnapshotID returns the abbreviated ID of the snapshot that restic
+// prints and accepts in place of the full ID
+func (s BackupSummary) ShortSnapshotID() string {
+	if len(s.SnapshotID) > shortIDLength {
+		return s.SnapshotID[:shortIDLength]
+	}
+	return s.SnapshotID
+}
+
+// ParseBackupOutput parses the output of restic backup --json. It calls
+// progress, if it is not nil, with each status message and returns the
+// summary. Lines that are not JSON messages are ignored.
+func ParseBackupOutput(output string, progress func(BackupStatus)) (*BackupSummary, error) {
+	var summary *BackupSummary
+	s := bufio.NewScanner(strings.NewReader(output))
+	// Status messages list the files in progress and can be long
+	s.Buffer(nil, 1024*1024)
+	for s.Scan() {
+		l := strings.TrimSpace(s.Text())
+		if !strings.HasPrefix(l, "{") {
+			continue
+		}
+		var msg struct {
+			MessageType string `json:"message_type"`
+		}
+		if err := json.Unmarshal([]byte(l), &msg); err != nil {
+			continue
+		}
+		switch msg.MessageType {
+		case messageTypeStatus:
+			if progress == nil {
+				continue
+			}
+			var status BackupStatus
+			if err := json.Unmarshal([]byte(l), &status); err != nil {
+				return nil, errors.Wrap(err, "Failed to unmarshall backup status")
+			}
+			progress(status)
+		case messageTypeSummary:
+			summary = &BackupSummary{}
+			if err := json.Unmarshal([]byte(l), summary); err != nil {
+				return nil, errors.Wrap(err, "Failed to unmarshall backup summary")
+			}
+		}
+	}
+	if err := s.Err(); err != nil {
+		return nil, errors.Wrap(err, "Failed to read backup output")
+	}
+	if summary == nil || summary.SnapshotID == "" {
+		return nil, errors.New("Backup summary not found")
+	}
+	return summary, nil
+}
+
+// Node is a file, directory or link in a snapshot, as listed by
+// restic ls --json
+type Node struct {
+	Name    string      `json:"name"`
+	Type    string      `json:"type"`
+	Path    string      `json:"path"`
+	UID     uint32      `json:"uid"`
+	GID     uint32      `json:"gid"`
+	Size    uint64      `json:"size,omitempty"`
+	Mode    os.FileMode `json:"mode,omitempty"`
+	ModTime time.Time   `json:"mtime"`
+}
+
+// Listing is the content of a snapshot
+type Listing struct {
+	SnapshotID string    `json:"snapshotID"`
+	Time       time.Time `json:"time"`
+	Paths      []string  `json:"paths"`
+	Nodes      []Node    `json:"nodes"`
+	// Truncated is set when the snapshot has more nodes than were listed
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// ParseLsOutput parses the output of restic ls --json, which starts with the
+// snapshot and continues with a node per line. If maxNodes is positive, at
+// most maxNodes nodes are kept and the listing is marked as truncated.
+func ParseLsOutput(output string, maxNodes int) (*Listing, error) {
+	w := NewLsWriter(maxNodes)
+	if _, err := io.WriteString(w, output); err != nil {
+		return nil, err
+	}
+	return w.Listing()
+}
+
+// maxLsLineLength is the longest line of restic ls --json that is parsed
+const maxLsLineLength = 1024 * 1024
+
+// LsWriter parses the output of restic ls --json as it is written, so that
+// the output of a large snapshot need not be held in memory. If maxNodes is
+// positive, at most maxNodes nodes are kept and the rest of the output is
+// discarded without being parsed.
+type LsWriter struct {
+	maxNodes int
+	listing  *Listing
+	line     []byte
+	done     bool
+	err      error
+}
+
+// NewLsWriter returns an LsWriter that keeps at most maxNodes nodes, or all
+// of them if maxNodes is not positive.
+func NewLsWriter(maxNodes int) *LsWriter {
+	return &LsWriter{maxNodes: maxNodes}
+}
+
+// Write parses the complete lines in p. It always consumes all of p, so the
+// command writing the output is not interrupted; a parse error is returned
+// by Listing.
+func (w *LsWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for !w.done && len(p) > 0 {
+		i := bytes.IndexByte(p, '\n')
+		if i < 0 {
+			w.line = append(w.line, p...)
+			if len(w.line) > maxLsLineLength {
+				w.fail(errors.New("Failed to read ls output: line too long"))
+			}
+			break
+		}
+		w.line = append(w.line, p[:i]...)
+		p = p[i+1:]
+		w.parseLine()
+	}
+	return n, nil
+}
+
+// Listing parses the last line, if it was not terminated, and returns the
+// listing.
+func (w *LsWriter) Listing() (*Listing, error) {
+	if !w.done && len(w.line) > 0 {
+		w.parseLine()
+	}
+	if w.err != nil {
+		return nil, w.err
+	}
+	if w.listing == nil {
+		return nil, errors.New("Snapshot not found")
+	}
+	return w.listing, nil
+}
+
+func (w *LsWriter) fail(err error) {
+	w.err = err
+	w.done = true
+	w.line = nil
+}
+
+func (w *LsWriter) parseLine() {
+	l := bytes.TrimSpace(w.line)
+	w.line = w.line[:0]
+	if !bytes.HasPrefix(l, []byte("{")) {
+		return
+	}
+	if w.listing == nil {
+		var snap struct {
+			StructType string    `json:"struct_type"`
+			Tree       string    `json:"tree"`
+			ID         string    `json:"id"`
+			Time       time.Time `json:"time"`
+			Paths      []string  `json:"paths"`
+		}
+		if err := json.Unmarshal(l, &snap); err != nil {
+			w.fail(errors.Wrap(err, "Failed to unmarshall snapshot from lsCommand"))
+			return
+		}
+		if snap.StructType != structTypeSnapshot && snap.Tree == "" {
+			w.fail(errors.New("Snapshot not found"))
+			return
+		}
+		w.listing = &Listing{SnapshotID: snap.ID, Time: snap.Time, Paths: snap.Paths, Nodes: []Node{}}
+		return
+	}
+	if w.maxNodes > 0 && len(w.listing.Nodes) == w.maxNodes {
+		w.listing.Truncated = true
+		w.done = true
+		w.line = nil
+		return
+	}
+	var n Node
+	if err := json.Unmarshal(l, &n); err != nil {
+		w.fail(errors.Wrap(err, "Failed to unmarshall node from lsCommand"))
+		return
+	}
+	w.listing.Nodes = append(w.listing.Nodes, n)
+}
+
+// Snapshot is a backup in a repository, as listed by restic snapshots --json
+type Snapshot struct {
+	ID       string    `json:"id"`
+	ShortID  string    `json:"short_id"`
+	Time     time.Time `json:"time"`
+	Hostname string    `json:"hostname"`
+	Paths    []string  `json:"paths"`
+	Tags     []string  `json:"tags"`
+}
+
+// ParseSnapshotsOutput parses the output of restic snapshots --json
+func ParseSnapshotsOutput(output string) ([]Snapshot, error) {
+	var snapshots []Snapshot
+	if err := json.Unmarshal([]byte(output), &snapshots); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshall output from snapshotCommand")
+	}
+	return snapshots, nil
+}
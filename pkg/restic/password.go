@@ -0,0 +1,117 @@
+package restic
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+
+	"github.com/pkg/errors"
+	"k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+
+	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+)
+
+const (
+	// RepositoryPasswordKeyPrefix is the prefix of the keys of repository
+	// passwords in a Secret
+	RepositoryPasswordKeyPrefix = "repository-"
+	repositoryPasswordLength    = 32
+)
+
+// RepositoryPasswordKey returns the key under which the password of the
+// repository is stored in a Secret. Repository paths are not valid keys,
+// so the key is derived from the SHA-256 of the path.
+func RepositoryPasswordKey(repository string) string {
+	sum := sha256.Sum256([]byte(repository))
+	return RepositoryPasswordKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// RepositoryPassword returns the password of the repository from the
+// Secret. It returns an error if the Secret has none.
+func RepositoryPassword(cli kubernetes.Interface, ref crv1alpha1.ObjectReference, repository string) (string, error) {
+	s, err := cli.CoreV1().Secrets(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+	if err != nil {
+		return "", errors.Wrapf(err, "Failed to get repository password secret '%s:%s'", ref.Namespace, ref.Name)
+	}
+	pw, ok := s.Data[RepositoryPasswordKey(repository)]
+	if !ok {
+		return "", errors.Errorf("Password of repository '%s' not found in secret '%s:%s'", repository, ref.Namespace, ref.Name)
+	}
+	return string(pw), nil
+}
+
+// LookupRepositoryPassword returns the password of the repository from the
+// Secret and whether the Secret has one. A missing Secret has no passwords.
+func LookupRepositoryPassword(cli kubernetes.Interface, ref crv1alpha1.ObjectReference, repository string) (string, bool, error) {
+	s, err := cli.CoreV1().Secrets(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return "", false, nil
+	case err != nil:
+		return "", false, errors.Wrapf(err, "Failed to get repository password secret '%s:%s'", ref.Namespace, ref.Name)
+	}
+	pw, ok := s.Data[RepositoryPasswordKey(repository)]
+	return string(pw), ok, nil
+}
+
+// NewRepositoryPassword returns a random password for a new repository
+func NewRepositoryPassword() (string, error) {
+	return newPassword()
+}
+
+// StoreRepositoryPassword stores the password of the repository in the
+// Secret. The Secret is created if it does not exist. It fails if the Secret
+// has a different password for the repository, so it must only be called
+// once the repository has been created with the password.
+func StoreRepositoryPassword(cli kubernetes.Interface, ref crv1alpha1.ObjectReference, repository, password string) error {
+	key := RepositoryPasswordKey(repository)
+	// Concurrent functions may add the passwords of other repositories to
+	// the same Secret
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		s, err := cli.CoreV1().Secrets(ref.Namespace).Get(ref.Name, metav1.GetOptions{})
+		switch {
+		case apierrors.IsNotFound(err):
+			s = &v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ref.Name,
+					Namespace: ref.Namespace,
+				},
+				Type: v1.SecretTypeOpaque,
+				Data: map[string][]byte{key: []byte(password)},
+			}
+			_, err = cli.CoreV1().Secrets(ref.Namespace).Create(s)
+			if apierrors.IsAlreadyExists(err) {
+				// Retry with the Secret created in the meantime
+				return apierrors.NewConflict(v1.Resource("secrets"), ref.Name, err)
+			}
+			return err
+		case err != nil:
+			return err
+		}
+		if pw, ok := s.Data[key]; ok {
+			if string(pw) != password {
+				return errors.New("Secret has a different password for the repository")
+			}
+			return nil
+		}
+		if s.Data == nil {
+			s.Data = make(map[string][]byte, 1)
+		}
+		s.Data[key] = []byte(password)
+		_, err = cli.CoreV1().Secrets(ref.Namespace).Update(s)
+		return err
+	})
+	return errors.Wrapf(err, "Failed to store password of repository '%s' in secret '%s:%s'", repository, ref.Namespace, ref.Name)
+}
+
+func newPassword() (string, error) {
+	b := make([]byte, repositoryPasswordLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", errors.Wrap(err, "Failed to generate repository password")
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,58 @@
+package restic
+
+import (
+	. "gopkg.in/check.v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+)
+
+type PasswordSuite struct{}
+
+var _ = Suite(&PasswordSuite{})
+
+func (s *PasswordSuite) TestRepositoryPassword(c *C) {
+	cli := fake.NewSimpleClientset()
+	ref := v1alpha1.ObjectReference{Name: "passwords", Namespace: "kanister"}
+
+	_, err := RepositoryPassword(cli, ref, "bucket/repo")
+	c.Assert(err, NotNil)
+
+	pw, ok, err := LookupRepositoryPassword(cli, ref, "bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, false)
+	c.Assert(pw, Equals, "")
+
+	// The Secret is created with the password of the first repository
+	pw, err = NewRepositoryPassword()
+	c.Assert(err, IsNil)
+	c.Assert(pw, HasLen, 2*repositoryPasswordLength)
+	c.Assert(pw, Not(Equals), GeneratePassword())
+	c.Assert(StoreRepositoryPassword(cli, ref, "bucket/repo", pw), IsNil)
+	c.Assert(StoreRepositoryPassword(cli, ref, "bucket/repo", pw), IsNil)
+	got, err := RepositoryPassword(cli, ref, "bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(got, Equals, pw)
+	got, ok, err = LookupRepositoryPassword(cli, ref, "bucket/repo")
+	c.Assert(err, IsNil)
+	c.Assert(ok, Equals, true)
+	c.Assert(got, Equals, pw)
+
+	// The password of a repository is never replaced
+	other, err := NewRepositoryPassword()
+	c.Assert(err, IsNil)
+	c.Assert(other, Not(Equals), pw)
+	c.Assert(StoreRepositoryPassword(cli, ref, "bucket/repo", other), NotNil)
+
+	// Other repositories get their own passwords
+	c.Assert(StoreRepositoryPassword(cli, ref, "bucket/other", other), IsNil)
+	_, err = RepositoryPassword(cli, ref, "bucket/missing")
+	c.Assert(err, NotNil)
+
+	secret, err := cli.CoreV1().Secrets("kanister").Get("passwords", metav1.GetOptions{})
+	c.Assert(err, IsNil)
+	c.Assert(secret.Data, HasLen, 2)
+	c.Assert(string(secret.Data[RepositoryPasswordKey("bucket/repo")]), Equals, pw)
+	c.Assert(string(secret.Data[RepositoryPasswordKey("bucket/other")]), Equals, other)
+}
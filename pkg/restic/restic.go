@@ -1,9 +1,17 @@
 package restic
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path"
 	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -19,92 +27,301 @@ import (
 
 const (
 	GoogleCloudCredsFilePath = "/tmp/creds.txt"
+	SSHKeyFilePath           = "/tmp/ssh_key"
+	SSHKnownHostsFilePath    = "/tmp/known_hosts"
+	CACertFilePath           = "/tmp/ca.crt"
 )
 
 func shCommand(command string) []string {
 	return []string{"bash", "-o", "errexit", "-o", "pipefail", "-c", command}
 }
 
-// BackupCommandByID returns restic backup command
-func BackupCommandByID(profile *param.Profile, repository, pathToBackup, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
-	cmd = append(cmd, "backup", pathToBackup)
+// BackupCommandByID returns restic backup command. The command only prints
+// the summary of the backup; --quiet drops the periodic status messages.
+func BackupCommandByID(profile *param.Profile, repository, pathToBackup string) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "backup", "--json", "--quiet", pathToBackup)
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // BackupCommandByTag returns restic backup command with tag
-func BackupCommandByTag(profile *param.Profile, repository, backupTag, includePath, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
-	cmd = append(cmd, "backup", "--tag", backupTag, includePath)
+func BackupCommandByTag(profile *param.Profile, repository, backupTag, includePath string) []string {
+	return BackupCommandByTagWithOptions(profile, repository, backupTag, []string{includePath}, BackupOptions{})
+}
+
+// BackupOptions select the files that are backed up
+type BackupOptions struct {
+	// Exclude skips the files that match one of the patterns
+	Exclude []string
+	// ExcludeCaches skips the directories that contain a CACHEDIR.TAG file
+	ExcludeCaches bool
+	// OneFileSystem does not cross the file systems of the backed up paths
+	OneFileSystem bool
+}
+
+func (o BackupOptions) args() []string {
+	var args []string
+	for _, p := range o.Exclude {
+		args = append(args, "--exclude", shellQuote(p))
+	}
+	if o.ExcludeCaches {
+		args = append(args, "--exclude-caches")
+	}
+	if o.OneFileSystem {
+		args = append(args, "--one-file-system")
+	}
+	return args
+}
+
+// BackupCommandByTagWithOptions returns restic backup command with tag that
+// backs up the files under includePaths selected by opts. Like
+// BackupCommandByID, it only prints the summary of the backup.
+func BackupCommandByTagWithOptions(profile *param.Profile, repository, backupTag string, includePaths []string, opts BackupOptions) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "backup", "--json", "--quiet", "--tag", backupTag)
+	cmd = append(cmd, opts.args()...)
+	for _, p := range includePaths {
+		cmd = append(cmd, shellQuote(p))
+	}
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // RestoreCommandByID returns restic restore command with snapshotID as the identifier
-func RestoreCommandByID(profile *param.Profile, repository, id, restorePath, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func RestoreCommandByID(profile *param.Profile, repository, id, restorePath string) []string {
+	return RestoreCommandByIDWithOptions(profile, repository, id, restorePath, RestoreOptions{})
+}
+
+// RestoreCommandByTag returns restic restore command with tag as the identifier
+func RestoreCommandByTag(profile *param.Profile, repository, tag, restorePath string) []string {
+	return RestoreCommandByTagWithOptions(profile, repository, tag, restorePath, RestoreOptions{})
+}
+
+// RestoreOptions select the files of a snapshot that are restored
+type RestoreOptions struct {
+	// Include restores only the files that match one of the patterns
+	Include []string
+	// Exclude skips the files that match one of the patterns
+	Exclude []string
+	// Verify reads the restored files back and checks their content
+	Verify bool
+}
+
+func (o RestoreOptions) args() []string {
+	var args []string
+	for _, p := range o.Include {
+		args = append(args, "--include", shellQuote(p))
+	}
+	for _, p := range o.Exclude {
+		args = append(args, "--exclude", shellQuote(p))
+	}
+	if o.Verify {
+		args = append(args, "--verify")
+	}
+	return args
+}
+
+// RestoreCommandByIDWithOptions returns restic restore command with
+// snapshotID as the identifier that restores the files selected by opts
+func RestoreCommandByIDWithOptions(profile *param.Profile, repository, id, restorePath string, opts RestoreOptions) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "restore", id, "--target", restorePath)
+	cmd = append(cmd, opts.args()...)
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
-// RestoreCommandByTag returns restic restore command with tag as the identifier
-func RestoreCommandByTag(profile *param.Profile, repository, tag, restorePath, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+// RestoreCommandByTagWithOptions returns restic restore command with tag as
+// the identifier that restores the files selected by opts
+func RestoreCommandByTagWithOptions(profile *param.Profile, repository, tag, restorePath string, opts RestoreOptions) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "restore", "--tag", tag, "latest", "--target", restorePath)
+	cmd = append(cmd, opts.args()...)
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // SnapshotsCommand returns restic snapshots command
-func SnapshotsCommand(profile *param.Profile, repository, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func SnapshotsCommand(profile *param.Profile, repository string) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "snapshots", "--json")
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // SnapshotsCommandByTag returns restic snapshots command
-func SnapshotsCommandByTag(profile *param.Profile, repository, tag, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func SnapshotsCommandByTag(profile *param.Profile, repository, tag string) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "snapshots", "--tag", tag, "--json")
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // InitCommand returns restic init command
-func InitCommand(profile *param.Profile, repository, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func InitCommand(profile *param.Profile, repository string) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "init")
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // ForgetCommandByTag returns restic forget command
-func ForgetCommandByTag(profile *param.Profile, repository, tag, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func ForgetCommandByTag(profile *param.Profile, repository, tag string) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "forget", "--tag", tag)
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // ForgetCommandByID returns restic forget command
-func ForgetCommandByID(profile *param.Profile, repository, id, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func ForgetCommandByID(profile *param.Profile, repository, id string) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "forget", id)
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
 // PruneCommand returns restic prune command
-func PruneCommand(profile *param.Profile, repository, encryptionKey string) []string {
-	cmd := resticArgs(profile, repository, encryptionKey)
+func PruneCommand(profile *param.Profile, repository string) []string {
+	cmd := resticArgs(profile, repository)
 	cmd = append(cmd, "prune")
 	command := strings.Join(cmd, " ")
 	return shCommand(command)
 }
 
+// LsCommandByID returns restic ls command that lists the files of the
+// snapshot with the ID. If paths are given, only the files under them are
+// listed.
+func LsCommandByID(profile *param.Profile, repository, id string, paths []string) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "ls", "--json", shellQuote(id))
+	for _, p := range paths {
+		cmd = append(cmd, shellQuote(p))
+	}
+	command := strings.Join(cmd, " ")
+	return shCommand(command)
+}
+
+// LsCommandByTag returns restic ls command that lists the files of the latest
+// snapshot with the tag. If paths are given, only the files under them are
+// listed.
+func LsCommandByTag(profile *param.Profile, repository, tag string, paths []string) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "ls", "--json", "--tag", shellQuote(tag), "latest")
+	for _, p := range paths {
+		cmd = append(cmd, shellQuote(p))
+	}
+	command := strings.Join(cmd, " ")
+	return shCommand(command)
+}
+
+// CheckCommand returns restic check command. If readDataSubset is set, e.g.
+// to "1/5", that part of the data of the repository is read and verified.
+func CheckCommand(profile *param.Profile, repository, readDataSubset string) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "check")
+	if readDataSubset != "" {
+		cmd = append(cmd, "--read-data-subset", shellQuote(readDataSubset))
+	}
+	command := strings.Join(cmd, " ")
+	return shCommand(command)
+}
+
+var (
+	readDataSubsetPattern = regexp.MustCompile(`^([1-9][0-9]{0,8})/([1-9][0-9]{0,8})$`)
+	keepWithinPattern     = regexp.MustCompile(`^([0-9]+y)?([0-9]+m)?([0-9]+d)?([0-9]+h)?$`)
+)
+
+// ValidateReadDataSubset returns an error if s is not a subset of the data
+// that restic check can read, e.g. "1/5"
+func ValidateReadDataSubset(s string) error {
+	if m := readDataSubsetPattern.FindStringSubmatch(s); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		t, _ := strconv.Atoi(m[2])
+		if n <= t {
+			return nil
+		}
+	}
+	return errors.Errorf("invalid data subset '%s', expected n/t with 1 <= n <= t", s)
+}
+
+// KeepPolicy selects the snapshots that are kept by forget
+type KeepPolicy struct {
+	Last    int
+	Hourly  int
+	Daily   int
+	Weekly  int
+	Monthly int
+	Yearly  int
+	// Within keeps the snapshots made within a duration of the latest
+	// snapshot, e.g. "2y5m7d3h"
+	Within string
+}
+
+// Validate returns an error if Within is not a restic duration
+func (p KeepPolicy) Validate() error {
+	if p.Within != "" && !keepWithinPattern.MatchString(p.Within) {
+		return errors.Errorf("invalid duration '%s', expected e.g. 2y5m7d3h", p.Within)
+	}
+	return nil
+}
+
+// IsEmpty returns true if the policy does not keep any snapshot
+func (p KeepPolicy) IsEmpty() bool {
+	return p == KeepPolicy{}
+}
+
+func (p KeepPolicy) args() []string {
+	var args []string
+	for _, o := range []struct {
+		option string
+		n      int
+	}{
+		{"--keep-last", p.Last},
+		{"--keep-hourly", p.Hourly},
+		{"--keep-daily", p.Daily},
+		{"--keep-weekly", p.Weekly},
+		{"--keep-monthly", p.Monthly},
+		{"--keep-yearly", p.Yearly},
+	} {
+		if o.n > 0 {
+			args = append(args, o.option, strconv.Itoa(o.n))
+		}
+	}
+	if p.Within != "" {
+		args = append(args, "--keep-within", shellQuote(p.Within))
+	}
+	return args
+}
+
+// ForgetAndPruneCommand returns restic forget command that removes the
+// snapshots not kept by the policy and prunes their data
+func ForgetAndPruneCommand(profile *param.Profile, repository string, policy KeepPolicy) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "forget", "--prune")
+	cmd = append(cmd, policy.args()...)
+	command := strings.Join(cmd, " ")
+	return shCommand(command)
+}
+
+// UnlockCommand returns restic unlock command, which removes stale locks
+func UnlockCommand(profile *param.Profile, repository string) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "unlock")
+	command := strings.Join(cmd, " ")
+	return shCommand(command)
+}
+
+// StatsCommand returns restic stats command for the size of the data stored
+// in the repository
+func StatsCommand(profile *param.Profile, repository string) []string {
+	cmd := resticArgs(profile, repository)
+	cmd = append(cmd, "stats", "--mode", "raw-data", "--json")
+	command := strings.Join(cmd, " ")
+	return shCommand(command)
+}
+
 const (
 	ResticPassword   = "RESTIC_PASSWORD"
 	ResticRepository = "RESTIC_REPOSITORY"
@@ -112,7 +329,7 @@ const (
 	awsS3Endpoint    = "s3.amazonaws.com"
 )
 
-func resticArgs(profile *param.Profile, repository, encryptionKey string) []string {
+func resticArgs(profile *param.Profile, repository string) []string {
 	var cmd []string
 	switch profile.Location.Type {
 	case crv1alpha1.LocationTypeS3Compliant:
@@ -121,10 +338,136 @@ func resticArgs(profile *param.Profile, repository, encryptionKey string) []stri
 		cmd = resticGCSArgs(profile, repository)
 	case crv1alpha1.LocationTypeAzure:
 		cmd = resticAzureArgs(profile, repository)
+	case crv1alpha1.LocationTypeFileSystem:
+		cmd = resticFileSystemArgs(profile, repository)
+	case crv1alpha1.LocationTypeSFTP:
+		cmd = resticSFTPArgs(profile, repository)
 	default:
 		return nil
 	}
-	return append(cmd, fmt.Sprintf("export %s=%s\n", ResticPassword, encryptionKey), ResticCommand)
+	if profile.CACert != "" {
+		cmd = append(cmd, fmt.Sprintf("printf '%%s\\n' %s > %s\n", shellQuote(strings.TrimSpace(profile.CACert)), CACertFilePath))
+	}
+	// The credentials are exported by the script that Exec passes on stdin
+	cmd = append(cmd, fmt.Sprintf(". %s\n", stdinPath), ResticCommand)
+	if profile.Location.Type == crv1alpha1.LocationTypeSFTP {
+		cmd = append(cmd, resticSFTPCommandOption(profile))
+	}
+	if profile.CACert != "" {
+		cmd = append(cmd, "--cacert", CACertFilePath)
+	}
+	return append(cmd, resticBandwidthLimitArgs(profile.BandwidthLimit)...)
+}
+
+const stdinPath = "/dev/stdin"
+
+// resticEnv returns the script that exports the credentials of the profile,
+// its proxy and the repository password
+func resticEnv(profile *param.Profile, encryptionKey string) string {
+	var env [][2]string
+	switch profile.Location.Type {
+	case crv1alpha1.LocationTypeS3Compliant:
+		env = append(env,
+			[2]string{location.AWSAccessKeyID, profile.Credential.KeyPair.ID},
+			[2]string{location.AWSSecretAccessKey, profile.Credential.KeyPair.Secret},
+		)
+	case crv1alpha1.LocationTypeGCS:
+		env = append(env,
+			[2]string{location.GoogleProjectId, profile.Credential.KeyPair.ID},
+			[2]string{location.GoogleCloudCreds, GoogleCloudCredsFilePath},
+		)
+	case crv1alpha1.LocationTypeAzure:
+		env = append(env,
+			[2]string{location.AzureStorageAccount, profile.Credential.KeyPair.ID},
+			[2]string{location.AzureStorageKey, profile.Credential.KeyPair.Secret},
+		)
+	}
+	if profile.Proxy != "" {
+		env = append(env, [2]string{"HTTPS_PROXY", profile.Proxy}, [2]string{"HTTP_PROXY", profile.Proxy})
+	}
+	env = append(env, [2]string{ResticPassword, encryptionKey})
+	var script strings.Builder
+	for _, kv := range env {
+		fmt.Fprintf(&script, "export %s=%s\n", kv[0], shellQuote(kv[1]))
+	}
+	return script.String()
+}
+
+// secretValues returns the values of resticEnv that are redacted from logs
+// and errors
+func secretValues(profile *param.Profile, encryptionKey string) []string {
+	values := []string{encryptionKey}
+	if profile.Credential.KeyPair != nil {
+		values = append(values, profile.Credential.KeyPair.Secret)
+	}
+	if pw := profile.ProxyPassword(); pw != "" {
+		values = append(values, pw)
+	}
+	return values
+}
+
+// shellQuote quotes s for a POSIX shell
+func shellQuote(s string) string {
+	return "'" + strings.Replace(s, "'", `'\''`, -1) + "'"
+}
+
+// Exec runs a restic command built by this package in the container. The
+// credentials of the profile and the repository password are passed on stdin
+// rather than in the command, which shows up in process listings and in the
+// audit logs of the API server. They are redacted from the logged output and
+// from the returned error.
+func Exec(cli kubernetes.Interface, namespace, pod, container string, cmd []string, profile *param.Profile, encryptionKey string) (string, string, error) {
+	return execRestic(cli, namespace, pod, container, cmd, profile, encryptionKey, true)
+}
+
+// ExecJSON runs a restic command that prints JSON, such as backup --json or
+// ls --json, like Exec. The output is returned to be parsed rather than
+// logged, since it grows with the number of files and snapshots. Only the
+// errors written to stderr are logged.
+func ExecJSON(cli kubernetes.Interface, namespace, pod, container string, cmd []string, profile *param.Profile, encryptionKey string) (string, string, error) {
+	return execRestic(cli, namespace, pod, container, cmd, profile, encryptionKey, false)
+}
+
+// ExecStream runs a restic command like ExecJSON, but writes the output to
+// stdout as it is produced instead of returning it, so that the output of a
+// large repository need not be held in memory. It returns stderr.
+func ExecStream(cli kubernetes.Interface, namespace, pod, container string, cmd []string, profile *param.Profile, encryptionKey string, stdout io.Writer) (string, error) {
+	r := format.NewRedactor(secretValues(profile, encryptionKey)...)
+	_, stderr, err := kube.ExecWithOptions(cli, kube.ExecOptions{
+		Command:       cmd,
+		Namespace:     namespace,
+		PodName:       pod,
+		ContainerName: container,
+		Stdin:         strings.NewReader(resticEnv(profile, encryptionKey)),
+		CaptureStderr: true,
+		Stdout:        stdout,
+	})
+	r.Log(pod, container, stderr)
+	return stderr, r.RedactError(err)
+}
+
+func execRestic(cli kubernetes.Interface, namespace, pod, container string, cmd []string, profile *param.Profile, encryptionKey string, logStdout bool) (string, string, error) {
+	r := format.NewRedactor(secretValues(profile, encryptionKey)...)
+	stdin := strings.NewReader(resticEnv(profile, encryptionKey))
+	stdout, stderr, err := kube.Exec(cli, namespace, pod, container, cmd, stdin)
+	if logStdout {
+		r.Log(pod, container, stdout)
+	}
+	r.Log(pod, container, stderr)
+	return stdout, stderr, r.RedactError(err)
+}
+
+// resticBandwidthLimitArgs returns the options that limit the bandwidth of
+// restic in KiB/s
+func resticBandwidthLimitArgs(l crv1alpha1.BandwidthLimit) []string {
+	var args []string
+	if l.Upload > 0 {
+		args = append(args, "--limit-upload", strconv.FormatInt(l.Upload, 10))
+	}
+	if l.Download > 0 {
+		args = append(args, "--limit-download", strconv.FormatInt(l.Download, 10))
+	}
+	return args
 }
 
 func resticS3Args(profile *param.Profile, repository string) []string {
@@ -137,43 +480,121 @@ func resticS3Args(profile *param.Profile, repository string) []string {
 		s3Endpoint = strings.TrimRight(s3Endpoint, "/")
 	}
 	return []string{
-		fmt.Sprintf("export %s=%s\n", location.AWSAccessKeyID, profile.Credential.KeyPair.ID),
-		fmt.Sprintf("export %s=%s\n", location.AWSSecretAccessKey, profile.Credential.KeyPair.Secret),
 		fmt.Sprintf("export %s=s3:%s/%s\n", ResticRepository, s3Endpoint, repository),
 	}
 }
 
 func resticGCSArgs(profile *param.Profile, repository string) []string {
 	return []string{
-		fmt.Sprintf("export %s=%s\n", location.GoogleProjectId, profile.Credential.KeyPair.ID),
-		fmt.Sprintf("export %s=%s\n", location.GoogleCloudCreds, GoogleCloudCredsFilePath),
 		fmt.Sprintf("export %s=gs:%s/\n", ResticRepository, strings.Replace(repository, "/", ":/", 1)),
 	}
 }
 
 func resticAzureArgs(profile *param.Profile, repository string) []string {
 	return []string{
-		fmt.Sprintf("export %s=%s\n", location.AzureStorageAccount, profile.Credential.KeyPair.ID),
-		fmt.Sprintf("export %s=%s\n", location.AzureStorageKey, profile.Credential.KeyPair.Secret),
 		fmt.Sprintf("export %s=azure:%s/\n", ResticRepository, strings.Replace(repository, "/", ":/", 1)),
 	}
 }
 
+func resticFileSystemArgs(profile *param.Profile, repository string) []string {
+	return []string{
+		fmt.Sprintf("export %s=%s\n", ResticRepository, shellQuote(path.Join(profile.Location.Path, repository))),
+	}
+}
+
+func resticSFTPArgs(profile *param.Profile, repository string) []string {
+	cmd := []string{
+		fmt.Sprintf("export %s=%s\n", ResticRepository, shellQuote(fmt.Sprintf("sftp:%s@%s:%s", profile.Credential.KeyPair.ID, profile.Location.Host, path.Join("/", profile.Location.Path, repository)))),
+	}
+	if profile.Location.HostKey != "" {
+		knownHost := sftpKnownHost(profile.Location.Host, sftpPort(profile)) + " " + strings.TrimSpace(profile.Location.HostKey)
+		cmd = append(cmd, fmt.Sprintf("printf '%%s\\n' %s > %s\n", shellQuote(knownHost), SSHKnownHostsFilePath))
+	}
+	return cmd
+}
+
+// resticSFTPCommandOption returns the option that makes restic connect
+// with the profile's key, port and host key instead of the ssh defaults.
+func resticSFTPCommandOption(profile *param.Profile) string {
+	knownHosts := SSHKnownHostsFilePath
+	strict := "yes"
+	if profile.Location.HostKey == "" {
+		knownHosts = "/dev/null"
+		strict = "no"
+	}
+	command := fmt.Sprintf("ssh %s@%s -p %d -i %s -o IdentitiesOnly=yes -o UserKnownHostsFile=%s -o StrictHostKeyChecking=%s -s sftp",
+		profile.Credential.KeyPair.ID, profile.Location.Host, sftpPort(profile), SSHKeyFilePath, knownHosts, strict)
+	return "-o " + shellQuote("sftp.command="+command)
+}
+
+func sftpPort(profile *param.Profile) int {
+	if profile.Location.Port == 0 {
+		return 22
+	}
+	return profile.Location.Port
+}
+
+// sftpKnownHost returns the host pattern used in known_hosts files
+func sftpKnownHost(host string, port int) string {
+	if port == 22 {
+		return host
+	}
+	return fmt.Sprintf("[%s]:%d", host, port)
+}
+
+// ExecLocal runs a restic command built by this package in the current
+// container, e.g. from kando. Like Exec, it passes the credentials on stdin.
+// It writes the key files of GCS and SFTP locations, which functions write
+// with a PodWriter, and removes them when the command exits.
+func ExecLocal(ctx context.Context, cmd []string, profile *param.Profile, encryptionKey string) (string, string, error) {
+	var stdout bytes.Buffer
+	stderr, err := ExecLocalStream(ctx, cmd, profile, encryptionKey, &stdout)
+	return strings.TrimSpace(stdout.String()), stderr, err
+}
+
+// ExecLocalStream runs a restic command like ExecLocal, but writes the output
+// to stdout as it is produced instead of returning it. It returns stderr.
+func ExecLocalStream(ctx context.Context, cmd []string, profile *param.Profile, encryptionKey string, stdout io.Writer) (string, error) {
+	r := format.NewRedactor(secretValues(profile, encryptionKey)...)
+	var keyFile string
+	switch profile.Location.Type {
+	case crv1alpha1.LocationTypeGCS:
+		keyFile = GoogleCloudCredsFilePath
+	case crv1alpha1.LocationTypeSFTP:
+		keyFile = SSHKeyFilePath
+	}
+	if keyFile != "" {
+		if err := ioutil.WriteFile(keyFile, []byte(profile.Credential.KeyPair.Secret), 0600); err != nil {
+			return "", errors.Wrap(err, "Failed to write credentials")
+		}
+		defer os.Remove(keyFile) // nolint: errcheck
+	}
+	var stderr bytes.Buffer
+	c := exec.CommandContext(ctx, cmd[0], cmd[1:]...)
+	c.Stdin = strings.NewReader(resticEnv(profile, encryptionKey))
+	c.Stdout = stdout
+	c.Stderr = &stderr
+	err := c.Run()
+	if err != nil {
+		err = errors.Wrapf(err, "Failed to run restic: %s", strings.TrimSpace(stderr.String()))
+	}
+	return strings.TrimSpace(stderr.String()), r.RedactError(err)
+}
+
 // GetOrCreateRepository will check if the repository already exists and initialize one if not
 func GetOrCreateRepository(cli kubernetes.Interface, namespace, pod, container, artifactPrefix, encryptionKey string, profile *param.Profile) error {
 	// Use the snapshots command to check if the repository exists
-	cmd := SnapshotsCommand(profile, artifactPrefix, encryptionKey)
-	stdout, stderr, err := kube.Exec(cli, namespace, pod, container, cmd, nil)
-	format.Log(pod, container, stdout)
-	format.Log(pod, container, stderr)
-	if err == nil {
+	cmd := SnapshotsCommand(profile, artifactPrefix)
+	if _, _, err := Exec(cli, namespace, pod, container, cmd, profile, encryptionKey); err == nil {
 		return nil
 	}
-	// Create a repository
-	cmd = InitCommand(profile, artifactPrefix, encryptionKey)
-	stdout, stderr, err = kube.Exec(cli, namespace, pod, container, cmd, nil)
-	format.Log(pod, container, stdout)
-	format.Log(pod, container, stderr)
+	return CreateRepository(cli, namespace, pod, container, artifactPrefix, encryptionKey, profile)
+}
+
+// CreateRepository creates a repository. It fails if the repository exists.
+func CreateRepository(cli kubernetes.Interface, namespace, pod, container, artifactPrefix, encryptionKey string, profile *param.Profile) error {
+	cmd := InitCommand(profile, artifactPrefix)
+	_, _, err := Exec(cli, namespace, pod, container, cmd, profile, encryptionKey)
 	return errors.Wrapf(err, "Failed to create object store backup location")
 }
 
@@ -191,11 +612,40 @@ func SnapshotIDFromSnapshotLog(output string) (string, error) {
 	return snapId.(string), nil
 }
 
-// SnapshotIDFromBackupLog gets the SnapshotID from Backup Command log
+// SnapshotCountFromSnapshotLog gets the number of snapshots from Snapshot
+// Command log
+func SnapshotCountFromSnapshotLog(output string) (int, error) {
+	var result []map[string]interface{}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0, errors.WithMessage(err, "Failed to unmarshall output from snapshotCommand")
+	}
+	return len(result), nil
+}
+
+// RepositorySizeFromStatsLog gets the size of the data in the repository
+// from Stats Command log
+func RepositorySizeFromStatsLog(output string) (int64, error) {
+	var result struct {
+		TotalSize *int64 `json:"total_size"`
+	}
+	if err := json.Unmarshal([]byte(output), &result); err != nil {
+		return 0, errors.WithMessage(err, "Failed to unmarshall output from statsCommand")
+	}
+	if result.TotalSize == nil {
+		return 0, errors.New("Repository size not found")
+	}
+	return *result.TotalSize, nil
+}
+
+// SnapshotIDFromBackupLog gets the short SnapshotID from Backup Command log,
+// written with or without --json
 func SnapshotIDFromBackupLog(output string) string {
 	if output == "" {
 		return ""
 	}
+	if summary, err := ParseBackupOutput(output, nil); err == nil {
+		return summary.ShortSnapshotID()
+	}
 	logs := regexp.MustCompile("[\n]").Split(output, -1)
 	for _, l := range logs {
 		// Log should contain "snapshot ABC123 saved"
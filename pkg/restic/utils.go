@@ -9,7 +9,9 @@ const (
 	password = "testpassword"
 )
 
-// GeneratePassword generates a password
+// GeneratePassword returns the fixed password that older versions used for
+// repositories without an encryption key. It is the same everywhere and is
+// only meant to access repositories that were created with it.
 func GeneratePassword() string {
 	h := sha256.New()
 	h.Write([]byte(password))
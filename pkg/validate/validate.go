@@ -2,12 +2,15 @@ package validate
 
 import (
 	"context"
+	"net/url"
 	"strings"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	crv1alpha1 "github.com/kanisterio/kanister/pkg/apis/cr/v1alpha1"
+	"github.com/kanisterio/kanister/pkg/datamover"
+	"github.com/kanisterio/kanister/pkg/location"
 	"github.com/kanisterio/kanister/pkg/objectstore"
 	"github.com/kanisterio/kanister/pkg/param"
 )
@@ -121,6 +124,41 @@ func ProfileSchema(p *crv1alpha1.Profile) error {
 	if !supported(p.Location.Type) {
 		return errorf("unknown or unsupported location type '%s'", p.Location.Type)
 	}
+	if err := serverSideEncryption(p.Location); err != nil {
+		return err
+	}
+	if err := retention(p.Location); err != nil {
+		return err
+	}
+	if p.BandwidthLimit.Upload < 0 || p.BandwidthLimit.Download < 0 {
+		return errorf("bandwidth limits must not be negative")
+	}
+	if err := caBundle(p); err != nil {
+		return err
+	}
+	if p.RepositoryPasswordSecret != nil && p.RepositoryPasswordSecret.Name == "" {
+		return errorf("secret for repository passwords not specified")
+	}
+	if !datamover.Supported(datamover.Type(p.DataMover)) {
+		return errorf("unsupported data mover %s", p.DataMover)
+	}
+	if p.Encryption != nil && (p.Encryption.Secret.Name == "" || p.Encryption.KeyID == "") {
+		return errorf("secret or key ID for client-side encryption not specified")
+	}
+	if p.Location.Type == crv1alpha1.LocationTypeFileSystem {
+		if p.Location.Path == "" {
+			return errorf("path for filesystem location not specified")
+		}
+		return nil
+	}
+	if p.Location.Type == crv1alpha1.LocationTypeSFTP {
+		if p.Location.Host == "" || p.Location.Path == "" {
+			return errorf("host or path for sftp location not specified")
+		}
+		if p.Location.HostKey == "" && !p.SkipSSLVerify {
+			return errorf("host key for sftp location not specified")
+		}
+	}
 	if p.Credential.Type != crv1alpha1.CredentialTypeKeyPair {
 		return errorf("unknown or unsupported credential type '%s'", p.Credential.Type)
 	}
@@ -138,8 +176,88 @@ func ProfileSchema(p *crv1alpha1.Profile) error {
 	return nil
 }
 
+func serverSideEncryption(l crv1alpha1.Location) error {
+	sse := l.ServerSideEncryption
+	if sse == (crv1alpha1.ServerSideEncryption{}) {
+		return nil
+	}
+	switch l.Type {
+	case crv1alpha1.LocationTypeS3Compliant:
+		switch objectstore.SSEType(sse.Type) {
+		case "", objectstore.SSETypeS3, objectstore.SSETypeKMS:
+		default:
+			return errorf("unknown or unsupported server-side encryption type '%s'", sse.Type)
+		}
+		if sse.KMSKeyID != "" && objectstore.SSEType(sse.Type) == objectstore.SSETypeS3 {
+			return errorf("KMS key ID requires server-side encryption type '%s'", objectstore.SSETypeKMS)
+		}
+		if sse.EncryptionScope != "" {
+			return errorf("encryption scope is only supported for azure locations")
+		}
+	case crv1alpha1.LocationTypeGCS:
+		if sse.KMSKeyID == "" || sse.Type != "" || sse.EncryptionScope != "" {
+			return errorf("only a KMS key name is supported for gcs server-side encryption")
+		}
+	case crv1alpha1.LocationTypeAzure:
+		if sse.EncryptionScope == "" || sse.Type != "" || sse.KMSKeyID != "" {
+			return errorf("only an encryption scope is supported for azure server-side encryption")
+		}
+	default:
+		return errorf("server-side encryption is not supported for location type '%s'", l.Type)
+	}
+	return nil
+}
+
+func retention(l crv1alpha1.Location) error {
+	r := l.Retention
+	if r == (crv1alpha1.Retention{}) {
+		return nil
+	}
+	switch l.Type {
+	case crv1alpha1.LocationTypeS3Compliant, crv1alpha1.LocationTypeGCS, crv1alpha1.LocationTypeAzure:
+	default:
+		return errorf("retention is not supported for location type '%s'", l.Type)
+	}
+	switch r.Mode {
+	case crv1alpha1.RetentionModeGovernance, crv1alpha1.RetentionModeCompliance:
+	default:
+		return errorf("unknown or unsupported retention mode '%s'", r.Mode)
+	}
+	if r.Duration.Duration <= 0 {
+		return errorf("retention duration must be positive")
+	}
+	return nil
+}
+
+func caBundle(p *crv1alpha1.Profile) error {
+	if p.CABundle != nil {
+		b := p.CABundle
+		if (b.Secret == nil) == (b.ConfigMap == nil) {
+			return errorf("CA bundle must reference either a secret or a config map")
+		}
+		if b.Key == "" {
+			return errorf("key of the CA bundle not specified")
+		}
+	}
+	if p.Proxy != "" {
+		u, err := url.Parse(p.Proxy)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return errorf("invalid proxy URL '%s'", p.Proxy)
+		}
+	}
+	if p.CABundle == nil && p.Proxy == "" {
+		return nil
+	}
+	switch p.Location.Type {
+	case crv1alpha1.LocationTypeS3Compliant, crv1alpha1.LocationTypeGCS, crv1alpha1.LocationTypeAzure:
+		return nil
+	default:
+		return errorf("CA bundle and proxy are not supported for location type '%s'", p.Location.Type)
+	}
+}
+
 func supported(t crv1alpha1.LocationType) bool {
-	return t == crv1alpha1.LocationTypeS3Compliant || t == crv1alpha1.LocationTypeGCS || t == crv1alpha1.LocationTypeAzure
+	return t == crv1alpha1.LocationTypeS3Compliant || t == crv1alpha1.LocationTypeGCS || t == crv1alpha1.LocationTypeAzure || t == crv1alpha1.LocationTypeFileSystem || t == crv1alpha1.LocationTypeSFTP
 }
 
 func ProfileBucket(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Interface) error {
@@ -150,7 +268,11 @@ func ProfileBucket(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.In
 	case crv1alpha1.LocationTypeS3Compliant:
 		givenRegion := p.Location.Region
 		if givenRegion != "" {
-			actualRegion, err := objectstore.GetS3BucketRegion(ctx, bucketName, givenRegion)
+			pc, err := providerConfig(ctx, objectstore.ProviderTypeS3, p, cli)
+			if err != nil {
+				return err
+			}
+			actualRegion, err := objectstore.GetS3BucketRegionForConfig(ctx, pc, bucketName, givenRegion)
 			if err != nil {
 				return err
 			}
@@ -158,15 +280,27 @@ func ProfileBucket(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.In
 				return errorf("Incorrect region for bucket. Expected '%s', Got '%s'", actualRegion, givenRegion)
 			}
 		}
-		return nil
+		if p.Location.ServerSideEncryption == (crv1alpha1.ServerSideEncryption{}) && p.Location.Retention == (crv1alpha1.Retention{}) {
+			return nil
+		}
+		pType = objectstore.ProviderTypeS3
 	case crv1alpha1.LocationTypeGCS:
 		pType = objectstore.ProviderTypeGCS
 	case crv1alpha1.LocationTypeAzure:
 		pType = objectstore.ProviderTypeAzure
+	case crv1alpha1.LocationTypeFileSystem:
+		_, err := fileSystemBucket(ctx, p)
+		return err
+	case crv1alpha1.LocationTypeSFTP:
+		pType = objectstore.ProviderTypeSFTP
 	default:
 		return errorf("unknown or unsupported location type '%s'", p.Location.Type)
 	}
-	pc := objectstore.ProviderConfig{Type: pType}
+	pc, err := providerConfig(ctx, pType, p, cli)
+	if err != nil {
+		return err
+	}
+	pc.Retention = location.Retention(p.Location)
 	secret, err := osSecretFromProfile(pType, p, cli)
 	if err != nil {
 		return err
@@ -175,11 +309,18 @@ func ProfileBucket(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.In
 	if err != nil {
 		return err
 	}
-	_, err = provider.GetBucket(ctx, bucketName)
+	bucket, err := provider.GetBucket(ctx, bucketName)
 	if err != nil {
 		return err
 	}
-	return nil
+	// Restic repositories depend on the default encryption of the bucket
+	if err := objectstore.CheckBucketEncryption(ctx, bucket); err != nil {
+		return err
+	}
+	if pc.Retention.IsZero() {
+		return nil
+	}
+	return objectstore.CheckBucketRetention(ctx, bucket)
 }
 
 func ReadAccess(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Interface) error {
@@ -193,6 +334,17 @@ func ReadAccess(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Inter
 		pType = objectstore.ProviderTypeGCS
 	case crv1alpha1.LocationTypeAzure:
 		pType = objectstore.ProviderTypeAzure
+	case crv1alpha1.LocationTypeFileSystem:
+		bucket, err := fileSystemBucket(ctx, p)
+		if err != nil {
+			return err
+		}
+		if _, err := bucket.ListDirectories(ctx); err != nil {
+			return errorf("failed to list directories in '%s'", bucket)
+		}
+		return nil
+	case crv1alpha1.LocationTypeSFTP:
+		pType = objectstore.ProviderTypeSFTP
 	default:
 		return errorf("unknown or unsupported location type '%s'", p.Location.Type)
 	}
@@ -200,10 +352,9 @@ func ReadAccess(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Inter
 	if err != nil {
 		return err
 	}
-	pc := objectstore.ProviderConfig{
-		Type:          pType,
-		Endpoint:      p.Location.Endpoint,
-		SkipSSLVerify: p.SkipSSLVerify,
+	pc, err := providerConfig(ctx, pType, p, cli)
+	if err != nil {
+		return err
 	}
 	provider, err := objectstore.NewProvider(ctx, pc, secret)
 	if err != nil {
@@ -230,6 +381,20 @@ func WriteAccess(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Inte
 		pType = objectstore.ProviderTypeGCS
 	case crv1alpha1.LocationTypeAzure:
 		pType = objectstore.ProviderTypeAzure
+	case crv1alpha1.LocationTypeFileSystem:
+		bucket, err := fileSystemBucket(ctx, p)
+		if err != nil {
+			return err
+		}
+		if err := bucket.PutBytes(ctx, sampleObjectName, []byte("sample content"), nil); err != nil {
+			return errorf("failed to write contents to '%s'", bucket)
+		}
+		if err := bucket.Delete(ctx, sampleObjectName); err != nil {
+			return errorf("failed to delete contents in '%s'", bucket)
+		}
+		return nil
+	case crv1alpha1.LocationTypeSFTP:
+		pType = objectstore.ProviderTypeSFTP
 	default:
 		return errorf("unknown or unsupported location type '%s'", p.Location.Type)
 	}
@@ -237,12 +402,9 @@ func WriteAccess(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Inte
 	if err != nil {
 		return err
 	}
-	const objName = "sample"
-
-	pc := objectstore.ProviderConfig{
-		Type:          pType,
-		Endpoint:      p.Location.Endpoint,
-		SkipSSLVerify: p.SkipSSLVerify,
+	pc, err := providerConfig(ctx, pType, p, cli)
+	if err != nil {
+		return err
 	}
 	provider, err := objectstore.NewProvider(ctx, pc, secret)
 	if err != nil {
@@ -253,15 +415,63 @@ func WriteAccess(ctx context.Context, p *crv1alpha1.Profile, cli kubernetes.Inte
 		return err
 	}
 	data := []byte("sample content")
-	if err := bucket.PutBytes(ctx, objName, data, nil); err != nil {
+	if err := bucket.PutBytes(ctx, sampleObjectName, data, nil); err != nil {
 		return errorf("failed to write contents to bucket '%s'", p.Location.Bucket)
 	}
-	if err := bucket.Delete(ctx, objName); err != nil {
+	if err := objectstore.CheckServerSideEncryption(ctx, bucket, sampleObjectName); err != nil {
+		bucket.Delete(ctx, sampleObjectName)
+		return errorf("contents written to bucket '%s' are not encrypted as specified: %s", p.Location.Bucket, err)
+	}
+	if err := bucket.Delete(ctx, sampleObjectName); err != nil {
 		return errorf("failed to delete contents in bucket '%s'", p.Location.Bucket)
 	}
 	return nil
 }
 
+// sampleObjectName is the object that is written to check write access
+const sampleObjectName = "sample"
+
+// fileSystemBucket returns the bucket of a filesystem location. The location
+// is only mounted in the Pods that use it, so it can only be checked where
+// it is mounted at its path as well.
+func fileSystemBucket(ctx context.Context, p *crv1alpha1.Profile) (objectstore.Bucket, error) {
+	pc := objectstore.ProviderConfig{
+		Type:     objectstore.ProviderTypeFileSystem,
+		Endpoint: p.Location.Path,
+	}
+	provider, err := objectstore.NewProvider(ctx, pc, nil)
+	if err != nil {
+		return nil, err
+	}
+	bucket, err := provider.GetBucket(ctx, p.Location.Bucket)
+	if err != nil {
+		return nil, errorf("bucket '%s' not found under path '%s'; the filesystem location must be mounted at its path: %s", p.Location.Bucket, p.Location.Path, err)
+	}
+	return bucket, nil
+}
+
+func providerConfig(ctx context.Context, pType objectstore.ProviderType, p *crv1alpha1.Profile, cli kubernetes.Interface) (objectstore.ProviderConfig, error) {
+	pc := objectstore.ProviderConfig{
+		Type:          pType,
+		Endpoint:      p.Location.Endpoint,
+		SkipSSLVerify: p.SkipSSLVerify,
+		Encryption:    location.ServerSideEncryption(p.Location),
+		Proxy:         p.Proxy,
+	}
+	if pType == objectstore.ProviderTypeSFTP {
+		pc.Endpoint = objectstore.SFTPEndpoint(p.Location.Host, p.Location.Port, p.Location.Path)
+		pc.HostKey = p.Location.HostKey
+	}
+	if p.CABundle != nil {
+		caCert, err := param.FetchCABundle(ctx, cli, p.CABundle)
+		if err != nil {
+			return pc, errorf("could not fetch the CA bundle: %s", err)
+		}
+		pc.CACert = caCert
+	}
+	return pc, nil
+}
+
 func osSecretFromProfile(pType objectstore.ProviderType, p *crv1alpha1.Profile, cli kubernetes.Interface) (*objectstore.Secret, error) {
 	var key, value []byte
 	var ok bool
@@ -299,6 +509,12 @@ func osSecretFromProfile(pType objectstore.ProviderType, p *crv1alpha1.Profile,
 			StorageAccount: string(key),
 			StorageKey:     string(value),
 		}
+	case objectstore.ProviderTypeSFTP:
+		secret.Type = objectstore.SecretTypeSSHKey
+		secret.SSH = &objectstore.SecretSSH{
+			Username:   string(key),
+			PrivateKey: string(value),
+		}
 	default:
 		return nil, errorf("unknown or unsupported provider type '%s'", pType)
 	}
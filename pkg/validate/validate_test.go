@@ -1,8 +1,13 @@
 package validate
 
 import (
-	"github.com/kanisterio/kanister/pkg/param"
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
+
+	"github.com/kanisterio/kanister/pkg/param"
 
 	. "gopkg.in/check.v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -466,3 +471,277 @@ func (s *ValidateSuite) TestBlueprint(c *C) {
 	err := Blueprint(nil)
 	c.Assert(err, IsNil)
 }
+
+func (s *ValidateSuite) TestProfileSchemaServerSideEncryption(c *C) {
+	for _, tc := range []struct {
+		lType   crv1alpha1.LocationType
+		sse     crv1alpha1.ServerSideEncryption
+		checker Checker
+	}{
+		{
+			lType:   crv1alpha1.LocationTypeS3Compliant,
+			sse:     crv1alpha1.ServerSideEncryption{},
+			checker: IsNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeS3Compliant,
+			sse:     crv1alpha1.ServerSideEncryption{Type: "AES256"},
+			checker: IsNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeS3Compliant,
+			sse:     crv1alpha1.ServerSideEncryption{Type: "aws:kms", KMSKeyID: "key"},
+			checker: IsNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeS3Compliant,
+			sse:     crv1alpha1.ServerSideEncryption{Type: "AES256", KMSKeyID: "key"},
+			checker: NotNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeS3Compliant,
+			sse:     crv1alpha1.ServerSideEncryption{Type: "invalid"},
+			checker: NotNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeGCS,
+			sse:     crv1alpha1.ServerSideEncryption{KMSKeyID: "projects/p/locations/l/keyRings/r/cryptoKeys/k"},
+			checker: IsNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeGCS,
+			sse:     crv1alpha1.ServerSideEncryption{EncryptionScope: "scope"},
+			checker: NotNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeAzure,
+			sse:     crv1alpha1.ServerSideEncryption{EncryptionScope: "scope"},
+			checker: IsNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeAzure,
+			sse:     crv1alpha1.ServerSideEncryption{Type: "AES256"},
+			checker: NotNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeFileSystem,
+			sse:     crv1alpha1.ServerSideEncryption{Type: "AES256"},
+			checker: NotNil,
+		},
+	} {
+		p := &crv1alpha1.Profile{
+			Location: crv1alpha1.Location{
+				Type:                 tc.lType,
+				Endpoint:             "endpoint",
+				Path:                 "/mnt/data",
+				ServerSideEncryption: tc.sse,
+			},
+			Credential: crv1alpha1.Credential{
+				Type: crv1alpha1.CredentialTypeKeyPair,
+				KeyPair: &crv1alpha1.KeyPair{
+					IDField:     "id",
+					SecretField: "secret",
+					Secret: crv1alpha1.ObjectReference{
+						Name: "secret",
+					},
+				},
+			},
+		}
+		err := ProfileSchema(p)
+		c.Check(err, tc.checker, Commentf("%s %+v", tc.lType, tc.sse))
+	}
+}
+
+func (s *ValidateSuite) TestProfileSchemaRetention(c *C) {
+	day := metav1.Duration{Duration: 24 * time.Hour}
+	for _, tc := range []struct {
+		lType     crv1alpha1.LocationType
+		retention crv1alpha1.Retention
+		checker   Checker
+	}{
+		{
+			lType:     crv1alpha1.LocationTypeS3Compliant,
+			retention: crv1alpha1.Retention{},
+			checker:   IsNil,
+		},
+		{
+			lType:     crv1alpha1.LocationTypeS3Compliant,
+			retention: crv1alpha1.Retention{Mode: crv1alpha1.RetentionModeGovernance, Duration: day},
+			checker:   IsNil,
+		},
+		{
+			lType:     crv1alpha1.LocationTypeGCS,
+			retention: crv1alpha1.Retention{Mode: crv1alpha1.RetentionModeCompliance, Duration: day},
+			checker:   IsNil,
+		},
+		{
+			lType:     crv1alpha1.LocationTypeAzure,
+			retention: crv1alpha1.Retention{Mode: crv1alpha1.RetentionModeCompliance, Duration: day},
+			checker:   IsNil,
+		},
+		{
+			lType:     crv1alpha1.LocationTypeS3Compliant,
+			retention: crv1alpha1.Retention{Mode: "invalid", Duration: day},
+			checker:   NotNil,
+		},
+		{
+			lType:     crv1alpha1.LocationTypeS3Compliant,
+			retention: crv1alpha1.Retention{Mode: crv1alpha1.RetentionModeGovernance},
+			checker:   NotNil,
+		},
+		{
+			lType:     crv1alpha1.LocationTypeFileSystem,
+			retention: crv1alpha1.Retention{Mode: crv1alpha1.RetentionModeGovernance, Duration: day},
+			checker:   NotNil,
+		},
+	} {
+		p := &crv1alpha1.Profile{
+			Location: crv1alpha1.Location{
+				Type:      tc.lType,
+				Endpoint:  "endpoint",
+				Path:      "/mnt/data",
+				Retention: tc.retention,
+			},
+			Credential: crv1alpha1.Credential{
+				Type: crv1alpha1.CredentialTypeKeyPair,
+				KeyPair: &crv1alpha1.KeyPair{
+					IDField:     "id",
+					SecretField: "secret",
+					Secret: crv1alpha1.ObjectReference{
+						Name: "secret",
+					},
+				},
+			},
+		}
+		err := ProfileSchema(p)
+		c.Check(err, tc.checker, Commentf("%s %+v", tc.lType, tc.retention))
+	}
+}
+
+func (s *ValidateSuite) TestProfileSchemaCABundle(c *C) {
+	ref := &crv1alpha1.ObjectReference{Name: "ca", Namespace: "kanister"}
+	for _, tc := range []struct {
+		lType    crv1alpha1.LocationType
+		caBundle *crv1alpha1.CABundle
+		proxy    string
+		checker  Checker
+	}{
+		{
+			lType:   crv1alpha1.LocationTypeS3Compliant,
+			checker: IsNil,
+		},
+		{
+			lType:    crv1alpha1.LocationTypeS3Compliant,
+			caBundle: &crv1alpha1.CABundle{Secret: ref, Key: "ca.crt"},
+			proxy:    "http://proxy.example.com:3128",
+			checker:  IsNil,
+		},
+		{
+			lType:    crv1alpha1.LocationTypeGCS,
+			caBundle: &crv1alpha1.CABundle{ConfigMap: ref, Key: "ca.crt"},
+			checker:  IsNil,
+		},
+		{
+			lType:    crv1alpha1.LocationTypeS3Compliant,
+			caBundle: &crv1alpha1.CABundle{Secret: ref, ConfigMap: ref, Key: "ca.crt"},
+			checker:  NotNil,
+		},
+		{
+			lType:    crv1alpha1.LocationTypeS3Compliant,
+			caBundle: &crv1alpha1.CABundle{Key: "ca.crt"},
+			checker:  NotNil,
+		},
+		{
+			lType:    crv1alpha1.LocationTypeS3Compliant,
+			caBundle: &crv1alpha1.CABundle{Secret: ref},
+			checker:  NotNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeAzure,
+			proxy:   "proxy.example.com:3128",
+			checker: NotNil,
+		},
+		{
+			lType:   crv1alpha1.LocationTypeFileSystem,
+			proxy:   "http://proxy.example.com:3128",
+			checker: NotNil,
+		},
+	} {
+		p := &crv1alpha1.Profile{
+			Location: crv1alpha1.Location{
+				Type:     tc.lType,
+				Endpoint: "endpoint",
+				Path:     "/mnt/data",
+			},
+			Credential: crv1alpha1.Credential{
+				Type: crv1alpha1.CredentialTypeKeyPair,
+				KeyPair: &crv1alpha1.KeyPair{
+					IDField:     "id",
+					SecretField: "secret",
+					Secret: crv1alpha1.ObjectReference{
+						Name: "secret",
+					},
+				},
+			},
+			CABundle: tc.caBundle,
+			Proxy:    tc.proxy,
+		}
+		err := ProfileSchema(p)
+		c.Check(err, tc.checker, Commentf("%s %+v %s", tc.lType, tc.caBundle, tc.proxy))
+	}
+}
+
+func (s *ValidateSuite) TestProfileSchemaDataMover(c *C) {
+	for _, tc := range []struct {
+		dataMover string
+		checker   Checker
+	}{
+		{dataMover: "", checker: IsNil},
+		{dataMover: "Restic", checker: IsNil},
+		{dataMover: "Tar", checker: NotNil},
+	} {
+		p := &crv1alpha1.Profile{
+			Location: crv1alpha1.Location{
+				Type:     crv1alpha1.LocationTypeS3Compliant,
+				Endpoint: "endpoint",
+			},
+			Credential: crv1alpha1.Credential{
+				Type: crv1alpha1.CredentialTypeKeyPair,
+				KeyPair: &crv1alpha1.KeyPair{
+					IDField:     "id",
+					SecretField: "secret",
+					Secret: crv1alpha1.ObjectReference{
+						Name: "secret",
+					},
+				},
+			},
+			DataMover: tc.dataMover,
+		}
+		err := ProfileSchema(p)
+		c.Check(err, tc.checker, Commentf("%s", tc.dataMover))
+	}
+}
+
+func (s *ValidateSuite) TestFileSystemLocation(c *C) {
+	ctx := context.Background()
+	root := c.MkDir()
+	c.Assert(os.Mkdir(filepath.Join(root, "bucket"), 0755), IsNil)
+	p := &crv1alpha1.Profile{
+		Location: crv1alpha1.Location{
+			Type:   crv1alpha1.LocationTypeFileSystem,
+			Path:   root,
+			Bucket: "bucket",
+		},
+	}
+	c.Assert(ProfileBucket(ctx, p, nil), IsNil)
+	c.Assert(ReadAccess(ctx, p, nil), IsNil)
+	c.Assert(WriteAccess(ctx, p, nil), IsNil)
+	_, err := os.Stat(filepath.Join(root, "bucket", "sample"))
+	c.Assert(os.IsNotExist(err), Equals, true)
+
+	// The location is not mounted
+	p.Location.Path = filepath.Join(root, "missing")
+	c.Assert(ProfileBucket(ctx, p, nil), ErrorMatches, "bucket 'bucket' not found under path .*")
+	c.Assert(ReadAccess(ctx, p, nil), NotNil)
+	c.Assert(WriteAccess(ctx, p, nil), NotNil)
+}